@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -35,6 +36,7 @@ import (
 	"k8s.io/klog/v2"
 	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
 
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/fakenvml"
 	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/info"
 	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/plugin"
 	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
@@ -42,12 +44,16 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 	flagutil "github.com/Project-HAMi/HAMi/pkg/util/flag"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 type options struct {
 	flags         []cli.Flag
 	configFile    string
 	kubeletSocket string
+	// fakeNVML is the simulated NVML the plugins run against, if any. It is
+	// kept across restarts so that MIG instances and reported XIDs persist.
+	fakeNVML *fakenvml.Server
 }
 
 func main() {
@@ -195,6 +201,7 @@ func main() {
 		},
 	}
 	c.Flags = append(c.Flags, addFlags()...)
+	c.Flags = append(c.Flags, tracing.CliFlags()...)
 	o.flags = c.Flags
 	err := c.Run(os.Args)
 	if err != nil {
@@ -263,6 +270,26 @@ func start(c *cli.Context, o *options) error {
 	util.NodeName = os.Getenv(util.NodeNameEnvName)
 	client.InitGlobalClient()
 
+	shutdownTracing, err := tracing.Init(c.Context, "hami-device-plugin", tracing.OptionsFromCli(c))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+
+	if addr := c.String("metrics-bind-address"); addr != "" {
+		defer serveMetrics(addr)()
+	}
+
+	if path := c.String("fake-nvml-topology"); path != "" {
+		topology, err := fakenvml.LoadTopology(path)
+		if err != nil {
+			return fmt.Errorf("unable to load fake NVML topology: %v", err)
+		}
+		klog.Warningf("Running against a simulated NVML with %d GPUs from %s", len(topology.GPUs), path)
+		o.fakeNVML = fakenvml.New(topology)
+		o.fakeNVML.Install()
+	}
+
 	kubeletSocketDir := filepath.Dir(o.kubeletSocket)
 	klog.Infof("Starting FS watcher for %v", kubeletSocketDir)
 	watcher, err := watch.Files(kubeletSocketDir)
@@ -363,14 +390,19 @@ func startPlugins(c *cli.Context, o *options) ([]plugin.Interface, bool, error)
 	driverRoot := root(*config.Flags.Plugin.ContainerDriverRoot)
 	// We construct an NVML library specifying the path to libnvidia-ml.so.1
 	// explicitly so that we don't have to rely on the library path.
-	nvmllib := nvml.New(
+	var nvmllib nvml.Interface = nvml.New(
 		nvml.WithLibraryPath(driverRoot.tryResolveLibrary("libnvidia-ml.so.1")),
 	)
+	var infoOpts []nvinfo.Option
+	if o.fakeNVML != nil {
+		nvmllib = o.fakeNVML
+		infoOpts = append(infoOpts, nvinfo.WithPropertyExtractor(fakenvml.PropertyExtractor()))
+	}
 	devicelib := device.New(nvmllib)
-	infolib := nvinfo.New(
+	infolib := nvinfo.New(append(infoOpts,
 		nvinfo.WithNvmlLib(nvmllib),
 		nvinfo.WithDeviceLib(devicelib),
-	)
+	)...)
 
 	err = validateFlags(infolib, config)
 	if err != nil {
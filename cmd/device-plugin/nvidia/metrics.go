@@ -0,0 +1,52 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/plugin"
+	versionmetrics "github.com/Project-HAMi/HAMi/pkg/metrics"
+)
+
+// serveMetrics serves the device plugin's prometheus metrics on addr until
+// the returned function is called.
+func serveMetrics(addr string) func() {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(versionmetrics.NewBuildInfoCollector())
+	reg.MustRegister(plugin.XIDEventsTotal)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 15 * time.Second, ReadTimeout: 60 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("Failed to serve metrics: %v", err)
+		}
+	}()
+	return func() {
+		if err := server.Shutdown(context.Background()); err != nil {
+			klog.Errorf("Failed to shut down metrics server: %v", err)
+		}
+	}
+}
@@ -63,6 +63,24 @@ func addFlags() []cli.Flag {
 			Usage:   "If set, the core utilization limit will be ignored",
 			EnvVars: []string{"DISABLE_CORE_LIMIT"},
 		},
+		&cli.StringFlag{
+			Name:    "pod-resources-socket",
+			Value:   "",
+			Usage:   "the kubelet PodResources API socket used to tell which pod an Allocate call is for, so that several pods on the node are allocated concurrently (e.g. /var/lib/kubelet/pod-resources/kubelet.sock). Empty relies on the pending pod lookup alone",
+			EnvVars: []string{"POD_RESOURCES_SOCKET"},
+		},
+		&cli.StringFlag{
+			Name:    "metrics-bind-address",
+			Value:   ":9396",
+			Usage:   "The TCP address that the device plugin should bind to for serving prometheus metrics(e.g. 127.0.0.1:9396, :9396). Empty disables metrics",
+			EnvVars: []string{"METRICS_BIND_ADDRESS"},
+		},
+		&cli.StringFlag{
+			Name:    "fake-nvml-topology",
+			Value:   "",
+			Usage:   "run against a simulated NVML with the GPUs described in this YAML topology file instead of the driver, for testing on nodes without GPUs. Empty uses the driver",
+			EnvVars: []string{"FAKE_NVML_TOPOLOGY"},
+		},
 		&cli.StringFlag{
 			Name:  "resource-name",
 			Value: "nvidia.com/gpu",
@@ -103,6 +121,9 @@ func generateDeviceConfigFromNvidia(cfg *spec.Config, c *cli.Context, flags []cl
 			if strings.Compare(n, "config-file") == 0 {
 				updateFromCLIFlag(&plugin.ConfigFile, c, n)
 			}
+			if strings.Compare(n, "pod-resources-socket") == 0 {
+				updateFromCLIFlag(&plugin.PodResourcesSocket, c, n)
+			}
 		}
 	}
 
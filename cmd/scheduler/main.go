@@ -38,6 +38,7 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 	"github.com/Project-HAMi/HAMi/pkg/util/flag"
 	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 	"github.com/Project-HAMi/HAMi/pkg/version"
 )
 
@@ -49,6 +50,7 @@ var (
 	tlsCertFile     string
 	enableProfiling bool
 	legacyMetrics   bool
+	tracingOptions  tracing.Options
 	rootCmd         = &cobra.Command{
 		Use:   "scheduler",
 		Short: "kubernetes vgpu scheduler",
@@ -77,16 +79,22 @@ func init() {
 	rootCmd.Flags().IntVar(&config.Timeout, "kube-timeout", client.DefaultTimeout, "Timeout to use while talking with kube-apiserver.")
 	rootCmd.Flags().BoolVar(&enableProfiling, "profiling", false, "Enable pprof profiling via HTTP server")
 	rootCmd.Flags().DurationVar(&config.NodeLockTimeout, "node-lock-timeout", time.Minute*5, "timeout for node locks")
+	rootCmd.Flags().DurationVar(&config.BindPhaseTimeout, "bind-phase-timeout", 5*time.Minute, "how long a bound pod may wait for the device plugin to allocate its devices before it is marked failed and its node locks and devices are released (0 disables)")
+	rootCmd.Flags().BoolVar(&config.BindTimeoutDeletePod, "bind-timeout-delete-pod", false, "also delete pods whose device allocation timed out, so that their controller recreates them; pods without a controller are kept")
 	rootCmd.Flags().DurationVar(&config.NodeLockRetryTimeout, "node-lock-retry-timeout", 28*time.Second, "timeout for retrying LockNode when contended by another PodGroup member (0 disables retry). Align the Extender's httpTimeout in KubeSchedulerConfiguration with this value.")
+	rootCmd.Flags().StringVar(&config.NamespaceProfileConfigMap, "namespace-profile-configmap", "", "<namespace>/<name> of a ConfigMap with per-namespace GPU request profiles keyed by namespace name")
+	rootCmd.Flags().StringVar(&config.ChargebackPriceTable, "chargeback-price-table", "", "YAML file with the weights and per device type prices of GPU-hours used by the /chargeback report on the metrics address. Pods that ended are kept in memory only, so the report covers those this scheduler saw end since it started")
 	rootCmd.Flags().BoolVar(&config.ForceOverwriteDefaultScheduler, "force-overwrite-default-scheduler", true, "Overwrite schedulerName in Pod Spec when set to the const DefaultSchedulerName in https://k8s.io/api/core/v1 package")
 
 	rootCmd.Flags().BoolVar(&config.LeaderElect, "leader-elect", false, "The pod of hami-scheduler enable leader select")
 	rootCmd.Flags().StringVar(&config.LeaderElectResourceName, "leader-elect-resource-name", "", "The name of resource object that is used for leader election")
 	rootCmd.Flags().StringVar(&config.LeaderElectResourceNamespace, "leader-elect-resource-namespace", "", "The namespace of resource object that is used for leader election")
 	rootCmd.Flags().BoolVar(&legacyMetrics, "legacy-metrics", false, "Emit legacy metric names alongside new ones for backward compatibility")
+	tracingOptions.AddFlags(rootCmd.Flags())
 
 	rootCmd.PersistentFlags().AddGoFlagSet(config.GlobalFlagSet())
 	rootCmd.AddCommand(version.VersionCmd)
+	rootCmd.AddCommand(validateConfigCmd)
 	rootCmd.Flags().AddGoFlagSet(util.InitKlogFlags())
 }
 
@@ -121,7 +129,12 @@ func start() error {
 
 	config.InitDevices()
 
-	var err error
+	shutdownTracing, err := tracing.Init(context.Background(), "hami-scheduler", tracingOptions)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+
 	config.HostName, err = os.Hostname()
 	if err != nil {
 		return fmt.Errorf("unable to get hostname: %v", err)
@@ -132,20 +145,31 @@ func start() error {
 
 	sher = scheduler.NewScheduler()
 	go sher.RegisterFromNodeAnnotations()
+	if config.BindPhaseTimeout > 0 {
+		go sher.WatchBindTimeouts()
+	}
 	err = sher.Start()
 	if err != nil {
 		return err
 	}
 	defer sher.Stop()
+	if config.WatchDeviceConfig {
+		if err := sher.WatchDeviceConfig(config.NewDeviceConfigReloader()); err != nil {
+			return fmt.Errorf("failed to watch device config: %w", err)
+		}
+	}
 
 	// start monitor metrics
-	go initMetrics(config.MetricsBindAddress, sher, legacyMetrics)
+	// The chargeback report is served next to the metrics rather than on the
+	// extender port, which the webhook and kube-scheduler reach cluster-wide.
+	go initMetrics(config.MetricsBindAddress, sher, legacyMetrics, routes.ChargebackRoute(sher))
 
 	// start http server
 	router := httprouter.New()
 	router.POST("/filter", routes.PredicateRoute(sher))
 	router.POST("/bind", routes.Bind(sher))
 	router.POST("/webhook", routes.WebHookRoute())
+	router.POST("/validate-node", routes.NodeWebHookRoute())
 	router.GET("/healthz", routes.HealthzRoute())
 	router.GET("/readyz", routes.ReadyzRoute(sher))
 	klog.Info("listen on ", config.HTTPBind)
@@ -24,13 +24,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/julienschmidt/httprouter"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	klog "k8s.io/klog/v2"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 	versionmetrics "github.com/Project-HAMi/HAMi/pkg/metrics"
 	schedulerpkg "github.com/Project-HAMi/HAMi/pkg/scheduler"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
 )
 
 type ClusterManager struct {
@@ -144,6 +147,11 @@ func (cc ClusterManagerCollector) collectNodeMetrics(ch chan<- prometheus.Metric
 		"Realized MIG instance identity and scheduler placement",
 		[]string{"node", "device_uuid", "device_index", "mig_uuid", "profile", "gpu_instance_id", "compute_instance_id", "placement_start", "placement_size"}, nil,
 	)
+	nodeGPUMigFragmentation := prometheus.NewDesc(
+		"hami_node_gpu_mig_fragmentation_ratio",
+		"Fragmentation of a MIG GPU's free slices, 0 when the largest profile they could hold can still be placed",
+		[]string{"node", "device_uuid", "device_index"}, nil,
+	)
 
 	// Legacy metric descriptors (only created when legacy mode is enabled)
 	var (
@@ -203,6 +211,12 @@ func (cc ClusterManagerCollector) collectNodeMetrics(ch chan<- prometheus.Metric
 		for _, devs := range val.Devices.DeviceLists {
 			coreLimit, coreAllocated := normalizeAMDCoreMetrics(devs.Device.Type, devs.Device.Totalcore, devs.Device.Usedcores)
 			if devs.Device.Mode == "mig" {
+				if len(devs.Device.MigProfiles) > 0 {
+					fragmentation := nvidia.MigFragmentation(devs.Device.MigProfiles, devs.Device.MigAllocationsInUse)
+					if err := sendMetric(ch, nodeGPUMigFragmentation, prometheus.GaugeValue, fragmentation, nodeID, devs.Device.ID, fmt.Sprint(devs.Device.Index)); err != nil {
+						klog.V(4).Infof("Failed to send nodeGPUMigFragmentation metric: %v", err)
+					}
+				}
 				for _, allocation := range devs.Device.MigAllocationsInUse {
 					if !allocation.RuntimeReady {
 						continue
@@ -410,15 +424,29 @@ func NewClusterManager(zone string, reg prometheus.Registerer, metricsProvider s
 	return c
 }
 
-func initMetrics(bindAddress string, metricsProvider schedulerMetricsProvider, legacyMetrics bool) {
+func initMetrics(bindAddress string, metricsProvider schedulerMetricsProvider, legacyMetrics bool, chargeback httprouter.Handle) {
 	klog.Info("Initializing metrics for scheduler")
 	reg := prometheus.NewRegistry()
 	reg.MustRegister(versionmetrics.NewBuildInfoCollector())
+	reg.MustRegister(config.ConfigReloadsTotal, config.ConfigLastReloadSuccessful)
+	reg.MustRegister(
+		schedulerpkg.FilterDuration,
+		schedulerpkg.FilterEvaluatedNodes,
+		schedulerpkg.ScoreDuration,
+		schedulerpkg.FilterNodeFailuresTotal,
+		schedulerpkg.BindDuration,
+		schedulerpkg.NodeLockWaitDuration,
+		schedulerpkg.NodeLockContentionsTotal,
+		schedulerpkg.BindTimeoutsTotal,
+	)
 
 	NewClusterManager("vGPU", reg, metricsProvider, legacyMetrics)
 
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	mux.HandleFunc("GET /chargeback", func(w http.ResponseWriter, r *http.Request) {
+		chargeback(w, r, nil)
+	})
 	server := &http.Server{
 		Addr:              bindAddress,
 		Handler:           mux,
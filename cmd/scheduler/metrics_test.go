@@ -409,6 +409,45 @@ hami_node_gpu_mig_instance_info{compute_instance_id="0",device_index="0",device_
 	}
 }
 
+func TestClusterManagerCollectorExposesMigFragmentation(t *testing.T) {
+	profiles := []device.MigProfile{
+		{Name: "1g.5gb", Placements: []device.MigPlacement{{Start: 0, Size: 1}, {Start: 1, Size: 1}, {Start: 2, Size: 1}, {Start: 3, Size: 1}, {Start: 4, Size: 1}, {Start: 5, Size: 1}, {Start: 6, Size: 1}}},
+		{Name: "2g.10gb", Placements: []device.MigPlacement{{Start: 0, Size: 2}, {Start: 2, Size: 2}, {Start: 4, Size: 2}}},
+		{Name: "3g.20gb", Placements: []device.MigPlacement{{Start: 0, Size: 4}, {Start: 4, Size: 4}}},
+	}
+	nodeUsage := map[string]*schedulerpkg.NodeUsage{
+		"node-1": {
+			Devices: policy.DeviceUsageList{DeviceLists: []*policy.DeviceListsScore{
+				{Device: &device.DeviceUsage{ID: "GPU-empty", Index: 0, Mode: "mig", Type: "NVIDIA", MigProfiles: profiles}},
+				{Device: &device.DeviceUsage{
+					ID: "GPU-fragmented", Index: 1, Mode: "mig", Type: "NVIDIA", MigProfiles: profiles,
+					MigAllocationsInUse: []device.MigAllocation{
+						{Profile: "2g.10gb", Placement: device.MigPlacement{Start: 2, Size: 2}},
+						{Profile: "1g.5gb", Placement: device.MigPlacement{Start: 6, Size: 1}},
+					},
+				}},
+				{Device: &device.DeviceUsage{ID: "GPU-shared", Index: 2, Mode: "hami-core", Type: "NVIDIA"}},
+			}},
+		},
+	}
+	collector := ClusterManagerCollector{
+		ClusterManager: &ClusterManager{},
+		metricsProvider: &fakeMetricsProvider{
+			nodeUsage: nodeUsage, quotaManager: device.NewQuotaManager(), podManager: device.NewPodManager(),
+		},
+	}
+
+	want := `
+# HELP hami_node_gpu_mig_fragmentation_ratio Fragmentation of a MIG GPU's free slices, 0 when the largest profile they could hold can still be placed
+# TYPE hami_node_gpu_mig_fragmentation_ratio gauge
+hami_node_gpu_mig_fragmentation_ratio{device_index="0",device_uuid="GPU-empty",node="node-1"} 0
+hami_node_gpu_mig_fragmentation_ratio{device_index="1",device_uuid="GPU-fragmented",node="node-1"} 0.5
+`
+	if err := promtestutil.CollectAndCompare(collector, strings.NewReader(want), "hami_node_gpu_mig_fragmentation_ratio"); err != nil {
+		t.Fatalf("unexpected collecting result:\n%s", err)
+	}
+}
+
 func TestClusterManagerCollectorQuotaMetrics(t *testing.T) {
 	// Regression coverage for collectQuotaMetrics: verify per-namespace quota
 	// usage is exported as hami_resource_quota_used, and as legacy QuotaUsed
@@ -0,0 +1,56 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+var validateConfigFile string
+
+// validateConfigCmd checks a device config file without starting the
+// scheduler, so config changes can be gated in CI.
+var validateConfigCmd = &cobra.Command{
+	Use:          "validate-config",
+	Short:        "validate a device config file",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		data, err := os.ReadFile(validateConfigFile)
+		if err != nil {
+			return err
+		}
+		issues := config.ValidateConfigData(data)
+		for _, issue := range issues {
+			cmd.Printf("%s: %s\n", validateConfigFile, issue)
+		}
+		if len(issues) > 0 {
+			return fmt.Errorf("%s has %d issue(s)", validateConfigFile, len(issues))
+		}
+		cmd.Printf("%s is valid\n", validateConfigFile)
+		return nil
+	},
+}
+
+func init() {
+	validateConfigCmd.Flags().StringVar(&validateConfigFile, "file", "", "path to the device config file")
+	_ = validateConfigCmd.MarkFlagRequired("file")
+}
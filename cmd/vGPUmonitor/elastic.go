@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"math"
+
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+// cappedUtilizationRatio is the share of its core limit a container has to
+// use before it counts as capped and may borrow idle cores.
+const cappedUtilizationRatio = 0.9
+
+// ElasticCores raises the SM limit of capped containers that opted into
+// elastic cores while nobody else is using their devices, and drops it back to
+// the allocated limit as soon as a co-tenant becomes active.
+func ElasticCores(containers map[string]*nvidia.ContainerUsage) {
+	tenants := map[string][]*nvidia.ContainerUsage{}
+	for _, c := range containers {
+		for i := range c.Info.DeviceMax() {
+			if c.Info.IsValidUUID(i) {
+				uuid := c.Info.DeviceUUID(i)
+				tenants[uuid] = append(tenants[uuid], c)
+			}
+		}
+	}
+	for idx, c := range containers {
+		if c.Info.DeviceNum() == 0 || c.BaseCoreLimit == 0 || c.BaseCoreLimit >= 100 {
+			continue
+		}
+		current := c.Info.DeviceSmLimit(0)
+		if c.CoreBurstRatio == 0 && current <= c.BaseCoreLimit {
+			continue
+		}
+		target := c.BaseCoreLimit
+		if c.CoreBurstRatio > 0 && isCoreCapped(c) && !hasActiveCoTenant(c, tenants) {
+			target = burstCoreLimit(c.BaseCoreLimit, c.CoreBurstRatio)
+		}
+		if current != target {
+			klog.V(4).InfoS("Adjusting elastic core limit", "container", idx, "from", current, "to", target)
+			c.Info.SetDeviceSmLimit(target)
+		}
+	}
+}
+
+// burstCoreLimit is base plus ratio of the cores base leaves unallocated.
+func burstCoreLimit(base uint64, ratio float64) uint64 {
+	return min(100, base+uint64(math.Ceil(ratio*float64(100-base))))
+}
+
+// isCoreCapped reports whether c runs close to its allocated core limit on any
+// of its devices. Blocked containers are never capped.
+func isCoreCapped(c *nvidia.ContainerUsage) bool {
+	if c.Info.GetRecentKernel() < 0 {
+		return false
+	}
+	for i := range c.Info.DeviceMax() {
+		if c.Info.IsValidUUID(i) && float64(c.Info.DeviceSmUtil(i)) >= cappedUtilizationRatio*float64(c.BaseCoreLimit) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCoreActive(c *nvidia.ContainerUsage) bool {
+	if c.Info.GetRecentKernel() > 0 {
+		return true
+	}
+	for i := range c.Info.DeviceMax() {
+		if c.Info.IsValidUUID(i) && c.Info.DeviceSmUtil(i) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasActiveCoTenant(c *nvidia.ContainerUsage, tenants map[string][]*nvidia.ContainerUsage) bool {
+	for i := range c.Info.DeviceMax() {
+		if !c.Info.IsValidUUID(i) {
+			continue
+		}
+		for _, other := range tenants[c.Info.DeviceUUID(i)] {
+			if other != c && isCoreActive(other) {
+				return true
+			}
+		}
+	}
+	return false
+}
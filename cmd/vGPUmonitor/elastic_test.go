@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+func elasticContainer(uuid string, base uint64, ratio float64, smUtil uint64) (*nvidia.ContainerUsage, *stubInfo) {
+	info := &stubInfo{uuids: []string{uuid}, smUtil: []uint64{smUtil}, smLimit: base}
+	return &nvidia.ContainerUsage{Info: info, BaseCoreLimit: base, CoreBurstRatio: ratio}, info
+}
+
+func TestBurstCoreLimit(t *testing.T) {
+	tests := []struct {
+		base  uint64
+		ratio float64
+		want  uint64
+	}{
+		{base: 30, ratio: 0.5, want: 65},
+		{base: 30, ratio: 1, want: 100},
+		{base: 99, ratio: 0.1, want: 100},
+		{base: 40, ratio: 0.01, want: 41},
+	}
+	for _, tt := range tests {
+		if got := burstCoreLimit(tt.base, tt.ratio); got != tt.want {
+			t.Errorf("burstCoreLimit(%d, %v) = %d, want %d", tt.base, tt.ratio, got, tt.want)
+		}
+	}
+}
+
+func TestElasticCores(t *testing.T) {
+	burst, burstInfo := elasticContainer("gpu-0", 30, 0.5, 30)
+	neighbour, neighbourInfo := elasticContainer("gpu-0", 20, 0, 0)
+	other, _ := elasticContainer("gpu-1", 50, 0, 50)
+	containers := map[string]*nvidia.ContainerUsage{"burst": burst, "neighbour": neighbour, "other": other}
+
+	// The neighbour is idle and activity on another GPU does not count.
+	ElasticCores(containers)
+	if burstInfo.smLimit != 65 {
+		t.Fatalf("capped container with idle co-tenants: smLimit = %d, want 65", burstInfo.smLimit)
+	}
+	if neighbourInfo.smLimit != 20 {
+		t.Errorf("non-elastic container limit changed to %d", neighbourInfo.smLimit)
+	}
+
+	// Staying capped at the raised limit keeps the burst.
+	burstInfo.smUtil = []uint64{64}
+	ElasticCores(containers)
+	if burstInfo.smLimit != 65 {
+		t.Errorf("smLimit = %d, want 65", burstInfo.smLimit)
+	}
+
+	// A co-tenant launching kernels claws the borrowed cores back.
+	neighbourInfo.recentKernel = 2
+	ElasticCores(containers)
+	if burstInfo.smLimit != 30 {
+		t.Errorf("active co-tenant: smLimit = %d, want 30", burstInfo.smLimit)
+	}
+
+	// So does the container going quiet.
+	neighbourInfo.recentKernel = 0
+	ElasticCores(containers)
+	if burstInfo.smLimit != 65 {
+		t.Fatalf("smLimit = %d, want 65", burstInfo.smLimit)
+	}
+	burstInfo.smUtil = []uint64{5}
+	ElasticCores(containers)
+	if burstInfo.smLimit != 30 {
+		t.Errorf("uncapped container: smLimit = %d, want 30", burstInfo.smLimit)
+	}
+
+	// Turning elastic mode off restores the allocated limit.
+	burstInfo.smUtil = []uint64{30}
+	ElasticCores(containers)
+	burst.CoreBurstRatio = 0
+	ElasticCores(containers)
+	if burstInfo.smLimit != 30 {
+		t.Errorf("elastic mode off: smLimit = %d, want 30", burstInfo.smLimit)
+	}
+}
+
+func TestElasticCoresSkipsBlockedContainers(t *testing.T) {
+	c, info := elasticContainer("gpu-0", 30, 0.5, 30)
+	info.recentKernel = -1
+	ElasticCores(map[string]*nvidia.ContainerUsage{"c": c})
+	if info.smLimit != 30 {
+		t.Errorf("blocked container: smLimit = %d, want 30", info.smLimit)
+	}
+}
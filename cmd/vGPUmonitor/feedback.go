@@ -71,12 +71,26 @@ func CheckPriority(utSwitchOn map[string]UtilizationPerDevice, p int, c *nvidia.
 	return false
 }
 
-func Observe(lister *nvidia.ContainerLister) {
+// Observe updates the blocking and utilization switches of every container
+// from the kernel activity of the others. With a nil slicer any activity of a
+// higher priority level blocks the lower ones; otherwise contended devices are
+// time-sliced between the levels by weight.
+func Observe(lister *nvidia.ContainerLister, slicer *TimeSlicer) {
+	observeContainers(lister.ListContainers(), slicer)
+}
+
+func observeContainers(containers map[string]*nvidia.ContainerUsage, slicer *TimeSlicer) {
 	utSwitchOn := map[string]UtilizationPerDevice{}
-	containers := lister.ListContainers()
+	// competing counts the tasks waiting for each device per priority level:
+	// the active ones and, since they cannot launch kernels to show they are
+	// still busy, the blocked ones.
+	competing := map[string]UtilizationPerDevice{}
 
 	for _, c := range containers {
 		recentKernel := c.Info.GetRecentKernel()
+		if slicer != nil && recentKernel < 0 {
+			countPriority(competing, c)
+		}
 		if recentKernel > 0 {
 			recentKernel--
 			if recentKernel > 0 {
@@ -96,15 +110,28 @@ func Observe(lister *nvidia.ContainerLister) {
 					}
 					utSwitchOn[uuid][p]++
 				}
+				if slicer != nil {
+					countPriority(competing, c)
+				}
 			}
 			c.Info.SetRecentKernel(recentKernel)
 		}
 	}
+	var granted map[string]int
+	if slicer != nil {
+		granted = slicer.Slice(competing)
+	}
 	for idx, c := range containers {
 		priority := c.Info.GetPriority()
 		recentKernel := c.Info.GetRecentKernel()
 		utilizationSwitch := c.Info.GetUtilizationSwitch()
-		if CheckBlocking(utSwitchOn, priority, c) {
+		var blocking bool
+		if slicer != nil {
+			blocking = CheckSliceBlocking(granted, priority, c)
+		} else {
+			blocking = CheckBlocking(utSwitchOn, priority, c)
+		}
+		if blocking {
 			if recentKernel >= 0 {
 				klog.V(5).Infof("utSwitchon=%v", utSwitchOn)
 				klog.V(5).Infof("Setting Blocking to on %v", idx)
@@ -133,7 +160,55 @@ func Observe(lister *nvidia.ContainerLister) {
 	}
 }
 
-func watchAndFeedback(ctx context.Context, lister *nvidia.ContainerLister, migLockSignal <-chan bool) error {
+// countPriority adds c to the per-level task count of each of its devices.
+func countPriority(counts map[string]UtilizationPerDevice, c *nvidia.ContainerUsage) {
+	p := c.Info.GetPriority()
+	if p < 0 {
+		return
+	}
+	for i := range c.Info.DeviceMax() {
+		if !c.Info.IsValidUUID(i) {
+			continue
+		}
+		uuid := c.Info.DeviceUUID(i)
+		for p >= len(counts[uuid]) {
+			counts[uuid] = append(counts[uuid], 0)
+		}
+		counts[uuid][p]++
+	}
+}
+
+// checkProcesses hands the NVML process list to the memory watchdog and the
+// process reconciler.
+func checkProcesses(lister *nvidia.ContainerLister, watchdog *MemoryWatchdog, reconciler *Reconciler) {
+	procs, err := nvmlDeviceProcesses()
+	if err != nil {
+		klog.Errorf("Failed to list GPU processes: %v", err)
+		return
+	}
+	pods, err := lister.ListPods()
+	if err != nil {
+		klog.Errorf("Failed to list pods for GPU process attribution: %v", err)
+		return
+	}
+	if watchdog != nil {
+		watchdog.Check(lister.ListContainers(), pods, procs)
+	}
+	if reconciler != nil {
+		reconciler.Reconcile(lister.ListContainers(), pods, procs)
+	}
+}
+
+// feedbackComponents are the optional parts of the feedback loop. Nil ones are
+// disabled.
+type feedbackComponents struct {
+	slicer     *TimeSlicer
+	watchdog   *MemoryWatchdog
+	reconciler *Reconciler
+	usage      *UsageRecorder
+}
+
+func watchAndFeedback(ctx context.Context, lister *nvidia.ContainerLister, components feedbackComponents, migLockSignal <-chan bool) error {
 	klog.Info("Starting watchAndFeedback")
 	if nvret := nvml.Init(); nvret != nvml.SUCCESS {
 		return fmt.Errorf("failed to initialize NVML: %s", nvml.ErrorString(nvret))
@@ -142,12 +217,21 @@ func watchAndFeedback(ctx context.Context, lister *nvidia.ContainerLister, migLo
 
 	ticker := time.NewTicker(time.Second * 5)
 	defer ticker.Stop()
+	var saveUsageCh <-chan time.Time
+	if components.usage != nil {
+		saveTicker := time.NewTicker(time.Minute)
+		defer saveTicker.Stop()
+		defer saveUsage(components.usage)
+		saveUsageCh = saveTicker.C
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			klog.Info("Shutting down watchAndFeedback")
 			return nil
+		case <-saveUsageCh:
+			saveUsage(components.usage)
 		case signal := <-migLockSignal:
 			if signal {
 				klog.Info("Received MIG apply lock file")
@@ -159,7 +243,17 @@ func watchAndFeedback(ctx context.Context, lister *nvidia.ContainerLister, migLo
 				klog.Errorf("Failed to update container list: %v", err)
 				continue
 			}
-			Observe(lister)
+			Observe(lister, components.slicer)
+			if err := lister.SyncResizedLimits(); err != nil {
+				klog.Errorf("Failed to sync resized limits: %v", err)
+			}
+			ElasticCores(lister.ListContainers())
+			if components.watchdog != nil || components.reconciler != nil {
+				checkProcesses(lister, components.watchdog, components.reconciler)
+			}
+			if components.usage != nil {
+				components.usage.Sample(lister.ListContainers(), time.Now())
+			}
 		}
 	}
 }
@@ -40,6 +40,10 @@ type stubInfo struct {
 	bufSize    []uint64
 	smUtil     []uint64
 	lastKernel int64
+
+	smLimit      uint64
+	recentKernel int32
+	hostPIDs     []int32
 }
 
 func slot(v []uint64, i int) uint64 {
@@ -63,7 +67,9 @@ func (s *stubInfo) DeviceMemoryBufferSize(i int) uint64  { return slot(s.bufSize
 func (s *stubInfo) DeviceMemoryOffset(int) uint64        { return 0 }
 func (s *stubInfo) DeviceMemoryTotal(i int) uint64       { return slot(s.total, i) }
 func (s *stubInfo) DeviceSmUtil(i int) uint64            { return slot(s.smUtil, i) }
-func (s *stubInfo) SetDeviceSmLimit(uint64)              {}
+func (s *stubInfo) DeviceSmLimit(int) uint64             { return s.smLimit }
+func (s *stubInfo) SetDeviceSmLimit(l uint64)            { s.smLimit = l }
+func (s *stubInfo) HostPIDs() []int32                    { return s.hostPIDs }
 func (s *stubInfo) IsValidUUID(i int) bool {
 	return i >= 0 && i < len(s.uuids) && len(s.uuids[i]) > 0 && s.uuids[i][0] != 0
 }
@@ -71,8 +77,8 @@ func (s *stubInfo) DeviceMemoryLimit(i int) uint64 { return slot(s.limit, i) }
 func (s *stubInfo) SetDeviceMemoryLimit(uint64)    {}
 func (s *stubInfo) LastKernelTime() int64          { return s.lastKernel }
 func (s *stubInfo) GetPriority() int               { return s.priority }
-func (s *stubInfo) GetRecentKernel() int32         { return 1 }
-func (s *stubInfo) SetRecentKernel(int32)          {}
+func (s *stubInfo) GetRecentKernel() int32         { return s.recentKernel }
+func (s *stubInfo) SetRecentKernel(v int32)        { s.recentKernel = v }
 func (s *stubInfo) GetUtilizationSwitch() int32    { return 0 }
 func (s *stubInfo) SetUtilizationSwitch(int32)     {}
 
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sync"
 	"syscall"
 	"time"
@@ -32,12 +33,18 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/flag"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 	"github.com/Project-HAMi/HAMi/pkg/version"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 )
 
@@ -52,6 +59,12 @@ var (
 	}
 	metricsBindAddress string
 	legacyMetrics      bool
+	priorityWeights    []int
+	memoryWatchdog     string
+	reconcileProcesses bool
+	usageHistoryFile   string
+	usageRetention     time.Duration
+	tracingOptions     tracing.Options
 )
 
 func init() {
@@ -60,6 +73,12 @@ func init() {
 	rootCmd.Flags().AddGoFlagSet(util.InitKlogFlags())
 	rootCmd.Flags().StringVar(&metricsBindAddress, "metrics-bind-address", ":9394", "The TCP address that the vGPUmonitor should bind to for serving prometheus metrics(e.g. 127.0.0.1:9394, :9394)")
 	rootCmd.Flags().BoolVar(&legacyMetrics, "legacy-metrics", false, "Emit legacy metric names alongside new ones for backward compatibility")
+	rootCmd.Flags().IntSliceVar(&priorityWeights, "priority-weights", nil, "Time-slice weights of the task priority levels, indexed by CUDA_TASK_PRIORITY (e.g. 70,20,10). When empty, a busy higher priority level blocks the lower ones")
+	rootCmd.Flags().StringVar(&memoryWatchdog, "memory-watchdog", string(WatchdogReport), "What to do about containers using more device memory than their vGPU limit: off, report (pod event and metric) or kill (also kill the container's largest process on the device)")
+	rootCmd.Flags().BoolVar(&reconcileProcesses, "reconcile-processes", true, "Report GPU processes NVML lists that no HAMi container accounts for, such as those bypassing libvgpu")
+	rootCmd.Flags().StringVar(&usageHistoryFile, "usage-history-file", "", "File the per-container GPU usage history is kept in across restarts (default $HOOK_PATH/usage/history.json)")
+	rootCmd.Flags().DurationVar(&usageRetention, "usage-retention", 31*24*time.Hour, "How long the hourly GPU usage history served on /usage is kept. 0 disables usage recording")
+	tracingOptions.AddFlags(rootCmd.Flags())
 	rootCmd.AddCommand(version.VersionCmd)
 }
 
@@ -68,14 +87,54 @@ func start() error {
 		return fmt.Errorf("failed to validate environment variables: %v", err)
 	}
 
+	slicer, err := NewTimeSlicer(priorityWeights)
+	if err != nil {
+		return fmt.Errorf("invalid --priority-weights: %v", err)
+	}
+
+	watchdogPolicy, err := ParseMemoryWatchdogPolicy(memoryWatchdog)
+	if err != nil {
+		return fmt.Errorf("invalid --memory-watchdog: %v", err)
+	}
+
 	containerLister, err := nvidia.NewContainerLister()
 	if err != nil {
 		return fmt.Errorf("failed to create container lister: %v", err)
 	}
 
+	nodeName := os.Getenv(util.NodeNameEnvName)
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartStructuredLogging(0)
+	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: containerLister.Clientset().CoreV1().Events(metav1.NamespaceAll)})
+	defer eventBroadcaster.Shutdown()
+	recorder := eventBroadcaster.NewRecorder(clientgoscheme.Scheme, corev1.EventSource{Component: "hami-vgpu-monitor", Host: nodeName})
+
+	var components feedbackComponents
+	components.slicer = slicer
+	if watchdogPolicy != WatchdogOff {
+		components.watchdog = NewMemoryWatchdog(watchdogPolicy, recorder)
+	}
+	if reconcileProcesses {
+		components.reconciler = NewReconciler(nodeName, containerLister.Clientset(), recorder)
+	}
+	if usageRetention > 0 {
+		if usageHistoryFile == "" {
+			usageHistoryFile = filepath.Join(os.Getenv("HOOK_PATH"), "usage", "history.json")
+		}
+		if components.usage, err = NewUsageRecorder(usageHistoryFile, usageRetention); err != nil {
+			return err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, err := tracing.Init(ctx, "hami-vgpu-monitor", tracingOptions)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+
 	// Prepare the lock file sub directory.Due to the sequence of startup processes, both the device plugin
 	// and the vGPU monitor should attempt to create this directory by default to ensure its creation.
 	err = plugin.CreateMigApplyLockDir()
@@ -93,7 +152,7 @@ func start() error {
 
 	// Start the metrics service
 	wg.Go(func() {
-		if err := initMetrics(ctx, containerLister); err != nil {
+		if err := initMetrics(ctx, containerLister, components); err != nil {
 			errCh <- err
 		}
 	})
@@ -101,7 +160,7 @@ func start() error {
 	// Start the monitoring and feedback service
 	wg.Go(func() {
 		for {
-			if err := watchAndFeedback(ctx, containerLister, lockChannel); err != nil {
+			if err := watchAndFeedback(ctx, containerLister, components, lockChannel); err != nil {
 				// if err is temporary closed, wait for lock file to be removed
 				if errors.Is(err, errTemporaryClosed) {
 					klog.Info("MIG apply lock file detected, waiting for lock file to be removed")
@@ -135,7 +194,7 @@ func start() error {
 	return nil
 }
 
-func initMetrics(ctx context.Context, containerLister *nvidia.ContainerLister) error {
+func initMetrics(ctx context.Context, containerLister *nvidia.ContainerLister, components feedbackComponents) error {
 	klog.V(4).Info("Initializing metrics for vGPUmonitor")
 	reg := prometheus.NewRegistry()
 	//reg := prometheus.NewPedanticRegistry()
@@ -143,6 +202,19 @@ func initMetrics(ctx context.Context, containerLister *nvidia.ContainerLister) e
 	reg.MustRegister(versionmetrics.NewBuildInfoCollector())
 
 	NewClusterManager("vGPU", reg, containerLister, legacyMetrics)
+	zoneReg := prometheus.WrapRegistererWith(prometheus.Labels{"zone": "vGPU"}, reg)
+	if components.slicer != nil {
+		zoneReg.MustRegister(components.slicer)
+	}
+	if components.watchdog != nil {
+		zoneReg.MustRegister(components.watchdog)
+	}
+	if components.reconciler != nil {
+		zoneReg.MustRegister(components.reconciler)
+	}
+	if components.usage != nil {
+		zoneReg.MustRegister(components.usage)
+	}
 
 	// Uncomment to add the standard process and Go metrics to the custom registry.
 	//reg.MustRegister(
@@ -152,6 +224,9 @@ func initMetrics(ctx context.Context, containerLister *nvidia.ContainerLister) e
 
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	if components.usage != nil {
+		mux.Handle("/usage", components.usage)
+	}
 	server := &http.Server{Addr: metricsBindAddress, Handler: mux, ReadHeaderTimeout: 15 * time.Second, ReadTimeout: 60 * time.Second}
 
 	// Starting the HTTP server in a goroutine
@@ -121,6 +121,11 @@ var (
 		"Container device memory buffer size in bytes",
 		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
 	)
+	ctrDeviceBorrowedCoresDesc = prometheus.NewDesc(
+		"hami_vgpu_core_borrowed_ratio",
+		"SM ratio (0-100) an elastic container currently borrows above its core limit",
+		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
+	)
 )
 
 // Legacy metric descriptors (populated only when --legacy-metrics is enabled).
@@ -202,6 +207,7 @@ func (cc ClusterManagerCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- ctrDeviceMemoryContextDesc
 	ch <- ctrDeviceMemoryModuleDesc
 	ch <- ctrDeviceMemoryBufferDesc
+	ch <- ctrDeviceBorrowedCoresDesc
 
 	if cc.ClusterManager.LegacyMetrics {
 		ch <- legacyHostGPUdesc
@@ -508,6 +514,13 @@ func (cc ClusterManagerCollector) collectContainerMetrics(ch chan<- prometheus.M
 			klog.Errorf("Failed to send Device Memory buffer size metric: %v", err)
 			return err
 		}
+		if c.CoreBurstRatio > 0 {
+			borrowed := c.Info.DeviceSmLimit(i) - min(c.BaseCoreLimit, c.Info.DeviceSmLimit(i))
+			if err := sendMetric(ch, ctrDeviceBorrowedCoresDesc, prometheus.GaugeValue, float64(borrowed), labels...); err != nil {
+				klog.Errorf("Failed to send borrowed cores metric: %v", err)
+				return err
+			}
+		}
 
 		if lastKernelTime > 0 {
 			lastSec := max(nowSec-lastKernelTime, 0)
@@ -110,6 +110,31 @@ func TestCollectContainerMetrics(t *testing.T) {
 	}
 }
 
+func TestCollectContainerMetricsBorrowedCores(t *testing.T) {
+	cu := &nvidia.ContainerUsage{
+		Info:           &stubInfo{uuids: []string{testUUID}, smLimit: 60},
+		BaseCoreLimit:  30,
+		CoreBurstRatio: 0.5,
+	}
+	metrics, err := collectContainer(t, cu, 160)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, m := range metrics {
+		if m.Desc() != ctrDeviceBorrowedCoresDesc {
+			continue
+		}
+		found = true
+		if v, _ := gaugeValue(t, m); v != 30 {
+			t.Errorf("borrowed cores = %v, want 30", v)
+		}
+	}
+	if !found {
+		t.Error("borrowed cores metric not emitted for an elastic container")
+	}
+}
+
 func TestCollectContainerMetricsNoKernelActivity(t *testing.T) {
 	cu := &nvidia.ContainerUsage{Info: &stubInfo{uuids: []string{testUUID}}}
 	metrics, err := collectContainer(t, cu, 160)
@@ -0,0 +1,307 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	nv "github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+// EventReasonUnmanagedProcess indicates that a GPU process is not accounted
+// for by any HAMi container.
+const EventReasonUnmanagedProcess = "UnmanagedGPUProcess"
+
+var (
+	unmanagedProcessesDesc = prometheus.NewDesc(
+		"hami_gpu_unmanaged_processes",
+		"GPU compute processes NVML reports that no HAMi container accounts for, by the pod their cgroup belongs to if any",
+		[]string{"device_uuid", "namespace", "pod", "container"}, nil,
+	)
+	unattributedMemoryDesc = prometheus.NewDesc(
+		"hami_gpu_unattributed_memory_bytes",
+		"Device memory used by GPU processes that no HAMi container accounts for",
+		[]string{"device_uuid"}, nil,
+	)
+)
+
+var (
+	// cgroupPodUID matches the pod UID in both the cgroupfs (pod<uid>) and the
+	// systemd (pod<uid with underscores>.slice) layouts.
+	cgroupPodUID      = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+	cgroupContainerID = regexp.MustCompile(`([0-9a-f]{64})(\.scope)?$`)
+)
+
+// registeredModesTTL bounds how often the node is read for the modes its
+// GPUs are registered in.
+const registeredModesTTL = time.Minute
+
+type unmanagedProcess struct {
+	pid                       uint32
+	uuid                      string
+	memory                    uint64
+	namespace, pod, container string
+}
+
+// Reconciler finds GPU processes that escape HAMi's accounting, such as those
+// of containers that bypass ld.so.preload, by matching the NVML process list
+// with the processes registered in the containers' shared regions. Each
+// unmanaged process is mapped to its pod through its cgroup. Processes of
+// pods given their GPU in MIG or exclusive mode are HAMi's although no shared
+// region lists them, as the device plugin does not load hami-core for those.
+type Reconciler struct {
+	nodeName string
+	procRoot string
+	client   kubernetes.Interface
+	recorder record.EventRecorder
+
+	mu        sync.Mutex
+	unmanaged []unmanagedProcess
+	reported  map[uint32]bool
+
+	registeredModes   map[string]string
+	registeredModesAt time.Time
+}
+
+func NewReconciler(nodeName string, client kubernetes.Interface, recorder record.EventRecorder) *Reconciler {
+	return &Reconciler{
+		nodeName: nodeName,
+		procRoot: "/proc",
+		client:   client,
+		recorder: recorder,
+		reported: map[uint32]bool{},
+	}
+}
+
+// Reconcile records the processes in procs that no container accounts for,
+// and reports the new ones as node events.
+func (r *Reconciler) Reconcile(containers map[string]*nvidia.ContainerUsage, pods []*corev1.Pod, procs deviceProcesses) {
+	managed := map[uint32]bool{}
+	for _, c := range containers {
+		for _, pid := range c.Info.HostPIDs() {
+			managed[uint32(pid)] = true
+		}
+	}
+	podsByUID := make(map[string]*corev1.Pod, len(pods))
+	for _, pod := range pods {
+		podsByUID[string(pod.UID)] = pod
+	}
+
+	var unmanaged []unmanagedProcess
+	for uuid, infos := range procs {
+		for _, info := range infos {
+			if managed[info.Pid] {
+				continue
+			}
+			p := unmanagedProcess{pid: info.Pid, uuid: uuid, memory: info.UsedGpuMemory}
+			if pod := r.attribute(&p, podsByUID); pod != nil && r.withoutSharedRegion(pod, uuid) {
+				continue
+			}
+			unmanaged = append(unmanaged, p)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unmanaged = unmanaged
+	current := map[uint32]bool{}
+	for _, p := range unmanaged {
+		current[p.pid] = true
+		if r.reported[p.pid] {
+			continue
+		}
+		klog.InfoS("Found GPU process outside HAMi accounting", "pid", p.pid, "device", p.uuid, "memory", p.memory, "pod", klog.KRef(p.namespace, p.pod), "container", p.container)
+		r.event(p)
+	}
+	r.reported = current
+}
+
+// attribute fills in the pod and container p belongs to, if any, and returns
+// the pod.
+func (r *Reconciler) attribute(p *unmanagedProcess, podsByUID map[string]*corev1.Pod) *corev1.Pod {
+	pod, container := processOwner(r.procRoot, p.pid, podsByUID)
+	if pod != nil {
+		p.namespace, p.pod, p.container = pod.Namespace, pod.Name, container
+	}
+	return pod
+}
+
+// withoutSharedRegion reports whether pod was given the GPU uuid in MIG or
+// exclusive mode. The mode comes from the pod's device modes or MIG
+// allocations, and otherwise from the mode the GPU is registered in when the
+// pod's devices include it.
+func (r *Reconciler) withoutSharedRegion(pod *corev1.Pod, uuid string) bool {
+	modes, err := nv.DecodeDeviceModes(pod.Annotations[nv.DeviceModesAnnotation])
+	if err != nil {
+		klog.V(4).InfoS("Ignoring pod device modes", "pod", klog.KObj(pod), "err", err)
+	}
+	if mode, ok := modes[uuid]; ok {
+		return mode == nv.MigMode || mode == nv.ExclusiveMode
+	}
+	allocations, err := nv.DecodeMigAllocations(pod.Annotations[nv.MigAllocationsAnnotation])
+	if err != nil {
+		klog.V(4).InfoS("Ignoring pod MIG allocations", "pod", klog.KObj(pod), "err", err)
+	}
+	for _, allocation := range allocations {
+		if allocation.GPUUUID == uuid {
+			return true
+		}
+	}
+	pd, err := device.DecodePodDevices(map[string]string{nv.NvidiaGPUDevice: nv.AllocatedDevicesAnnos}, pod.Annotations)
+	if err != nil {
+		return false
+	}
+	for _, ctr := range pd[nv.NvidiaGPUDevice] {
+		for _, dev := range ctr {
+			if dev.UUID == uuid {
+				mode := r.registeredMode(uuid)
+				return mode == nv.MigMode || mode == nv.ExclusiveMode
+			}
+		}
+	}
+	return false
+}
+
+// registeredMode returns the mode the device plugin registered the GPU uuid
+// in, reading the node's register annotation at most once a
+// registeredModesTTL.
+func (r *Reconciler) registeredMode(uuid string) string {
+	if r.client == nil || r.nodeName == "" {
+		return ""
+	}
+	if time.Since(r.registeredModesAt) > registeredModesTTL {
+		r.registeredModesAt = time.Now()
+		node, err := r.client.CoreV1().Nodes().Get(context.Background(), r.nodeName, metav1.GetOptions{})
+		if err != nil {
+			klog.ErrorS(err, "Failed to get node for GPU modes", "node", r.nodeName)
+			return r.registeredModes[uuid]
+		}
+		devices, err := device.UnMarshalNodeDevices(node.Annotations[nv.RegisterAnnos])
+		if err != nil {
+			klog.ErrorS(err, "Failed to decode node devices", "node", r.nodeName)
+			return r.registeredModes[uuid]
+		}
+		r.registeredModes = make(map[string]string, len(devices))
+		for _, d := range devices {
+			r.registeredModes[d.ID] = d.Mode
+		}
+	}
+	return r.registeredModes[uuid]
+}
+
+// processOwner returns the pod of podsByUID the process pid runs in, and the
+// name of its container, going by the process's cgroup. The container is
+// empty when the pod status does not list it.
+func processOwner(procRoot string, pid uint32, podsByUID map[string]*corev1.Pod) (*corev1.Pod, string) {
+	data, err := os.ReadFile(filepath.Join(procRoot, fmt.Sprint(pid), "cgroup"))
+	if err != nil {
+		klog.V(4).InfoS("Failed to read cgroup of GPU process", "pid", pid, "err", err)
+		return nil, ""
+	}
+	podUID, containerID := parseCgroup(string(data))
+	pod, ok := podsByUID[podUID]
+	if !ok {
+		return nil, ""
+	}
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if containerID != "" && strings.HasSuffix(status.ContainerID, "://"+containerID) {
+			return pod, status.Name
+		}
+	}
+	return pod, ""
+}
+
+// parseCgroup extracts the pod UID and container ID from the content of a
+// /proc/<pid>/cgroup file.
+func parseCgroup(content string) (podUID, containerID string) {
+	for line := range strings.SplitSeq(content, "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		m := cgroupPodUID.FindStringSubmatch(parts[2])
+		if m == nil {
+			continue
+		}
+		podUID = strings.ReplaceAll(m[1], "_", "-")
+		if m := cgroupContainerID.FindStringSubmatch(parts[2]); m != nil {
+			containerID = m[1]
+		}
+		return podUID, containerID
+	}
+	return "", ""
+}
+
+func (r *Reconciler) event(p unmanagedProcess) {
+	if r.recorder == nil || r.nodeName == "" {
+		return
+	}
+	owner := "no pod"
+	if p.pod != "" {
+		owner = fmt.Sprintf("pod %s/%s", p.namespace, p.pod)
+		if p.container != "" {
+			owner += " container " + p.container
+		}
+	}
+	ref := &corev1.ObjectReference{Kind: "Node", Name: r.nodeName, UID: types.UID(r.nodeName)}
+	r.recorder.Eventf(ref, corev1.EventTypeWarning, EventReasonUnmanagedProcess,
+		"Process %d of %s uses %d bytes on device %s outside HAMi accounting", p.pid, owner, p.memory, p.uuid)
+}
+
+// Describe implements prometheus.Collector.
+func (r *Reconciler) Describe(ch chan<- *prometheus.Desc) {
+	ch <- unmanagedProcessesDesc
+	ch <- unattributedMemoryDesc
+}
+
+// Collect implements prometheus.Collector.
+func (r *Reconciler) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type owner struct{ uuid, namespace, pod, container string }
+	counts := map[owner]int{}
+	memory := map[string]uint64{}
+	for _, p := range r.unmanaged {
+		counts[owner{p.uuid, p.namespace, p.pod, p.container}]++
+		memory[p.uuid] += p.memory
+	}
+	for o, n := range counts {
+		ch <- prometheus.MustNewConstMetric(unmanagedProcessesDesc, prometheus.GaugeValue, float64(n), o.uuid, o.namespace, o.pod, o.container)
+	}
+	for uuid, used := range memory {
+		ch <- prometheus.MustNewConstMetric(unattributedMemoryDesc, prometheus.GaugeValue, float64(used), uuid)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	nv "github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+const (
+	testPodUID      = "6a3c1f2e-8d4b-4c5a-9e7f-0123456789ab"
+	testContainerID = "f00dfeedf00dfeedf00dfeedf00dfeedf00dfeedf00dfeedf00dfeedf00dfeed"
+)
+
+func TestParseCgroup(t *testing.T) {
+	tests := []struct {
+		name, content, podUID, containerID string
+	}{
+		{
+			name:        "cgroup v1 cgroupfs",
+			content:     "12:memory:/kubepods/burstable/pod" + testPodUID + "/" + testContainerID + "\n11:cpu:/kubepods/burstable/pod" + testPodUID + "/" + testContainerID,
+			podUID:      testPodUID,
+			containerID: testContainerID,
+		},
+		{
+			name:        "cgroup v2 systemd",
+			content:     "0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod6a3c1f2e_8d4b_4c5a_9e7f_0123456789ab.slice/cri-containerd-" + testContainerID + ".scope\n",
+			podUID:      testPodUID,
+			containerID: testContainerID,
+		},
+		{name: "host process", content: "0::/system.slice/nvidia-persistenced.service\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podUID, containerID := parseCgroup(tt.content)
+			if podUID != tt.podUID || containerID != tt.containerID {
+				t.Errorf("parseCgroup() = %q, %q, want %q, %q", podUID, containerID, tt.podUID, tt.containerID)
+			}
+		})
+	}
+}
+
+func writeCgroup(t *testing.T, root, pid, content string) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := NewReconciler("node1", nil, recorder)
+	r.procRoot = t.TempDir()
+	writeCgroup(t, r.procRoot, "20", "0::/kubepods.slice/kubepods-pod6a3c1f2e_8d4b_4c5a_9e7f_0123456789ab.slice/cri-containerd-"+testContainerID+".scope\n")
+	writeCgroup(t, r.procRoot, "30", "0::/system.slice/cuda-job.service\n")
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{UID: testPodUID, Namespace: "team-a", Name: "leaky"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "main", ContainerID: "containerd://" + testContainerID},
+		}},
+	}
+	containers := map[string]*nvidia.ContainerUsage{
+		"managed": {Info: &stubInfo{uuids: []string{"GPU-0"}, hostPIDs: []int32{10}}},
+	}
+	procs := deviceProcesses{"GPU-0": {
+		{Pid: 10, UsedGpuMemory: 1000},
+		{Pid: 20, UsedGpuMemory: 300},
+		{Pid: 30, UsedGpuMemory: 200},
+	}}
+
+	r.Reconcile(containers, []*corev1.Pod{pod}, procs)
+	r.Reconcile(containers, []*corev1.Pod{pod}, procs)
+	if len(recorder.Events) != 2 {
+		t.Fatalf("got %d events, want one per unmanaged process", len(recorder.Events))
+	}
+	want := map[string]bool{
+		"Warning UnmanagedGPUProcess Process 20 of pod team-a/leaky container main uses 300 bytes on device GPU-0 outside HAMi accounting": true,
+		"Warning UnmanagedGPUProcess Process 30 of no pod uses 200 bytes on device GPU-0 outside HAMi accounting":                          true,
+	}
+	for range 2 {
+		if got := <-recorder.Events; !want[got] {
+			t.Errorf("unexpected event %q", got)
+		}
+	}
+
+	ch := make(chan prometheus.Metric, 16)
+	r.Collect(ch)
+	close(ch)
+	processes := map[string]float64{}
+	for m := range ch {
+		v, labels := gaugeValue(t, m)
+		switch m.Desc() {
+		case unattributedMemoryDesc:
+			if v != 500 {
+				t.Errorf("unattributed memory = %v, want 500", v)
+			}
+		case unmanagedProcessesDesc:
+			processes[labels["namespace"]+"/"+labels["pod"]+"/"+labels["container"]] = v
+		}
+	}
+	if processes["team-a/leaky/main"] != 1 || processes["//"] != 1 || len(processes) != 2 {
+		t.Errorf("unmanaged processes = %v", processes)
+	}
+}
+
+// reconcilePod runs r over a single process of pod on GPU-0, a device no
+// shared region lists, and returns the events it recorded.
+func reconcilePod(t *testing.T, r *Reconciler, pod *corev1.Pod) []string {
+	t.Helper()
+	recorder := record.NewFakeRecorder(10)
+	r.recorder = recorder
+	r.procRoot = t.TempDir()
+	writeCgroup(t, r.procRoot, "20", "0::/kubepods.slice/kubepods-pod6a3c1f2e_8d4b_4c5a_9e7f_0123456789ab.slice/cri-containerd-"+testContainerID+".scope\n")
+	pod.UID, pod.Namespace, pod.Name = testPodUID, "team-a", "isolated"
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "main", ContainerID: "containerd://" + testContainerID}}
+	r.Reconcile(nil, []*corev1.Pod{pod}, deviceProcesses{"GPU-0": {{Pid: 20, UsedGpuMemory: 300}}})
+	close(recorder.Events)
+	var events []string
+	for e := range recorder.Events {
+		events = append(events, e)
+	}
+	return events
+}
+
+func allocatedGPU0(t *testing.T) string {
+	t.Helper()
+	return device.EncodePodSingleDevice(device.PodSingleDevice{{{UUID: "GPU-0", Type: nv.NvidiaGPUDevice, Usedmem: 20480}}})
+}
+
+func TestReconcileSkipsMigPod(t *testing.T) {
+	allocations, err := json.Marshal([]nv.MigAllocation{{
+		GPUUUID:   "GPU-0",
+		Profile:   "3g.20gb",
+		Placement: device.MigPlacement{Start: 0, Size: 4},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		nv.AllocatedDevicesAnnos:    allocatedGPU0(t),
+		nv.MigAllocationsAnnotation: string(allocations),
+	}}}
+	r := NewReconciler("node1", nil, nil)
+	if events := reconcilePod(t, r, pod); len(events) != 0 {
+		t.Errorf("MIG pod reported as unmanaged: %v", events)
+	}
+	if len(r.unmanaged) != 0 {
+		t.Errorf("unmanaged = %v, want none", r.unmanaged)
+	}
+}
+
+func TestReconcileSkipsExclusivePod(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		nv.AllocatedDevicesAnnos: allocatedGPU0(t),
+		nv.DeviceModesAnnotation: `{"GPU-0":"exclusive"}`,
+	}}}
+	if events := reconcilePod(t, NewReconciler("node1", nil, nil), pod); len(events) != 0 {
+		t.Errorf("exclusive pod reported as unmanaged: %v", events)
+	}
+
+	// A node running every GPU in exclusive mode records no per-pod modes.
+	registered, err := json.Marshal([]*device.DeviceInfo{{ID: "GPU-0", Mode: nv.ExclusiveMode}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: map[string]string{nv.RegisterAnnos: string(registered)}}}
+	pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{nv.AllocatedDevicesAnnos: allocatedGPU0(t)}}}
+	if events := reconcilePod(t, NewReconciler("node1", fake.NewSimpleClientset(node), nil), pod); len(events) != 0 {
+		t.Errorf("pod on an exclusive GPU reported as unmanaged: %v", events)
+	}
+
+	// Processes of hami-core pods bypassing libvgpu are still reported.
+	node.Annotations[nv.RegisterAnnos] = `[{"id":"GPU-0","mode":"hami-core"}]`
+	if events := reconcilePod(t, NewReconciler("node1", fake.NewSimpleClientset(node), nil), pod); len(events) != 1 {
+		t.Errorf("got events %v, want the hami-core pod's process reported", events)
+	}
+}
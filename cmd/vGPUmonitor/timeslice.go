@@ -0,0 +1,183 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+var (
+	priorityActiveTasksDesc = prometheus.NewDesc(
+		"hami_vgpu_priority_active_tasks",
+		"Tasks of a priority level competing for a device in the last feedback tick",
+		[]string{"device_uuid", "priority"}, nil,
+	)
+	priorityGrantedDesc = prometheus.NewDesc(
+		"hami_vgpu_priority_granted",
+		"Whether a priority level holds the current time slice of a device (1) or not (0)",
+		[]string{"device_uuid", "priority"}, nil,
+	)
+	prioritySlicesDesc = prometheus.NewDesc(
+		"hami_vgpu_priority_slices_total",
+		"Feedback ticks a device was granted to a priority level",
+		[]string{"device_uuid", "priority"}, nil,
+	)
+)
+
+// TimeSlicer shares a device between the task priority levels competing for
+// it in proportion to their weights, instead of letting the highest active
+// level block all others. Levels are the CUDA_TASK_PRIORITY of the tasks and
+// index the weights; levels past the end use the last weight. Every feedback
+// tick grants each contended device to one level by smooth weighted
+// round-robin, so with weights 70,20,10 and all three levels busy, level 0
+// runs 7 of every 10 ticks, level 1 two and level 2 one.
+type TimeSlicer struct {
+	weights []int
+
+	mu      sync.Mutex
+	devices map[string]*deviceSlices
+}
+
+type deviceSlices struct {
+	credit  []int
+	active  []int
+	slices  []uint64
+	granted int
+}
+
+// NewTimeSlicer returns a TimeSlicer for weights, or nil when weights is
+// empty and strict priority preemption should be kept.
+func NewTimeSlicer(weights []int) (*TimeSlicer, error) {
+	if len(weights) == 0 {
+		return nil, nil
+	}
+	for i, w := range weights {
+		if w <= 0 {
+			return nil, fmt.Errorf("priority weight %d for level %d must be positive", w, i)
+		}
+	}
+	return &TimeSlicer{weights: weights, devices: map[string]*deviceSlices{}}, nil
+}
+
+func (s *TimeSlicer) weight(level int) int {
+	return s.weights[min(level, len(s.weights)-1)]
+}
+
+// Slice advances every device by one tick given the tasks competing for it
+// per priority level, and returns the level granted on each device more than
+// one level competes for.
+func (s *TimeSlicer) Slice(competing map[string]UtilizationPerDevice) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	granted := map[string]int{}
+	for uuid, d := range s.devices {
+		if _, ok := competing[uuid]; !ok {
+			clear(d.credit)
+			d.active = nil
+			d.granted = -1
+		}
+	}
+	for uuid, levels := range competing {
+		d, ok := s.devices[uuid]
+		if !ok {
+			d = &deviceSlices{}
+			s.devices[uuid] = d
+		}
+		for len(d.credit) < len(levels) {
+			d.credit = append(d.credit, 0)
+			d.slices = append(d.slices, 0)
+		}
+		d.active = append(d.active[:0], levels...)
+		total, best, contenders := 0, -1, 0
+		for p, n := range levels {
+			if n <= 0 {
+				// Idle levels must not bank credit for later.
+				d.credit[p] = 0
+				continue
+			}
+			contenders++
+			d.credit[p] += s.weight(p)
+			total += s.weight(p)
+			if best < 0 || d.credit[p] > d.credit[best] {
+				best = p
+			}
+		}
+		d.granted = best
+		if best < 0 {
+			continue
+		}
+		d.credit[best] -= total
+		d.slices[best]++
+		if contenders > 1 {
+			granted[uuid] = best
+		}
+	}
+	return granted
+}
+
+// Describe implements prometheus.Collector.
+func (s *TimeSlicer) Describe(ch chan<- *prometheus.Desc) {
+	ch <- priorityActiveTasksDesc
+	ch <- priorityGrantedDesc
+	ch <- prioritySlicesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (s *TimeSlicer) Collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uuid, d := range s.devices {
+		label := strings.TrimRight(uuid, "\x00")
+		for p := range d.slices {
+			level := strconv.Itoa(p)
+			active := 0
+			if p < len(d.active) {
+				active = d.active[p]
+			}
+			granted := 0.0
+			if p == d.granted {
+				granted = 1
+			}
+			ch <- prometheus.MustNewConstMetric(priorityActiveTasksDesc, prometheus.GaugeValue, float64(active), label, level)
+			ch <- prometheus.MustNewConstMetric(priorityGrantedDesc, prometheus.GaugeValue, granted, label, level)
+			ch <- prometheus.MustNewConstMetric(prioritySlicesDesc, prometheus.CounterValue, float64(d.slices[p]), label, level)
+		}
+	}
+}
+
+// CheckSliceBlocking reports whether c has to wait because one of its devices
+// is granted to another priority level.
+func CheckSliceBlocking(granted map[string]int, p int, c *nvidia.ContainerUsage) bool {
+	for i := range c.Info.DeviceMax() {
+		if !c.Info.IsValidUUID(i) {
+			continue
+		}
+		if level, ok := granted[c.Info.DeviceUUID(i)]; ok && level != p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+func TestNewTimeSlicer(t *testing.T) {
+	s, err := NewTimeSlicer(nil)
+	if s != nil || err != nil {
+		t.Errorf("no weights: got %v, %v, want strict preemption", s, err)
+	}
+	if _, err := NewTimeSlicer([]int{70, 0, 10}); err == nil {
+		t.Error("zero weight should be rejected")
+	}
+}
+
+func TestTimeSlicerWeights(t *testing.T) {
+	s, err := NewTimeSlicer([]int{70, 20, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]int, 3)
+	for range 100 {
+		granted := s.Slice(map[string]UtilizationPerDevice{"gpu-0": {1, 2, 1}, "gpu-1": {0, 1}})
+		got[granted["gpu-0"]]++
+		if _, ok := granted["gpu-1"]; ok {
+			t.Fatal("a device with a single busy level must not be sliced")
+		}
+	}
+	if got[0] != 70 || got[1] != 20 || got[2] != 10 {
+		t.Errorf("slices per level = %v, want [70 20 10]", got)
+	}
+
+	// Levels past the end of the weights share the last weight, and a level
+	// coming back from idle starts without banked credit.
+	got = make([]int, 4)
+	for range 20 {
+		granted := s.Slice(map[string]UtilizationPerDevice{"gpu-0": {0, 0, 1, 1}})
+		got[granted["gpu-0"]]++
+	}
+	if got[2] != 10 || got[3] != 10 {
+		t.Errorf("slices per level = %v, want 10 each for levels 2 and 3", got)
+	}
+}
+
+func TestObserveContainersTimeSlicing(t *testing.T) {
+	slicer, err := NewTimeSlicer([]int{1, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	high := &stubInfo{priority: 0, uuids: []string{"gpu-0"}, recentKernel: 2}
+	low := &stubInfo{priority: 1, uuids: []string{"gpu-0"}, recentKernel: 2}
+	containers := map[string]*nvidia.ContainerUsage{"high": {Info: high}, "low": {Info: low}}
+
+	observeContainers(containers, slicer)
+	if high.recentKernel < 0 || low.recentKernel >= 0 {
+		t.Fatalf("first slice: high=%d low=%d, want the high level to run", high.recentKernel, low.recentKernel)
+	}
+
+	// The blocked low level still competes and gets the next slice.
+	high.recentKernel = 2
+	observeContainers(containers, slicer)
+	if high.recentKernel >= 0 || low.recentKernel < 0 {
+		t.Fatalf("second slice: high=%d low=%d, want the low level to run", high.recentKernel, low.recentKernel)
+	}
+
+	// Without a slicer the high level blocks the low one outright.
+	high.recentKernel, low.recentKernel = 2, 2
+	observeContainers(containers, nil)
+	if low.recentKernel >= 0 {
+		t.Errorf("strict preemption: low=%d, want blocked", low.recentKernel)
+	}
+}
+
+func TestTimeSlicerCollect(t *testing.T) {
+	s, err := NewTimeSlicer([]int{3, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 4 {
+		s.Slice(map[string]UtilizationPerDevice{"gpu-0\x00": {1, 1}})
+	}
+	ch := make(chan prometheus.Metric, 16)
+	s.Collect(ch)
+	close(ch)
+	slices := map[string]float64{}
+	for m := range ch {
+		if m.Desc() != prioritySlicesDesc {
+			continue
+		}
+		var d dto.Metric
+		if err := m.Write(&d); err != nil {
+			t.Fatal(err)
+		}
+		labels := map[string]string{}
+		for _, p := range d.GetLabel() {
+			labels[p.GetName()] = p.GetValue()
+		}
+		if labels["device_uuid"] != "gpu-0" {
+			t.Errorf("device_uuid = %q, want trailing NULs trimmed", labels["device_uuid"])
+		}
+		slices[labels["priority"]] = d.GetCounter().GetValue()
+	}
+	if slices["0"] != 3 || slices["1"] != 1 {
+		t.Errorf("slices = %v, want 3 for level 0 and 1 for level 1", slices)
+	}
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+const (
+	// usageBucket is the granularity of the usage history.
+	usageBucket = time.Hour
+	// maxUsageSampleGap is the longest time a sample accounts for. Longer
+	// gaps, such as the monitor being down, are not billed to anyone.
+	maxUsageSampleGap = 30 * time.Second
+)
+
+var (
+	ctrMemorySecondsDesc = prometheus.NewDesc(
+		"hami_container_gpu_memory_byte_seconds_total",
+		"Device memory a container used, integrated over time, in byte-seconds",
+		[]string{"namespace", "pod", "container"}, nil,
+	)
+	ctrCoreSecondsDesc = prometheus.NewDesc(
+		"hami_container_gpu_core_seconds_total",
+		"SM utilization of a container integrated over time, in seconds of a whole GPU",
+		[]string{"namespace", "pod", "container"}, nil,
+	)
+	ctrActiveKernelSecondsDesc = prometheus.NewDesc(
+		"hami_container_gpu_active_kernel_seconds_total",
+		"Time a container was launching kernels, in seconds",
+		[]string{"namespace", "pod", "container"}, nil,
+	)
+)
+
+type usageKey struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+}
+
+type usageTotals struct {
+	MemoryByteSeconds   float64 `json:"memoryByteSeconds"`
+	CoreSeconds         float64 `json:"coreSeconds"`
+	ActiveKernelSeconds float64 `json:"activeKernelSeconds"`
+}
+
+func (t *usageTotals) add(o usageTotals) {
+	t.MemoryByteSeconds += o.MemoryByteSeconds
+	t.CoreSeconds += o.CoreSeconds
+	t.ActiveKernelSeconds += o.ActiveKernelSeconds
+}
+
+type usageRow struct {
+	usageKey
+	usageTotals
+}
+
+type usageHistoryBucket struct {
+	Start time.Time  `json:"start"`
+	Rows  []usageRow `json:"rows"`
+}
+
+// usageHistory is the on-disk form of a UsageRecorder.
+type usageHistory struct {
+	LastSample time.Time            `json:"lastSample"`
+	Totals     []usageRow           `json:"totals"`
+	Buckets    []usageHistoryBucket `json:"buckets"`
+}
+
+// UsageRecorder integrates the periodic container usage samples into
+// per-container GPU memory, core and active kernel seconds. It keeps running
+// totals, exported as Prometheus counters, and hourly buckets for the
+// retention period, served by ServeHTTP for chargeback. Both survive monitor
+// restarts through a file.
+type UsageRecorder struct {
+	path      string
+	retention time.Duration
+
+	mu         sync.Mutex
+	lastSample time.Time
+	totals     map[usageKey]*usageTotals
+	buckets    map[time.Time]map[usageKey]*usageTotals
+}
+
+// NewUsageRecorder returns a UsageRecorder persisting to path, restoring the
+// history stored there if any.
+func NewUsageRecorder(path string, retention time.Duration) (*UsageRecorder, error) {
+	u := &UsageRecorder{
+		path:      path,
+		retention: retention,
+		totals:    map[usageKey]*usageTotals{},
+		buckets:   map[time.Time]map[usageKey]*usageTotals{},
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return u, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage history: %v", err)
+	}
+	var history usageHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse usage history %s: %v", path, err)
+	}
+	u.lastSample = history.LastSample
+	for _, row := range history.Totals {
+		u.totals[row.usageKey] = &row.usageTotals
+	}
+	for _, b := range history.Buckets {
+		rows := map[usageKey]*usageTotals{}
+		for _, row := range b.Rows {
+			rows[row.usageKey] = &row.usageTotals
+		}
+		u.buckets[b.Start.UTC()] = rows
+	}
+	return u, nil
+}
+
+// Sample accounts the usage of containers since the previous sample.
+func (u *UsageRecorder) Sample(containers map[string]*nvidia.ContainerUsage, now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var dt float64
+	if gap := now.Sub(u.lastSample); gap > 0 && gap <= maxUsageSampleGap {
+		dt = gap.Seconds()
+	}
+	u.lastSample = now
+	bucketStart := now.UTC().Truncate(usageBucket)
+	bucket, ok := u.buckets[bucketStart]
+	if !ok {
+		bucket = map[usageKey]*usageTotals{}
+		u.buckets[bucketStart] = bucket
+	}
+
+	present := map[usageKey]bool{}
+	for _, c := range containers {
+		if c.PodName == "" {
+			continue
+		}
+		key := usageKey{Namespace: c.PodNamespace, Pod: c.PodName, Container: c.ContainerName}
+		present[key] = true
+		if dt <= 0 {
+			continue
+		}
+		sample := sampleUsage(c, now, dt)
+		if _, ok := u.totals[key]; !ok {
+			u.totals[key] = &usageTotals{}
+		}
+		u.totals[key].add(sample)
+		if _, ok := bucket[key]; !ok {
+			bucket[key] = &usageTotals{}
+		}
+		bucket[key].add(sample)
+	}
+	// The history keeps what containers that went away used; the running
+	// totals only cover the current ones.
+	for key := range u.totals {
+		if !present[key] {
+			delete(u.totals, key)
+		}
+	}
+	for start := range u.buckets {
+		if now.Sub(start) > u.retention+usageBucket {
+			delete(u.buckets, start)
+		}
+	}
+}
+
+func sampleUsage(c *nvidia.ContainerUsage, now time.Time, dt float64) usageTotals {
+	var memory, sm uint64
+	for i := range c.Info.DeviceNum() {
+		if !c.Info.IsValidUUID(i) {
+			continue
+		}
+		memory += c.Info.DeviceMemoryTotal(i)
+		sm += c.Info.DeviceSmUtil(i)
+	}
+	sample := usageTotals{
+		MemoryByteSeconds: float64(memory) * dt,
+		CoreSeconds:       float64(sm) / 100 * dt,
+	}
+	lastKernel := c.Info.LastKernelTime()
+	if c.Info.GetRecentKernel() > 0 || (lastKernel > 0 && float64(now.Unix()-lastKernel) <= dt) {
+		sample.ActiveKernelSeconds = dt
+	}
+	return sample
+}
+
+// Save writes the history to the recorder's file.
+func (u *UsageRecorder) Save() error {
+	u.mu.Lock()
+	history := usageHistory{LastSample: u.lastSample, Totals: usageRows(u.totals)}
+	for start, rows := range u.buckets {
+		history.Buckets = append(history.Buckets, usageHistoryBucket{Start: start, Rows: usageRows(rows)})
+	}
+	u.mu.Unlock()
+	slices.SortFunc(history.Buckets, func(a, b usageHistoryBucket) int { return a.Start.Compare(b.Start) })
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(u.path), 0o755); err != nil {
+		return err
+	}
+	tmp := u.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, u.path)
+}
+
+func usageRows(m map[usageKey]*usageTotals) []usageRow {
+	rows := make([]usageRow, 0, len(m))
+	for key, totals := range m {
+		rows = append(rows, usageRow{usageKey: key, usageTotals: *totals})
+	}
+	slices.SortFunc(rows, func(a, b usageRow) int {
+		return strings.Compare(a.Namespace+"/"+a.Pod+"/"+a.Container, b.Namespace+"/"+b.Pod+"/"+b.Container)
+	})
+	return rows
+}
+
+// Usage sums the history buckets starting in [from, to), grouped by
+// "container", "pod" or "namespace".
+func (u *UsageRecorder) Usage(from, to time.Time, by string) ([]usageRow, error) {
+	if by != "container" && by != "pod" && by != "namespace" {
+		return nil, fmt.Errorf("by must be one of container, pod, namespace, got %q", by)
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	sums := map[usageKey]*usageTotals{}
+	for start, rows := range u.buckets {
+		if start.Before(from.Truncate(usageBucket)) || !start.Before(to) {
+			continue
+		}
+		for key, totals := range rows {
+			switch by {
+			case "pod":
+				key.Container = ""
+			case "namespace":
+				key.Pod, key.Container = "", ""
+			}
+			if _, ok := sums[key]; !ok {
+				sums[key] = &usageTotals{}
+			}
+			sums[key].add(*totals)
+		}
+	}
+	return usageRows(sums), nil
+}
+
+// ServeHTTP exports the usage of a time window. The window is given by the
+// from and to query parameters in RFC 3339 and defaults to the last 24 hours;
+// by groups the rows by container (the default), pod or namespace, and format
+// selects json (the default) or csv.
+func (u *UsageRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+	var err error
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid from: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid to: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	by := query.Get("by")
+	if by == "" {
+		by = "container"
+	}
+	rows, err := u.Usage(from, to, by)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch query.Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"from": from, "to": to, "rows": rows})
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"namespace", "pod", "container", "memory_byte_seconds", "core_seconds", "active_kernel_seconds"})
+		for _, row := range rows {
+			_ = cw.Write([]string{row.Namespace, row.Pod, row.Container,
+				strconv.FormatFloat(row.MemoryByteSeconds, 'f', -1, 64),
+				strconv.FormatFloat(row.CoreSeconds, 'f', -1, 64),
+				strconv.FormatFloat(row.ActiveKernelSeconds, 'f', -1, 64)})
+		}
+		cw.Flush()
+	default:
+		http.Error(w, fmt.Sprintf("format must be json or csv, got %q", query.Get("format")), http.StatusBadRequest)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (u *UsageRecorder) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ctrMemorySecondsDesc
+	ch <- ctrCoreSecondsDesc
+	ch <- ctrActiveKernelSecondsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (u *UsageRecorder) Collect(ch chan<- prometheus.Metric) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key, t := range u.totals {
+		ch <- prometheus.MustNewConstMetric(ctrMemorySecondsDesc, prometheus.CounterValue, t.MemoryByteSeconds, key.Namespace, key.Pod, key.Container)
+		ch <- prometheus.MustNewConstMetric(ctrCoreSecondsDesc, prometheus.CounterValue, t.CoreSeconds, key.Namespace, key.Pod, key.Container)
+		ch <- prometheus.MustNewConstMetric(ctrActiveKernelSecondsDesc, prometheus.CounterValue, t.ActiveKernelSeconds, key.Namespace, key.Pod, key.Container)
+	}
+}
+
+// saveUsage persists the usage history, logging failures.
+func saveUsage(u *UsageRecorder) {
+	if err := u.Save(); err != nil {
+		klog.Errorf("Failed to save usage history: %v", err)
+	}
+}
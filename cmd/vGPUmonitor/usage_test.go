@@ -0,0 +1,168 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+func usageContainer(pod, container string, memory, smUtil uint64, recentKernel int32) *nvidia.ContainerUsage {
+	return &nvidia.ContainerUsage{
+		PodNamespace: "team-a", PodName: pod, ContainerName: container,
+		Info: &stubInfo{uuids: []string{"GPU-0"}, total: []uint64{memory}, smUtil: []uint64{smUtil}, recentKernel: recentKernel},
+	}
+}
+
+func TestUsageRecorderSample(t *testing.T) {
+	u, err := NewUsageRecorder(filepath.Join(t.TempDir(), "usage.json"), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 5, 1, 10, 59, 50, 0, time.UTC)
+	containers := map[string]*nvidia.ContainerUsage{
+		"a": usageContainer("trainer-0", "worker", 1000, 50, 1),
+		"b": usageContainer("trainer-1", "worker", 2000, 0, 0),
+	}
+	// The first sample only sets the clock.
+	u.Sample(containers, start)
+	u.Sample(containers, start.Add(5*time.Second))
+	u.Sample(containers, start.Add(15*time.Second))
+	// A gap longer than maxUsageSampleGap is not billed.
+	u.Sample(containers, start.Add(time.Minute+15*time.Second))
+
+	got := u.totals[usageKey{"team-a", "trainer-0", "worker"}]
+	if got == nil || got.MemoryByteSeconds != 15000 || got.CoreSeconds != 7.5 || got.ActiveKernelSeconds != 15 {
+		t.Fatalf("trainer-0 totals = %+v, want 15000 byte-seconds, 7.5 core-seconds, 15 active seconds", got)
+	}
+	if got := u.totals[usageKey{"team-a", "trainer-1", "worker"}]; got.ActiveKernelSeconds != 0 {
+		t.Errorf("idle container has %v active kernel seconds", got.ActiveKernelSeconds)
+	}
+
+	// The samples straddle an hour boundary.
+	rows, err := u.Usage(start, start.Add(time.Hour), "container")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].MemoryByteSeconds != 15000 {
+		t.Errorf("rows = %+v", rows)
+	}
+	rows, _ = u.Usage(start.Add(10*time.Second), start.Add(time.Hour), "namespace")
+	if len(rows) != 1 || rows[0].Namespace != "team-a" || rows[0].Pod != "" || rows[0].MemoryByteSeconds != 3*10000 {
+		t.Errorf("namespace rows for the second hour = %+v", rows)
+	}
+	if _, err := u.Usage(start, start, "node"); err == nil {
+		t.Error("unknown grouping should be rejected")
+	}
+
+	// Totals of containers that went away are dropped, their history is kept.
+	delete(containers, "b")
+	u.Sample(containers, start.Add(time.Minute+20*time.Second))
+	if _, ok := u.totals[usageKey{"team-a", "trainer-1", "worker"}]; ok {
+		t.Error("totals kept for a removed container")
+	}
+	rows, _ = u.Usage(start, start.Add(2*time.Hour), "pod")
+	if len(rows) != 2 {
+		t.Errorf("history lost the removed container: %+v", rows)
+	}
+
+	// History older than the retention is pruned.
+	u.Sample(containers, start.Add(26*time.Hour))
+	rows, _ = u.Usage(start, start.Add(2*time.Hour), "pod")
+	if len(rows) != 0 {
+		t.Errorf("expired history still served: %+v", rows)
+	}
+}
+
+func TestUsageRecorderPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook", "usage.json")
+	u, err := NewUsageRecorder(path, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	containers := map[string]*nvidia.ContainerUsage{"a": usageContainer("trainer-0", "worker", 1000, 100, 0)}
+	u.Sample(containers, now)
+	u.Sample(containers, now.Add(10*time.Second))
+	if err := u.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewUsageRecorder(path, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := usageKey{"team-a", "trainer-0", "worker"}
+	if got := restored.totals[key]; got == nil || got.CoreSeconds != 10 {
+		t.Fatalf("restored totals = %+v, want 10 core-seconds", got)
+	}
+	// Sampling resumes from the persisted clock.
+	restored.Sample(containers, now.Add(15*time.Second))
+	if got := restored.totals[key].CoreSeconds; got != 15 {
+		t.Errorf("core-seconds after restart = %v, want 15", got)
+	}
+
+	ch := make(chan prometheus.Metric, 8)
+	restored.Collect(ch)
+	close(ch)
+	for m := range ch {
+		if m.Desc() == ctrCoreSecondsDesc {
+			if v := counterValue(t, m); v != 15 {
+				t.Errorf("core-seconds counter = %v, want 15", v)
+			}
+		}
+	}
+}
+
+func TestUsageRecorderServeHTTP(t *testing.T) {
+	u, err := NewUsageRecorder(filepath.Join(t.TempDir(), "usage.json"), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	containers := map[string]*nvidia.ContainerUsage{"a": usageContainer("trainer-0", "worker", 1000, 100, 1)}
+	u.Sample(containers, start)
+	u.Sample(containers, start.Add(10*time.Second))
+
+	rec := httptest.NewRecorder()
+	u.ServeHTTP(rec, httptest.NewRequest("GET", "/usage?from=2026-05-01T10:00:00Z&to=2026-05-01T11:00:00Z&format=csv&by=pod", nil))
+	want := "namespace,pod,container,memory_byte_seconds,core_seconds,active_kernel_seconds\nteam-a,trainer-0,,10000,10,10\n"
+	if rec.Code != 200 || rec.Body.String() != want {
+		t.Errorf("csv export = %d %q, want %q", rec.Code, rec.Body.String(), want)
+	}
+
+	rec = httptest.NewRecorder()
+	u.ServeHTTP(rec, httptest.NewRequest("GET", "/usage?from=2026-05-01T10:00:00Z&to=2026-05-01T11:00:00Z", nil))
+	if rec.Code != 200 || !strings.Contains(rec.Body.String(), `"container":"worker","memoryByteSeconds":10000`) {
+		t.Errorf("json export = %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, query := range []string{"from=yesterday", "format=xml", "by=node"} {
+		rec = httptest.NewRecorder()
+		u.ServeHTTP(rec, httptest.NewRequest("GET", "/usage?"+query, nil))
+		if rec.Code != 400 {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+// MemoryWatchdogPolicy decides what the watchdog does about a container using
+// more device memory than its limit.
+type MemoryWatchdogPolicy string
+
+const (
+	// WatchdogOff disables the watchdog.
+	WatchdogOff MemoryWatchdogPolicy = "off"
+	// WatchdogReport records a pod event and exports the excess as a metric.
+	WatchdogReport MemoryWatchdogPolicy = "report"
+	// WatchdogKill reports like WatchdogReport and also kills the container
+	// process using the most memory on the device, one per feedback tick,
+	// until the container is back under its limit.
+	WatchdogKill MemoryWatchdogPolicy = "kill"
+)
+
+const (
+	// EventReasonMemoryExceeded indicates that a container went over its
+	// vGPU memory limit.
+	EventReasonMemoryExceeded = "VGPUMemoryExceeded"
+	// EventReasonProcessKilled indicates that the watchdog killed a process
+	// of a container over its vGPU memory limit.
+	EventReasonProcessKilled = "VGPUProcessKilled"
+)
+
+var (
+	ctrMemoryExceededDesc = prometheus.NewDesc(
+		"hami_vgpu_memory_exceeded_bytes",
+		"Device memory a container uses above its vGPU memory limit, by the larger of the libvgpu and NVML accounting",
+		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
+	)
+	ctrMemoryWatchdogKillsDesc = prometheus.NewDesc(
+		"hami_vgpu_memory_watchdog_kills_total",
+		"Processes the memory watchdog killed for exceeding the vGPU memory limit",
+		[]string{"namespace", "pod", "container"}, nil,
+	)
+)
+
+// ParseMemoryWatchdogPolicy validates the --memory-watchdog flag.
+func ParseMemoryWatchdogPolicy(value string) (MemoryWatchdogPolicy, error) {
+	switch p := MemoryWatchdogPolicy(value); p {
+	case WatchdogOff, WatchdogReport, WatchdogKill:
+		return p, nil
+	}
+	return "", fmt.Errorf("must be one of off, report, kill, got %q", value)
+}
+
+// deviceProcesses maps a device UUID to the compute processes NVML reports
+// on it.
+type deviceProcesses map[string][]nvml.ProcessInfo
+
+type memoryViolation struct {
+	namespace, pod, container string
+	vdevice                   int
+	uuid                      string
+	excess                    uint64
+}
+
+type killKey struct {
+	namespace, pod, container string
+}
+
+// MemoryWatchdog cross-checks the memory libvgpu accounts for each container
+// with what NVML reports for the container's processes, so containers that
+// bypass libvgpu are caught too. Processes missing from the container's shared
+// region are attributed to it through their cgroup.
+type MemoryWatchdog struct {
+	policy   MemoryWatchdogPolicy
+	recorder record.EventRecorder
+	kill     func(pid int) error
+	procRoot string
+
+	mu         sync.Mutex
+	violations map[string]memoryViolation
+	kills      map[killKey]uint64
+}
+
+func NewMemoryWatchdog(policy MemoryWatchdogPolicy, recorder record.EventRecorder) *MemoryWatchdog {
+	return &MemoryWatchdog{
+		policy:   policy,
+		recorder: recorder,
+		kill: func(pid int) error {
+			return syscall.Kill(pid, syscall.SIGKILL)
+		},
+		procRoot:   "/proc",
+		violations: map[string]memoryViolation{},
+		kills:      map[killKey]uint64{},
+	}
+}
+
+// Check compares every container's device memory with its limits and acts on
+// the violators according to the policy. pods are the node's pods, which the
+// cgroups of processes libvgpu does not know are matched with.
+func (w *MemoryWatchdog) Check(containers map[string]*nvidia.ContainerUsage, pods []*corev1.Pod, procs deviceProcesses) {
+	owners := w.processOwners(containers, pods, procs)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current := map[string]memoryViolation{}
+	present := map[killKey]bool{}
+	for key, c := range containers {
+		present[killKey{c.PodNamespace, c.PodName, c.ContainerName}] = true
+		for i := range c.Info.DeviceNum() {
+			if !c.Info.IsValidUUID(i) {
+				continue
+			}
+			limit := c.Info.DeviceMemoryLimit(i)
+			if limit == 0 {
+				continue
+			}
+			uuid := strings.TrimRight(c.Info.DeviceUUID(i), "\x00")
+			var nvmlUsed uint64
+			var largest *nvml.ProcessInfo
+			for j, p := range procs[uuid] {
+				if owners[p.Pid] != key {
+					continue
+				}
+				nvmlUsed += p.UsedGpuMemory
+				if largest == nil || p.UsedGpuMemory > largest.UsedGpuMemory {
+					largest = &procs[uuid][j]
+				}
+			}
+			used := max(c.Info.DeviceMemoryTotal(i), nvmlUsed)
+			if used <= limit {
+				continue
+			}
+			v := memoryViolation{namespace: c.PodNamespace, pod: c.PodName, container: c.ContainerName, vdevice: i, uuid: uuid, excess: used - limit}
+			vkey := fmt.Sprintf("%s/%d", key, i)
+			current[vkey] = v
+			if _, ok := w.violations[vkey]; !ok {
+				klog.InfoS("Container exceeds its vGPU memory limit", "pod", klog.KRef(c.PodNamespace, c.PodName), "container", c.ContainerName,
+					"device", uuid, "limit", limit, "libvgpuUsed", c.Info.DeviceMemoryTotal(i), "nvmlUsed", nvmlUsed)
+				w.event(c, corev1.EventTypeWarning, EventReasonMemoryExceeded,
+					fmt.Sprintf("Container %s uses %d bytes on device %s, over its limit of %d bytes", c.ContainerName, used, uuid, limit))
+			}
+			if w.policy == WatchdogKill && largest != nil {
+				w.killProcess(c, int(largest.Pid), uuid)
+			}
+		}
+	}
+	w.violations = current
+	for k := range w.kills {
+		if !present[k] {
+			delete(w.kills, k)
+		}
+	}
+}
+
+// processOwners maps the pid of every process in procs to the key of the
+// container in containers it belongs to: the one whose shared region lists
+// it, or else the one its cgroup is in.
+func (w *MemoryWatchdog) processOwners(containers map[string]*nvidia.ContainerUsage, pods []*corev1.Pod, procs deviceProcesses) map[uint32]string {
+	owners := map[uint32]string{}
+	byName := make(map[string]string, len(containers))
+	for key, c := range containers {
+		for _, pid := range c.Info.HostPIDs() {
+			owners[uint32(pid)] = key
+		}
+		byName[c.PodUID+"/"+c.ContainerName] = key
+	}
+	podsByUID := make(map[string]*corev1.Pod, len(pods))
+	for _, pod := range pods {
+		podsByUID[string(pod.UID)] = pod
+	}
+	for _, infos := range procs {
+		for _, info := range infos {
+			if _, ok := owners[info.Pid]; ok {
+				continue
+			}
+			pod, container := processOwner(w.procRoot, info.Pid, podsByUID)
+			if pod == nil || container == "" {
+				continue
+			}
+			if key, ok := byName[string(pod.UID)+"/"+container]; ok {
+				owners[info.Pid] = key
+			}
+		}
+	}
+	return owners
+}
+
+func (w *MemoryWatchdog) killProcess(c *nvidia.ContainerUsage, pid int, uuid string) {
+	if err := w.kill(pid); err != nil {
+		klog.ErrorS(err, "Failed to kill process over its vGPU memory limit", "pod", klog.KRef(c.PodNamespace, c.PodName), "container", c.ContainerName, "pid", pid)
+		return
+	}
+	w.kills[killKey{c.PodNamespace, c.PodName, c.ContainerName}]++
+	klog.InfoS("Killed process over its vGPU memory limit", "pod", klog.KRef(c.PodNamespace, c.PodName), "container", c.ContainerName, "pid", pid, "device", uuid)
+	w.event(c, corev1.EventTypeWarning, EventReasonProcessKilled,
+		fmt.Sprintf("Killed process %d of container %s for exceeding its memory limit on device %s", pid, c.ContainerName, uuid))
+}
+
+func (w *MemoryWatchdog) event(c *nvidia.ContainerUsage, eventType, reason, message string) {
+	if w.recorder == nil || c.PodName == "" {
+		return
+	}
+	ref := &corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: c.PodNamespace, Name: c.PodName, UID: types.UID(c.PodUID)}
+	w.recorder.Event(ref, eventType, reason, message)
+}
+
+// Describe implements prometheus.Collector.
+func (w *MemoryWatchdog) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ctrMemoryExceededDesc
+	ch <- ctrMemoryWatchdogKillsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (w *MemoryWatchdog) Collect(ch chan<- prometheus.Metric) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, v := range w.violations {
+		ch <- prometheus.MustNewConstMetric(ctrMemoryExceededDesc, prometheus.GaugeValue, float64(v.excess),
+			v.namespace, v.pod, v.container, fmt.Sprint(v.vdevice), v.uuid)
+	}
+	for k, n := range w.kills {
+		ch <- prometheus.MustNewConstMetric(ctrMemoryWatchdogKillsDesc, prometheus.CounterValue, float64(n), k.namespace, k.pod, k.container)
+	}
+}
+
+// nvmlDeviceProcesses lists the compute processes of every GPU. NVML must
+// already be initialized.
+func nvmlDeviceProcesses() (deviceProcesses, error) {
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("failed to get device count: %s", nvml.ErrorString(ret))
+	}
+	procs := deviceProcesses{}
+	for i := range count {
+		dev, ret := nvml.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get device %d: %s", i, nvml.ErrorString(ret))
+		}
+		uuid, ret := dev.GetUUID()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get UUID of device %d: %s", i, nvml.ErrorString(ret))
+		}
+		infos, ret := dev.GetComputeRunningProcesses()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("failed to get processes of device %s: %s", uuid, nvml.ErrorString(ret))
+		}
+		procs[uuid] = infos
+	}
+	return procs, nil
+}
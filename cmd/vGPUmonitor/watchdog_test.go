@@ -0,0 +1,179 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Project-HAMi/HAMi/pkg/monitor/nvidia"
+)
+
+func watchdogContainer(used, limit uint64, pids ...int32) *nvidia.ContainerUsage {
+	return &nvidia.ContainerUsage{
+		PodUID: "uid-1", PodNamespace: "team-a", PodName: "trainer-0", ContainerName: "worker",
+		Info: &stubInfo{uuids: []string{"GPU-0\x00\x00"}, total: []uint64{used}, limit: []uint64{limit}, hostPIDs: pids},
+	}
+}
+
+func TestParseMemoryWatchdogPolicy(t *testing.T) {
+	for _, value := range []string{"off", "report", "kill"} {
+		if _, err := ParseMemoryWatchdogPolicy(value); err != nil {
+			t.Errorf("%q: %v", value, err)
+		}
+	}
+	if _, err := ParseMemoryWatchdogPolicy("oom"); err == nil {
+		t.Error("unknown policy should be rejected")
+	}
+}
+
+func TestMemoryWatchdogReport(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	w := NewMemoryWatchdog(WatchdogReport, recorder)
+	w.kill = func(int) error {
+		t.Fatal("report policy must not kill")
+		return nil
+	}
+	// libvgpu accounts 900 of 1000 bytes, but NVML sees 1500 for the
+	// container's processes: the container bypasses libvgpu.
+	containers := map[string]*nvidia.ContainerUsage{"uid-1_worker": watchdogContainer(900, 1000, 10, 11)}
+	procs := deviceProcesses{"GPU-0": {{Pid: 10, UsedGpuMemory: 1000}, {Pid: 11, UsedGpuMemory: 500}, {Pid: 99, UsedGpuMemory: 4000}}}
+
+	w.Check(containers, nil, procs)
+	w.Check(containers, nil, procs)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("got %d events, want one per violation", len(recorder.Events))
+	}
+	if got := <-recorder.Events; got != "Warning VGPUMemoryExceeded Container worker uses 1500 bytes on device GPU-0, over its limit of 1000 bytes" {
+		t.Errorf("unexpected event %q", got)
+	}
+	if got := collectWatchdog(t, w)[ctrMemoryExceededDesc]; got != 500 {
+		t.Errorf("exceeded bytes = %v, want 500", got)
+	}
+
+	// Back under the limit the metric goes away.
+	w.Check(containers, nil, deviceProcesses{"GPU-0": {{Pid: 10, UsedGpuMemory: 800}}})
+	if _, ok := collectWatchdog(t, w)[ctrMemoryExceededDesc]; ok {
+		t.Error("exceeded metric still reported after the container recovered")
+	}
+}
+
+func TestMemoryWatchdogKill(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	w := NewMemoryWatchdog(WatchdogKill, recorder)
+	var killed []int
+	w.kill = func(pid int) error {
+		killed = append(killed, pid)
+		return nil
+	}
+	containers := map[string]*nvidia.ContainerUsage{"uid-1_worker": watchdogContainer(1200, 1000, 10, 11)}
+	w.Check(containers, nil, deviceProcesses{"GPU-0": {{Pid: 10, UsedGpuMemory: 200}, {Pid: 11, UsedGpuMemory: 1000}}})
+	if len(killed) != 1 || killed[0] != 11 {
+		t.Fatalf("killed %v, want the largest process 11", killed)
+	}
+	if len(recorder.Events) != 2 {
+		t.Errorf("got %d events, want the violation and the kill", len(recorder.Events))
+	}
+	if got := collectWatchdog(t, w)[ctrMemoryWatchdogKillsDesc]; got != 1 {
+		t.Errorf("kills = %v, want 1", got)
+	}
+
+	// Without NVML processes there is nothing to kill.
+	killed = nil
+	w.Check(containers, nil, deviceProcesses{})
+	if len(killed) != 0 {
+		t.Errorf("killed %v without knowing the container's processes", killed)
+	}
+
+	// Kill counters of containers that went away are dropped.
+	w.Check(map[string]*nvidia.ContainerUsage{}, nil, deviceProcesses{})
+	if _, ok := collectWatchdog(t, w)[ctrMemoryWatchdogKillsDesc]; ok {
+		t.Error("kill counter kept for a removed container")
+	}
+}
+
+func TestMemoryWatchdogAttributesByCgroup(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	w := NewMemoryWatchdog(WatchdogKill, recorder)
+	w.procRoot = t.TempDir()
+	var killed []int
+	w.kill = func(pid int) error {
+		killed = append(killed, pid)
+		return nil
+	}
+	// Process 12 bypasses libvgpu, so only its cgroup ties it to the
+	// container; process 13 belongs to another container of the pod.
+	writeCgroup(t, w.procRoot, "12", "0::/kubepods.slice/kubepods-pod6a3c1f2e_8d4b_4c5a_9e7f_0123456789ab.slice/cri-containerd-"+testContainerID+".scope\n")
+	writeCgroup(t, w.procRoot, "13", "0::/kubepods.slice/kubepods-pod6a3c1f2e_8d4b_4c5a_9e7f_0123456789ab.slice/cri-containerd-"+strings.Repeat("ab", 32)+".scope\n")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{UID: testPodUID, Namespace: "team-a", Name: "trainer-0"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "worker", ContainerID: "containerd://" + testContainerID},
+			{Name: "sidecar", ContainerID: "containerd://" + strings.Repeat("ab", 32)},
+		}},
+	}
+	c := watchdogContainer(300, 1000, 10)
+	c.PodUID = testPodUID
+	containers := map[string]*nvidia.ContainerUsage{"worker": c}
+	procs := deviceProcesses{"GPU-0": {{Pid: 10, UsedGpuMemory: 300}, {Pid: 12, UsedGpuMemory: 900}, {Pid: 13, UsedGpuMemory: 5000}}}
+
+	w.Check(containers, []*corev1.Pod{pod}, procs)
+	if got := collectWatchdog(t, w)[ctrMemoryExceededDesc]; got != 200 {
+		t.Errorf("exceeded bytes = %v, want 200 counting process 12 but not 13", got)
+	}
+	if len(killed) != 1 || killed[0] != 12 {
+		t.Errorf("killed %v, want the process missing from the shared region", killed)
+	}
+
+	// Without the pod the process cannot be attributed.
+	killed = nil
+	w.Check(containers, nil, procs)
+	if len(killed) != 0 {
+		t.Errorf("killed %v without attributing any process over the limit", killed)
+	}
+}
+
+func collectWatchdog(t *testing.T, w *MemoryWatchdog) map[*prometheus.Desc]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 16)
+	w.Collect(ch)
+	close(ch)
+	got := map[*prometheus.Desc]float64{}
+	for m := range ch {
+		v, _ := gaugeValue(t, m)
+		if m.Desc() == ctrMemoryWatchdogKillsDesc {
+			v = counterValue(t, m)
+		}
+		got[m.Desc()] = v
+	}
+	return got
+}
+
+func counterValue(t *testing.T, m prometheus.Metric) float64 {
+	t.Helper()
+	var d dto.Metric
+	if err := m.Write(&d); err != nil {
+		t.Fatal(err)
+	}
+	return d.GetCounter().GetValue()
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package conformance
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+// The environment variables HAMi-core enforces a container's limits by.
+const (
+	// MemoryLimitEnvPrefix is followed by the index of the device among the
+	// container's devices; the value is the limit in MiB with an "m" suffix.
+	MemoryLimitEnvPrefix = "CUDA_DEVICE_MEMORY_LIMIT_"
+	// CoreLimitEnv is the percentage of SMs the container may use.
+	CoreLimitEnv = "CUDA_DEVICE_SM_LIMIT"
+)
+
+// RequireMemoryLimits asserts resp limits the container's devices to
+// limitsMiB, in order, and no other device.
+func RequireMemoryLimits(t testing.TB, resp *kubeletdevicepluginv1beta1.ContainerAllocateResponse, limitsMiB ...int32) {
+	t.Helper()
+	want := make(map[string]string, len(limitsMiB))
+	for i, limit := range limitsMiB {
+		want[fmt.Sprintf("%s%d", MemoryLimitEnvPrefix, i)] = fmt.Sprintf("%dm", limit)
+	}
+	got := make(map[string]string)
+	for name, value := range resp.Envs {
+		if strings.HasPrefix(name, MemoryLimitEnvPrefix) {
+			got[name] = value
+		}
+	}
+	require.Equal(t, want, got, "memory limit envs")
+}
+
+// RequireCoreLimit asserts resp limits the container to cores percent of
+// the SMs.
+func RequireCoreLimit(t testing.TB, resp *kubeletdevicepluginv1beta1.ContainerAllocateResponse, cores int32) {
+	t.Helper()
+	require.Contains(t, resp.Envs, CoreLimitEnv)
+	require.Equal(t, fmt.Sprint(cores), resp.Envs[CoreLimitEnv], "core limit env")
+}
+
+// RequireNoLimits asserts resp sets neither memory nor core limits, as for
+// devices a container gets whole.
+func RequireNoLimits(t testing.TB, resp *kubeletdevicepluginv1beta1.ContainerAllocateResponse) {
+	t.Helper()
+	for name := range resp.Envs {
+		require.False(t, strings.HasPrefix(name, MemoryLimitEnvPrefix), "unexpected memory limit env %s", name)
+	}
+	require.NotContains(t, resp.Envs, CoreLimitEnv)
+}
+
+// RequireMount asserts resp mounts hostPath at containerPath.
+func RequireMount(t testing.TB, resp *kubeletdevicepluginv1beta1.ContainerAllocateResponse, containerPath, hostPath string, readOnly bool) {
+	t.Helper()
+	for _, m := range resp.Mounts {
+		if m.ContainerPath == containerPath {
+			require.Equal(t, hostPath, m.HostPath, "host path mounted at %s", containerPath)
+			require.Equal(t, readOnly, m.ReadOnly, "read-only mount at %s", containerPath)
+			return
+		}
+	}
+	require.FailNow(t, "mount not found", "no mount at %s in %v", containerPath, resp.Mounts)
+}
+
+// RequireNoMount asserts resp mounts nothing at containerPath.
+func RequireNoMount(t testing.TB, resp *kubeletdevicepluginv1beta1.ContainerAllocateResponse, containerPath string) {
+	t.Helper()
+	for _, m := range resp.Mounts {
+		require.NotEqual(t, containerPath, m.ContainerPath, "unexpected mount of %s", m.HostPath)
+	}
+}
+
+// CDIDevices returns the fully qualified CDI device names resp requests
+// through annotations with prefix, such as "cdi.k8s.io/".
+func CDIDevices(resp *kubeletdevicepluginv1beta1.ContainerAllocateResponse, prefix string) []string {
+	var devices []string
+	for key, value := range resp.Annotations {
+		if strings.HasPrefix(key, prefix) && value != "" {
+			devices = append(devices, strings.Split(value, ",")...)
+		}
+	}
+	return devices
+}
+
+// RequireCDIDevices asserts resp requests exactly devices, in any order,
+// through CDI annotations with prefix.
+func RequireCDIDevices(t testing.TB, resp *kubeletdevicepluginv1beta1.ContainerAllocateResponse, prefix string, devices ...string) {
+	t.Helper()
+	require.ElementsMatch(t, devices, CDIDevices(resp, prefix), "CDI devices annotated with %s", prefix)
+}
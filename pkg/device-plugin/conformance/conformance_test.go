@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package conformance
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const stubDevice = "Stub"
+
+// stubPlugin hands out the devices annotated on the pod being allocated,
+// limiting the container to their memory and cores like HAMi-core does.
+type stubPlugin struct {
+	kubeletdevicepluginv1beta1.UnimplementedDevicePluginServer
+}
+
+func (stubPlugin) ListAndWatch(_ *kubeletdevicepluginv1beta1.Empty, s kubeletdevicepluginv1beta1.DevicePlugin_ListAndWatchServer) error {
+	return s.Send(&kubeletdevicepluginv1beta1.ListAndWatchResponse{Devices: []*kubeletdevicepluginv1beta1.Device{
+		{ID: "stub-0", Health: kubeletdevicepluginv1beta1.Healthy},
+	}})
+}
+
+func (stubPlugin) Allocate(ctx context.Context, _ *kubeletdevicepluginv1beta1.AllocateRequest) (*kubeletdevicepluginv1beta1.AllocateResponse, error) {
+	pod, err := util.GetPendingPod(ctx, "node1")
+	if err != nil {
+		return nil, err
+	}
+	devices, err := device.DecodePodDevices(device.InRequestDevices, pod.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	dev := devices[stubDevice][0][0]
+	return &kubeletdevicepluginv1beta1.AllocateResponse{ContainerResponses: []*kubeletdevicepluginv1beta1.ContainerAllocateResponse{{
+		Envs: map[string]string{
+			MemoryLimitEnvPrefix + "0": "1024m",
+			CoreLimitEnv:               "30",
+		},
+		Mounts:      []*kubeletdevicepluginv1beta1.Mount{{ContainerPath: "/usr/lib/libstub.so", HostPath: "/opt/stub/libstub.so", ReadOnly: true}},
+		Annotations: map[string]string{"cdi.k8s.io/stub_" + dev.UUID: "example.com/stub=" + dev.UUID},
+	}}}, nil
+}
+
+func register(t *testing.T, k *Kubelet, version string) error {
+	conn, err := grpc.NewClient("unix://"+k.Socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = kubeletdevicepluginv1beta1.NewRegistrationClient(conn).Register(context.Background(), &kubeletdevicepluginv1beta1.RegisterRequest{
+		Version:      version,
+		Endpoint:     "stub.sock",
+		ResourceName: "example.com/stub",
+	})
+	return err
+}
+
+func TestKubelet(t *testing.T) {
+	device.InRequestDevices[stubDevice] = "example.com/stub-devices-to-allocate"
+	t.Cleanup(func() { delete(device.InRequestDevices, stubDevice) })
+	k := NewKubelet(t, "node1")
+
+	sock, err := net.Listen("unix", k.PluginSocket("stub"))
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	kubeletdevicepluginv1beta1.RegisterDevicePluginServer(server, stubPlugin{})
+	go server.Serve(sock)
+	t.Cleanup(server.Stop)
+
+	require.ErrorContains(t, register(t, k, "v1alpha"), "unsupported device plugin API version")
+	require.NoError(t, register(t, k, kubeletdevicepluginv1beta1.Version))
+	p := k.WaitForPlugin("example.com/stub")
+	require.Equal(t, []string{"stub-0"}, DeviceIDs(p.ListAndWatch().Next()))
+
+	pod := k.BindPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}}, stubDevice, device.PodSingleDevice{
+		{{UUID: "stub-uuid", Type: stubDevice, Usedmem: 1024, Usedcores: 30}},
+	})
+	require.Equal(t, "node1", pod.Spec.NodeName)
+	k.RequireBindPhase(pod, util.DeviceBindAllocating)
+
+	resp, err := p.Allocate("stub-0")
+	require.NoError(t, err)
+	RequireMemoryLimits(t, resp, 1024)
+	RequireCoreLimit(t, resp, 30)
+	RequireMount(t, resp, "/usr/lib/libstub.so", "/opt/stub/libstub.so", true)
+	RequireNoMount(t, resp, "/etc/ld.so.preload")
+	RequireCDIDevices(t, resp, "cdi.k8s.io/", "example.com/stub=stub-uuid")
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+// Package conformance is a fake kubelet for testing device plugins built
+// against HAMi's annotation protocol. It serves the device plugin
+// registration API on a unix socket, binds pods the way the scheduler does in
+// a fake clientset, and drives the plugins that register with it through
+// ListAndWatch, GetPreferredAllocation and Allocate.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+// Timeout bounds every wait on a plugin: for it to register, to send devices
+// and to answer a call.
+var Timeout = 10 * time.Second
+
+// Kubelet is a fake kubelet. Plugins under test find the pods to allocate
+// for through client.KubeClient and the NODE_NAME environment variable, which
+// NewKubelet points at Client and NodeName until the test ends.
+type Kubelet struct {
+	// Dir holds the kubelet socket and is where plugins should serve theirs.
+	Dir string
+	// Socket is the registration socket plugins register on.
+	Socket   string
+	NodeName string
+	Client   *fake.Clientset
+
+	t             testing.TB
+	registrations chan *kubeletdevicepluginv1beta1.RegisterRequest
+}
+
+// NewKubelet starts a fake kubelet for the node nodeName, whose Node object
+// and objs are in its clientset. It is stopped when the test ends.
+func NewKubelet(t testing.TB, nodeName string, objs ...runtime.Object) *Kubelet {
+	t.Helper()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	k := &Kubelet{
+		Dir:           t.TempDir(),
+		NodeName:      nodeName,
+		Client:        fake.NewSimpleClientset(append([]runtime.Object{node}, objs...)...),
+		t:             t,
+		registrations: make(chan *kubeletdevicepluginv1beta1.RegisterRequest, 8),
+	}
+	k.Socket = filepath.Join(k.Dir, "kubelet.sock")
+
+	orig := client.KubeClient
+	client.KubeClient = k.Client
+	t.Cleanup(func() { client.KubeClient = orig })
+	t.Setenv(util.NodeNameEnvName, nodeName)
+
+	sock, err := net.Listen("unix", k.Socket)
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	kubeletdevicepluginv1beta1.RegisterRegistrationServer(server, &registrationServer{k: k})
+	go server.Serve(sock)
+	t.Cleanup(server.Stop)
+	return k
+}
+
+// PluginSocket returns the socket a plugin serving resource name should
+// listen on, so that the endpoint it registers resolves in Dir.
+func (k *Kubelet) PluginSocket(name string) string {
+	return filepath.Join(k.Dir, name+".sock")
+}
+
+// WaitForPlugin waits for a plugin to register resourceName and connects to
+// it.
+func (k *Kubelet) WaitForPlugin(resourceName string) *Plugin {
+	k.t.Helper()
+	var req *kubeletdevicepluginv1beta1.RegisterRequest
+	select {
+	case req = <-k.registrations:
+	case <-time.After(Timeout):
+		require.FailNow(k.t, "no device plugin registered", "waited %v for %s", Timeout, resourceName)
+	}
+	require.Equal(k.t, resourceName, req.ResourceName, "registered resource")
+
+	conn, err := grpc.NewClient("unix://"+filepath.Join(k.Dir, req.Endpoint),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(k.t, err)
+	k.t.Cleanup(func() { conn.Close() })
+	return &Plugin{
+		Registration: req,
+		Client:       kubeletdevicepluginv1beta1.NewDevicePluginClient(conn),
+		t:            k.t,
+	}
+}
+
+// BindPod creates pod bound to the kubelet's node the way the scheduler
+// binds it: devices, one entry per init then regular container, are
+// recorded under devType's annotations and the pod waits for its devices to
+// be allocated. devType must have been registered with device.InRequestDevices;
+// it is added to the device types the scheduler handles, which plugins check
+// to tell whether a pod has devices left to allocate, until the test ends.
+func (k *Kubelet) BindPod(pod *corev1.Pod, devType string, devices device.PodSingleDevice) *corev1.Pod {
+	k.t.Helper()
+	inRequest, ok := device.InRequestDevices[devType]
+	require.True(k.t, ok, "device type %s has no request annotation", devType)
+	if toHandle := device.DevicesToHandle; !slices.Contains(toHandle, devType) {
+		devicesMap := device.GetDevices()
+		device.SetDevices(devicesMap, append(slices.Clone(toHandle), devType))
+		k.t.Cleanup(func() { device.SetDevices(devicesMap, toHandle) })
+	}
+
+	pod = pod.DeepCopy()
+	if pod.Namespace == "" {
+		pod.Namespace = metav1.NamespaceDefault
+	}
+	if pod.UID == "" {
+		pod.UID = types.UID("uid-" + pod.Name)
+	}
+	pod.Spec.NodeName = k.NodeName
+	pod.Status.Phase = corev1.PodPending
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	encoded := device.EncodePodSingleDevice(devices)
+	pod.Annotations[inRequest] = encoded
+	if support, ok := device.SupportDevices[devType]; ok {
+		pod.Annotations[support] = encoded
+	}
+	pod.Annotations[util.AssignedNodeAnnotations] = k.NodeName
+	pod.Annotations[util.BindTimeAnnotations] = strconv.FormatInt(time.Now().Unix(), 10)
+	pod.Annotations[util.DeviceBindPhase] = util.DeviceBindAllocating
+
+	created, err := k.Client.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	require.NoError(k.t, err)
+	return created
+}
+
+// Pod returns the current state of pod.
+func (k *Kubelet) Pod(pod *corev1.Pod) *corev1.Pod {
+	k.t.Helper()
+	current, err := k.Client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	require.NoError(k.t, err)
+	return current
+}
+
+// RequireBindPhase asserts the bind phase the plugins left pod in.
+func (k *Kubelet) RequireBindPhase(pod *corev1.Pod, phase string) {
+	k.t.Helper()
+	require.Equal(k.t, phase, k.Pod(pod).Annotations[util.DeviceBindPhase], "bind phase of pod %s/%s", pod.Namespace, pod.Name)
+}
+
+type registrationServer struct {
+	kubeletdevicepluginv1beta1.UnimplementedRegistrationServer
+	k *Kubelet
+}
+
+// Register accepts plugins speaking the kubelet's device plugin API version,
+// as the kubelet does.
+func (s *registrationServer) Register(_ context.Context, req *kubeletdevicepluginv1beta1.RegisterRequest) (*kubeletdevicepluginv1beta1.Empty, error) {
+	if req.Version != kubeletdevicepluginv1beta1.Version {
+		return nil, fmt.Errorf("unsupported device plugin API version %q, want %q", req.Version, kubeletdevicepluginv1beta1.Version)
+	}
+	if filepath.Base(req.Endpoint) != req.Endpoint {
+		return nil, fmt.Errorf("endpoint %q must be a socket name in the device plugin directory", req.Endpoint)
+	}
+	s.k.registrations <- req
+	return &kubeletdevicepluginv1beta1.Empty{}, nil
+}
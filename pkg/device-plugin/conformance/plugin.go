@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+// Plugin is a registered device plugin, called as the kubelet calls it.
+type Plugin struct {
+	Registration *kubeletdevicepluginv1beta1.RegisterRequest
+	Client       kubeletdevicepluginv1beta1.DevicePluginClient
+
+	t testing.TB
+}
+
+// Watch is an open ListAndWatch stream.
+type Watch struct {
+	t       testing.TB
+	updates chan []*kubeletdevicepluginv1beta1.Device
+}
+
+// ListAndWatch opens a ListAndWatch stream, which is closed when the test
+// ends.
+func (p *Plugin) ListAndWatch() *Watch {
+	p.t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	p.t.Cleanup(cancel)
+	stream, err := p.Client.ListAndWatch(ctx, &kubeletdevicepluginv1beta1.Empty{})
+	require.NoError(p.t, err)
+
+	w := &Watch{t: p.t, updates: make(chan []*kubeletdevicepluginv1beta1.Device, 16)}
+	go func() {
+		defer close(w.updates)
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			w.updates <- resp.Devices
+		}
+	}()
+	return w
+}
+
+// Next returns the next device list the plugin sends.
+func (w *Watch) Next() []*kubeletdevicepluginv1beta1.Device {
+	w.t.Helper()
+	select {
+	case devices, ok := <-w.updates:
+		require.True(w.t, ok, "ListAndWatch stream ended")
+		return devices
+	case <-time.After(Timeout):
+		require.FailNow(w.t, "no ListAndWatch update", "waited %v", Timeout)
+		return nil
+	}
+}
+
+// DeviceIDs returns the IDs of devices.
+func DeviceIDs(devices []*kubeletdevicepluginv1beta1.Device) []string {
+	ids := make([]string, 0, len(devices))
+	for _, d := range devices {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+// PreferredAllocation asks the plugin which size of available, including
+// mustInclude, a container should get.
+func (p *Plugin) PreferredAllocation(available, mustInclude []string, size int) []string {
+	p.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	resp, err := p.Client.GetPreferredAllocation(ctx, &kubeletdevicepluginv1beta1.PreferredAllocationRequest{
+		ContainerRequests: []*kubeletdevicepluginv1beta1.ContainerPreferredAllocationRequest{{
+			AvailableDeviceIDs:   available,
+			MustIncludeDeviceIDs: mustInclude,
+			AllocationSize:       int32(size),
+		}},
+	})
+	require.NoError(p.t, err)
+	require.Len(p.t, resp.ContainerResponses, 1, "preferred allocations")
+	return resp.ContainerResponses[0].DeviceIDs
+}
+
+// Allocate allocates deviceIDs to a container of the pod being admitted, as
+// the kubelet does once per container that requests the plugin's resource.
+func (p *Plugin) Allocate(deviceIDs ...string) (*kubeletdevicepluginv1beta1.ContainerAllocateResponse, error) {
+	p.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	resp, err := p.Client.Allocate(ctx, &kubeletdevicepluginv1beta1.AllocateRequest{
+		ContainerRequests: []*kubeletdevicepluginv1beta1.ContainerAllocateRequest{{DevicesIds: deviceIDs}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	require.Len(p.t, resp.ContainerResponses, 1, "container responses")
+	return resp.ContainerResponses[0], nil
+}
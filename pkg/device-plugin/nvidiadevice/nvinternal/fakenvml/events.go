@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package fakenvml
+
+import (
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/go-nvml/pkg/nvml/mock"
+)
+
+// eventSet reports the injected XIDs of the GPUs registered with it.
+type eventSet struct {
+	mock.EventSet
+	s *Server
+
+	mu sync.Mutex
+	// masks are the registered event types by GPU index.
+	masks map[int]uint64
+}
+
+func (s *Server) newEventSet() *eventSet {
+	es := &eventSet{s: s, masks: make(map[int]uint64)}
+	es.WaitFunc = es.wait
+	es.FreeFunc = func() nvml.Return {
+		return nvml.SUCCESS
+	}
+	return es
+}
+
+func (es *eventSet) register(gpu int, mask uint64) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.masks[gpu] |= mask
+}
+
+// wait returns the next due XID of a registered GPU, waiting up to timeout
+// milliseconds for one. Each injected XID is reported once.
+func (es *eventSet) wait(timeout uint32) (nvml.EventData, nvml.Return) {
+	deadline := time.Now().Add(time.Duration(timeout) * time.Millisecond)
+	for {
+		event, next, ok := es.next()
+		if ok {
+			return event, nvml.SUCCESS
+		}
+		now := time.Now()
+		if next.IsZero() || next.After(deadline) {
+			time.Sleep(deadline.Sub(now))
+			return nvml.EventData{}, nvml.ERROR_TIMEOUT
+		}
+		time.Sleep(next.Sub(now))
+	}
+}
+
+// next takes the first due XID, or returns when the next one is due.
+func (es *eventSet) next() (nvml.EventData, time.Time, bool) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.s.mu.Lock()
+	defer es.s.mu.Unlock()
+	now := time.Now()
+	var next time.Time
+	for i, gpu := range es.s.topology.GPUs {
+		if es.masks[i]&nvml.EventTypeXidCriticalError == 0 {
+			continue
+		}
+		for k, xid := range gpu.XIDs {
+			if es.s.delivered[[2]int{i, k}] {
+				continue
+			}
+			due := es.s.loadedAt.Add(time.Duration(xid.AfterSeconds) * time.Second)
+			if !due.After(now) {
+				es.s.delivered[[2]int{i, k}] = true
+				return nvml.EventData{
+					Device:            es.s.gpus[i],
+					EventType:         nvml.EventTypeXidCriticalError,
+					EventData:         xid.XID,
+					GpuInstanceId:     0xFFFFFFFF,
+					ComputeInstanceId: 0xFFFFFFFF,
+				}, time.Time{}, true
+			}
+			if next.IsZero() || due.Before(next) {
+				next = due
+			}
+		}
+	}
+	return nvml.EventData{}, next, false
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package fakenvml
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/device"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+func loadTestTopology(t *testing.T) *Server {
+	t.Helper()
+	topology, err := LoadTopology("testdata/topology.yaml")
+	require.NoError(t, err)
+	return New(topology)
+}
+
+func TestLoadTopologyValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{name: "no GPUs", yaml: "gpus: []", err: "topology has no GPUs"},
+		{name: "unknown field", yaml: "gpus: [{model: A30, memory: 1}]", err: "field memory not found"},
+		{name: "unknown model without memory", yaml: "gpus: [{model: T4}]", err: `gpus[0]: memoryMiB is required for model "T4"`},
+		{name: "MIG on a model without profiles", yaml: "gpus: [{model: T4, memoryMiB: 15360, migMode: true}]", err: `gpus[0]: model "T4" does not support MIG`},
+		{name: "bad compute capability", yaml: "gpus: [{model: A30, computeCapability: eight}]", err: `gpus[0]: invalid compute capability "eight"`},
+		{name: "asymmetric nvlinks", yaml: "gpus: [{model: A30}, {model: A30}]\nnvlinks: [[0, 4], [2, 0]]", err: "nvlinks is not symmetric at [0][1]"},
+		{name: "self nvlink", yaml: "gpus: [{model: A30}]\nnvlinks: [[1]]", err: "nvlinks[0][0] links a GPU to itself"},
+		{name: "too many nvlinks", yaml: "gpus: [{model: A30}, {model: A30}]\nnvlinks: [[0, 37], [37, 0]]", err: "nvlinks[0] has 37 links, more than the 36 a GPU has"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "topology.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.yaml), 0o600))
+			_, err := LoadTopology(path)
+			require.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func TestDevices(t *testing.T) {
+	s := loadTestTopology(t)
+	require.Equal(t, nvml.SUCCESS, s.Init())
+
+	count, ret := s.DeviceGetCount()
+	require.Equal(t, nvml.SUCCESS, ret)
+	require.Equal(t, 3, count)
+
+	d0, _ := s.DeviceGetHandleByIndex(0)
+	uuid, _ := d0.GetUUID()
+	require.Equal(t, "GPU-00000000-0000-0000-0000-000000000000", uuid)
+	byUUID, ret := s.DeviceGetHandleByUUID(uuid)
+	require.Equal(t, nvml.SUCCESS, ret)
+	require.Same(t, d0, byUUID)
+	name, _ := d0.GetName()
+	require.Equal(t, "NVIDIA A100-SXM4-40GB", name)
+	memory, _ := d0.GetMemoryInfo()
+	require.Equal(t, uint64(40960)<<20, memory.Total)
+	require.Equal(t, memory.Total, memory.Free)
+	mode, _, ret := d0.GetMigMode()
+	require.Equal(t, nvml.SUCCESS, ret)
+	require.Equal(t, nvml.DEVICE_MIG_DISABLE, mode)
+
+	d2, _ := s.DeviceGetHandleByIndex(2)
+	uuid2, _ := d2.GetUUID()
+	require.Equal(t, uuid2, New(s.topology).gpus[2].UUID, "generated UUIDs are stable")
+	memory, _ = d2.GetMemoryInfo()
+	require.Equal(t, uint64(15360)<<20, memory.Total)
+	major, minor, _ := d2.GetCudaComputeCapability()
+	require.Equal(t, []int{7, 5}, []int{major, minor})
+	_, _, ret = d2.GetMigMode()
+	require.Equal(t, nvml.ERROR_NOT_SUPPORTED, ret)
+	pci, _ := d2.GetPciInfo()
+	require.Equal(t, "0000:03:00.0", nvidia.PciInfo(pci).BusID())
+}
+
+func TestLinks(t *testing.T) {
+	s := loadTestTopology(t)
+	devices, err := device.New(s).GetDevices()
+	require.NoError(t, err)
+
+	link, err := nvidia.GetNVLink(devices[0], devices[1])
+	require.NoError(t, err)
+	require.Equal(t, nvidia.TwelveNVLINKLinks, link)
+	link, err = nvidia.GetNVLink(devices[1], devices[0])
+	require.NoError(t, err)
+	require.Equal(t, nvidia.TwelveNVLINKLinks, link)
+	link, err = nvidia.GetNVLink(devices[0], devices[2])
+	require.NoError(t, err)
+	require.Equal(t, nvidia.P2PLinkUnknown, link)
+
+	link, err = nvidia.GetP2PLink(devices[0], devices[1])
+	require.NoError(t, err)
+	require.Equal(t, nvidia.P2PLinkSameCPU, link)
+	link, err = nvidia.GetP2PLink(devices[0], devices[2])
+	require.NoError(t, err)
+	require.Equal(t, nvidia.P2PLinkCrossCPU, link)
+}
+
+func TestMIG(t *testing.T) {
+	s := loadTestTopology(t)
+	dev, _ := s.DeviceGetHandleByIndex(1)
+
+	giInfo, ret := dev.GetGpuInstanceProfileInfo(nvml.GPU_INSTANCE_PROFILE_3_SLICE)
+	require.Equal(t, nvml.SUCCESS, ret)
+	_, ret = dev.CreateGpuInstanceWithPlacement(&giInfo, &nvml.GpuInstancePlacement{Start: 1, Size: 4})
+	require.Equal(t, nvml.ERROR_INVALID_ARGUMENT, ret, "placements must be one of the profile's")
+	gi, ret := dev.CreateGpuInstanceWithPlacement(&giInfo, &nvml.GpuInstancePlacement{Start: 4, Size: 4})
+	require.Equal(t, nvml.SUCCESS, ret)
+	oneSlice, _ := dev.GetGpuInstanceProfileInfo(nvml.GPU_INSTANCE_PROFILE_1_SLICE)
+	_, ret = dev.CreateGpuInstanceWithPlacement(&oneSlice, &nvml.GpuInstancePlacement{Start: 5, Size: 1})
+	require.Equal(t, nvml.ERROR_INSUFFICIENT_RESOURCES, ret, "GPU instances must not overlap")
+
+	ciInfo, ret := gi.GetComputeInstanceProfileInfo(nvml.COMPUTE_INSTANCE_PROFILE_1_SLICE, nvml.COMPUTE_INSTANCE_ENGINE_PROFILE_SHARED)
+	require.Equal(t, nvml.SUCCESS, ret)
+	placements, ret := gi.GetComputeInstancePossiblePlacements(&ciInfo)
+	require.Equal(t, nvml.SUCCESS, ret)
+	require.Len(t, placements, 3)
+	ci, ret := gi.CreateComputeInstanceWithPlacement(&ciInfo, &nvml.ComputeInstancePlacement{Start: 1, Size: 1})
+	require.Equal(t, nvml.SUCCESS, ret)
+	_, ret = gi.CreateComputeInstanceWithPlacement(&ciInfo, &nvml.ComputeInstancePlacement{Start: 1, Size: 1})
+	require.Equal(t, nvml.ERROR_INSUFFICIENT_RESOURCES, ret, "compute instances must not overlap")
+
+	mig, ret := dev.GetMigDeviceHandleByIndex(0)
+	require.Equal(t, nvml.SUCCESS, ret)
+	migUUID, _ := mig.GetUUID()
+	byUUID, ret := s.DeviceGetHandleByUUID(migUUID)
+	require.Equal(t, nvml.SUCCESS, ret)
+	ciID, _ := byUUID.GetComputeInstanceId()
+	ciData, _ := ci.GetInfo()
+	require.Equal(t, int(ciData.Id), ciID)
+
+	migs, err := device.New(s).GetMigDevices()
+	require.NoError(t, err)
+	require.Len(t, migs, 1)
+	profile, err := migs[0].GetProfile()
+	require.NoError(t, err)
+	require.Equal(t, "1c.3g.20gb", profile.String())
+
+	require.Equal(t, nvml.ERROR_IN_USE, gi.Destroy(), "GPU instances with compute instances cannot be destroyed")
+	require.Equal(t, nvml.SUCCESS, ci.Destroy())
+	require.Equal(t, nvml.SUCCESS, gi.Destroy())
+	_, ret = dev.GetMigDeviceHandleByIndex(0)
+	require.Equal(t, nvml.ERROR_NOT_FOUND, ret)
+}
+
+func TestXIDEvents(t *testing.T) {
+	s := loadTestTopology(t)
+	set, ret := s.EventSetCreate()
+	require.Equal(t, nvml.SUCCESS, ret)
+	for i := range 3 {
+		d, _ := s.DeviceGetHandleByIndex(i)
+		require.Equal(t, nvml.SUCCESS, d.RegisterEvents(nvml.EventTypeXidCriticalError, set))
+	}
+
+	e, ret := set.Wait(10)
+	require.Equal(t, nvml.SUCCESS, ret)
+	require.Equal(t, uint64(79), e.EventData)
+	require.Equal(t, uint64(nvml.EventTypeXidCriticalError), e.EventType)
+	require.Same(t, s.gpus[2], e.Device)
+
+	_, ret = set.Wait(10)
+	require.Equal(t, nvml.ERROR_TIMEOUT, ret, "each XID is reported once")
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package fakenvml
+
+import (
+	"github.com/NVIDIA/go-nvlib/pkg/nvlib/info"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+// Install points the package-level NVML functions, which parts of the device
+// plugin call instead of the nvml.Interface they are given, and the library
+// the GPU topology scores are read from at s. It is not reversible and is
+// meant to be called once at startup.
+func (s *Server) Install() {
+	nvml.Init = s.Init
+	nvml.Shutdown = s.Shutdown
+	nvml.SystemGetDriverVersion = s.SystemGetDriverVersion
+	nvml.SystemGetCudaDriverVersion = s.SystemGetCudaDriverVersion
+	nvml.DeviceGetCount = s.DeviceGetCount
+	nvml.DeviceGetHandleByIndex = s.DeviceGetHandleByIndex
+	nvml.DeviceGetHandleByUUID = s.DeviceGetHandleByUUID
+	nvml.DeviceGetMigDeviceHandleByIndex = func(d nvml.Device, index int) (nvml.Device, nvml.Return) {
+		return d.GetMigDeviceHandleByIndex(index)
+	}
+	nvml.EventSetCreate = s.EventSetCreate
+	nvidia.NewNVML = func() nvml.Interface { return s }
+}
+
+// PropertyExtractor reports a system with NVML and nothing else, so that
+// go-nvlib resolves the NVML platform without the driver libraries.
+func PropertyExtractor() info.PropertyExtractor {
+	return propertyExtractor{}
+}
+
+type propertyExtractor struct{}
+
+func (propertyExtractor) HasDXCore() (bool, string) {
+	return false, "simulated NVML"
+}
+
+func (propertyExtractor) HasNvml() (bool, string) {
+	return true, "simulated NVML"
+}
+
+func (propertyExtractor) HasTegraFiles() (bool, string) {
+	return false, "simulated NVML"
+}
+
+func (propertyExtractor) HasAnIntegratedGPU() (bool, string) {
+	return false, "simulated NVML"
+}
@@ -0,0 +1,268 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package fakenvml
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/go-nvml/pkg/nvml/mock"
+	"github.com/NVIDIA/go-nvml/pkg/nvml/mock/server"
+	"github.com/google/uuid"
+)
+
+// setMigFuncs makes GPU and compute instances of d follow the placement rules
+// of a real GPU: they must fit one of the profile's placements and must not
+// overlap the instances already created.
+func setMigFuncs(d *server.Device) {
+	d.GetMaxMigDeviceCountFunc = func() (int, nvml.Return) {
+		info, ok := d.Config.MIGProfiles.GpuInstanceProfiles[nvml.GPU_INSTANCE_PROFILE_1_SLICE]
+		if !ok {
+			return 0, nvml.ERROR_NOT_SUPPORTED
+		}
+		return int(info.InstanceCount), nvml.SUCCESS
+	}
+	d.GetMigDeviceHandleByIndexFunc = func(index int) (nvml.Device, nvml.Return) {
+		migs := migDevices(d)
+		if index < 0 || index >= len(migs) {
+			return nil, nvml.ERROR_NOT_FOUND
+		}
+		return migs[index], nvml.SUCCESS
+	}
+	d.GetGpuInstanceByIdFunc = func(id int) (nvml.GpuInstance, nvml.Return) {
+		for _, gi := range gpuInstances(d) {
+			if int(gi.Info.Id) == id {
+				return gi, nvml.SUCCESS
+			}
+		}
+		return nil, nvml.ERROR_NOT_FOUND
+	}
+	d.CreateGpuInstanceWithPlacementFunc = func(info *nvml.GpuInstanceProfileInfo, placement *nvml.GpuInstancePlacement) (nvml.GpuInstance, nvml.Return) {
+		return createGpuInstance(d, info, placement)
+	}
+	d.CreateGpuInstanceFunc = func(info *nvml.GpuInstanceProfileInfo) (nvml.GpuInstance, nvml.Return) {
+		for _, placement := range d.Config.MIGProfiles.GpuInstancePlacements[int(info.Id)] {
+			if gi, ret := createGpuInstance(d, info, &placement); ret == nvml.SUCCESS {
+				return gi, ret
+			}
+		}
+		return nil, nvml.ERROR_INSUFFICIENT_RESOURCES
+	}
+}
+
+func createGpuInstance(d *server.Device, info *nvml.GpuInstanceProfileInfo, placement *nvml.GpuInstancePlacement) (nvml.GpuInstance, nvml.Return) {
+	d.Lock()
+	defer d.Unlock()
+	if d.MigMode != nvml.DEVICE_MIG_ENABLE {
+		return nil, nvml.ERROR_NOT_SUPPORTED
+	}
+	if !slices.Contains(d.Config.MIGProfiles.GpuInstancePlacements[int(info.Id)], *placement) {
+		return nil, nvml.ERROR_INVALID_ARGUMENT
+	}
+	for gi := range d.GpuInstances {
+		if overlaps(gi.Info.Placement.Start, gi.Info.Placement.Size, placement.Start, placement.Size) {
+			return nil, nvml.ERROR_INSUFFICIENT_RESOURCES
+		}
+	}
+	gi := server.NewGpuInstanceFromInfo(nvml.GpuInstanceInfo{
+		Device:    d,
+		Id:        d.GpuInstanceCounter,
+		ProfileId: info.Id,
+		Placement: *placement,
+	}, d.Config.MIGProfiles)
+	d.GpuInstanceCounter++
+	setGpuInstanceFuncs(gi, info.SliceCount)
+	d.GpuInstances[gi] = struct{}{}
+	return gi, nvml.SUCCESS
+}
+
+func setGpuInstanceFuncs(gi *server.GpuInstance, giSlices uint32) {
+	gi.GetComputeInstancePossiblePlacementsFunc = func(info *nvml.ComputeInstanceProfileInfo) ([]nvml.ComputeInstancePlacement, nvml.Return) {
+		if _, ok := gi.MIGProfiles.ComputeInstanceProfiles[int(gi.Info.ProfileId)][int(info.Id)]; !ok {
+			return nil, nvml.ERROR_NOT_SUPPORTED
+		}
+		return computePlacements(giSlices, info.SliceCount), nvml.SUCCESS
+	}
+	gi.CreateComputeInstanceWithPlacementFunc = func(info *nvml.ComputeInstanceProfileInfo, placement *nvml.ComputeInstancePlacement) (nvml.ComputeInstance, nvml.Return) {
+		return createComputeInstance(gi, giSlices, info, placement)
+	}
+	gi.CreateComputeInstanceFunc = func(info *nvml.ComputeInstanceProfileInfo) (nvml.ComputeInstance, nvml.Return) {
+		for _, placement := range computePlacements(giSlices, info.SliceCount) {
+			if ci, ret := createComputeInstance(gi, giSlices, info, &placement); ret == nvml.SUCCESS {
+				return ci, ret
+			}
+		}
+		return nil, nvml.ERROR_INSUFFICIENT_RESOURCES
+	}
+	gi.GetComputeInstanceByIdFunc = func(id int) (nvml.ComputeInstance, nvml.Return) {
+		for _, ci := range computeInstances(gi) {
+			if int(ci.Info.Id) == id {
+				return ci, nvml.SUCCESS
+			}
+		}
+		return nil, nvml.ERROR_NOT_FOUND
+	}
+	gi.DestroyFunc = func() nvml.Return {
+		d := gi.Info.Device.(*server.Device)
+		d.Lock()
+		defer d.Unlock()
+		gi.RLock()
+		defer gi.RUnlock()
+		if len(gi.ComputeInstances) > 0 {
+			return nvml.ERROR_IN_USE
+		}
+		delete(d.GpuInstances, gi)
+		return nvml.SUCCESS
+	}
+}
+
+func createComputeInstance(gi *server.GpuInstance, giSlices uint32, info *nvml.ComputeInstanceProfileInfo, placement *nvml.ComputeInstancePlacement) (nvml.ComputeInstance, nvml.Return) {
+	gi.Lock()
+	defer gi.Unlock()
+	if _, ok := gi.MIGProfiles.ComputeInstanceProfiles[int(gi.Info.ProfileId)][int(info.Id)]; !ok {
+		return nil, nvml.ERROR_NOT_SUPPORTED
+	}
+	if !slices.Contains(computePlacements(giSlices, info.SliceCount), *placement) {
+		return nil, nvml.ERROR_INVALID_ARGUMENT
+	}
+	for ci := range gi.ComputeInstances {
+		if overlaps(ci.Info.Placement.Start, ci.Info.Placement.Size, placement.Start, placement.Size) {
+			return nil, nvml.ERROR_INSUFFICIENT_RESOURCES
+		}
+	}
+	ci := server.NewComputeInstanceFromInfo(nvml.ComputeInstanceInfo{
+		Device:      gi.Info.Device,
+		GpuInstance: gi,
+		Id:          gi.ComputeInstanceCounter,
+		ProfileId:   info.Id,
+		Placement:   *placement,
+	})
+	gi.ComputeInstanceCounter++
+	gi.ComputeInstances[ci] = struct{}{}
+	return ci, nvml.SUCCESS
+}
+
+// computePlacements returns the placements of a compute instance of
+// ciSlices in a GPU instance of giSlices, aligned to the instance's size.
+func computePlacements(giSlices, ciSlices uint32) []nvml.ComputeInstancePlacement {
+	var placements []nvml.ComputeInstancePlacement
+	for start := uint32(0); ciSlices > 0 && start+ciSlices <= giSlices; start += ciSlices {
+		placements = append(placements, nvml.ComputeInstancePlacement{Start: start, Size: ciSlices})
+	}
+	return placements
+}
+
+func overlaps(start1, size1, start2, size2 uint32) bool {
+	return start1 < start2+size2 && start2 < start1+size1
+}
+
+// gpuInstances returns the GPU instances of d ordered by ID.
+func gpuInstances(d *server.Device) []*server.GpuInstance {
+	d.RLock()
+	defer d.RUnlock()
+	out := make([]*server.GpuInstance, 0, len(d.GpuInstances))
+	for gi := range d.GpuInstances {
+		out = append(out, gi)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Info.Id < out[j].Info.Id })
+	return out
+}
+
+// computeInstances returns the compute instances of gi ordered by ID.
+func computeInstances(gi *server.GpuInstance) []*server.ComputeInstance {
+	gi.RLock()
+	defer gi.RUnlock()
+	out := make([]*server.ComputeInstance, 0, len(gi.ComputeInstances))
+	for ci := range gi.ComputeInstances {
+		out = append(out, ci)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Info.Id < out[j].Info.Id })
+	return out
+}
+
+// migDevice is the device handle of a compute instance, as NVML returns for
+// MIG devices.
+type migDevice struct {
+	mock.Device
+	uuid string
+}
+
+// migDevices returns the MIG device handles of d, one per compute instance,
+// ordered by GPU and compute instance ID.
+func migDevices(d *server.Device) []*migDevice {
+	var migs []*migDevice
+	for _, gi := range gpuInstances(d) {
+		for _, ci := range computeInstances(gi) {
+			migs = append(migs, newMigDevice(d, gi, ci))
+		}
+	}
+	return migs
+}
+
+func newMigDevice(parent *server.Device, gi *server.GpuInstance, ci *server.ComputeInstance) *migDevice {
+	giProfile := gi.MIGProfiles.GpuInstanceProfiles[int(gi.Info.ProfileId)]
+	ciProfile := gi.MIGProfiles.ComputeInstanceProfiles[int(gi.Info.ProfileId)][int(ci.Info.ProfileId)]
+	m := &migDevice{
+		// The UUID is derived from the placement so that it is stable
+		// across lookups of the same compute instance.
+		uuid: "MIG-" + uuid.NewSHA1(uuid.NameSpaceOID, fmt.Appendf(nil, "%s/%d/%d", parent.UUID, gi.Info.Id, ci.Info.Id)).String(),
+	}
+	m.GetUUIDFunc = func() (string, nvml.Return) {
+		return m.uuid, nvml.SUCCESS
+	}
+	m.IsMigDeviceHandleFunc = func() (bool, nvml.Return) {
+		return true, nvml.SUCCESS
+	}
+	m.GetDeviceHandleFromMigDeviceHandleFunc = func() (nvml.Device, nvml.Return) {
+		return parent, nvml.SUCCESS
+	}
+	m.GetGpuInstanceIdFunc = func() (int, nvml.Return) {
+		return int(gi.Info.Id), nvml.SUCCESS
+	}
+	m.GetComputeInstanceIdFunc = func() (int, nvml.Return) {
+		return int(ci.Info.Id), nvml.SUCCESS
+	}
+	m.GetNameFunc = func() (string, nvml.Return) {
+		return parent.Config.Name, nvml.SUCCESS
+	}
+	m.GetCudaComputeCapabilityFunc = func() (int, int, nvml.Return) {
+		return parent.Config.CudaMajor, parent.Config.CudaMinor, nvml.SUCCESS
+	}
+	m.GetMemoryInfoFunc = func() (nvml.Memory, nvml.Return) {
+		total := giProfile.MemorySizeMB * 1024 * 1024
+		return nvml.Memory{Total: total, Free: total}, nvml.SUCCESS
+	}
+	m.GetAttributesFunc = func() (nvml.DeviceAttributes, nvml.Return) {
+		return nvml.DeviceAttributes{
+			MultiprocessorCount:       ciProfile.MultiprocessorCount,
+			SharedCopyEngineCount:     ciProfile.SharedCopyEngineCount,
+			SharedDecoderCount:        ciProfile.SharedDecoderCount,
+			SharedEncoderCount:        ciProfile.SharedEncoderCount,
+			SharedJpegCount:           ciProfile.SharedJpegCount,
+			SharedOfaCount:            ciProfile.SharedOfaCount,
+			GpuInstanceSliceCount:     giProfile.SliceCount,
+			ComputeInstanceSliceCount: ciProfile.SliceCount,
+			MemorySizeMB:              giProfile.MemorySizeMB,
+		}, nvml.SUCCESS
+	}
+	m.GetIndexFunc = func() (int, nvml.Return) {
+		return 0, nvml.ERROR_NOT_SUPPORTED
+	}
+	m.GetMinorNumberFunc = func() (int, nvml.Return) {
+		return 0, nvml.ERROR_NOT_SUPPORTED
+	}
+	m.GetSupportedEventTypesFunc = func() (uint64, nvml.Return) {
+		return 0, nvml.ERROR_NOT_SUPPORTED
+	}
+	return m
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package fakenvml
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/go-nvml/pkg/nvml/mock/server"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDriverVersion     = "550.54.15"
+	defaultCUDADriverVersion = 12040
+
+	// supportedEvents are the events health checks can register for.
+	supportedEvents = nvml.EventTypeXidCriticalError | nvml.EventTypeDoubleBitEccError | nvml.EventTypeSingleBitEccError
+)
+
+// Server is a simulated NVML. It implements nvml.Interface on top of the
+// go-nvml mock server, adding the PCIe and NVLink topology, MIG placement
+// rules, MIG device handles and XID events the device plugin relies on.
+type Server struct {
+	*server.Server
+
+	topology *Topology
+	gpus     []*server.Device
+	loadedAt time.Time
+
+	mu sync.Mutex
+	// delivered records the injected XIDs already reported, keyed by GPU
+	// index and position in its XIDs list.
+	delivered map[[2]int]bool
+}
+
+var _ nvml.Interface = (*Server)(nil)
+
+// New returns a simulated NVML for topology.
+func New(topology *Topology) *Server {
+	driverVersion := topology.DriverVersion
+	if driverVersion == "" {
+		driverVersion = defaultDriverVersion
+	}
+	cudaDriverVersion := topology.CUDADriverVersion
+	if cudaDriverVersion == 0 {
+		cudaDriverVersion = defaultCUDADriverVersion
+	}
+	s := &Server{
+		topology:  topology,
+		loadedAt:  time.Now(),
+		delivered: make(map[[2]int]bool),
+	}
+	devices := make([]nvml.Device, len(topology.GPUs))
+	for i, gpu := range topology.GPUs {
+		d := server.NewDeviceFromConfig(gpu.config(), i)
+		d.UUID = gpu.UUID
+		if d.UUID == "" {
+			d.UUID = "GPU-" + uuid.NewSHA1(uuid.NameSpaceOID, fmt.Appendf(nil, "fakenvml/gpu/%d", i)).String()
+		}
+		d.PciBusID = fmt.Sprintf("00000000:%02x:00.0", i+1)
+		d.MemoryInfo.Free = d.MemoryInfo.Total
+		if gpu.MigMode {
+			d.MigMode = nvml.DEVICE_MIG_ENABLE
+		}
+		s.gpus = append(s.gpus, d)
+		devices[i] = d
+	}
+	s.Server = server.NewServerWithGPUs(driverVersion, "12."+driverVersion, cudaDriverVersion)
+	s.Devices = devices
+	s.setServerFuncs()
+	for i, d := range s.gpus {
+		s.setDeviceFuncs(i, d)
+	}
+	return s
+}
+
+func (s *Server) setServerFuncs() {
+	s.DeviceGetHandleByUUIDFunc = func(id string) (nvml.Device, nvml.Return) {
+		for _, d := range s.gpus {
+			if d.UUID == id {
+				return d, nvml.SUCCESS
+			}
+			for _, mig := range migDevices(d) {
+				if mig.uuid == id {
+					return mig, nvml.SUCCESS
+				}
+			}
+		}
+		return nil, nvml.ERROR_NOT_FOUND
+	}
+	s.EventSetCreateFunc = func() (nvml.EventSet, nvml.Return) {
+		return s.newEventSet(), nvml.SUCCESS
+	}
+}
+
+func (s *Server) setDeviceFuncs(i int, d *server.Device) {
+	migCapable := len(d.Config.MIGProfiles.GpuInstanceProfiles) > 0
+
+	d.GetPciInfoFunc = func() (nvml.PciInfo, nvml.Return) {
+		return s.pciInfo(i), nvml.SUCCESS
+	}
+	d.GetNumaNodeIdFunc = func() (int, nvml.Return) {
+		return s.topology.GPUs[i].NUMANode, nvml.SUCCESS
+	}
+	d.GetTopologyCommonAncestorFunc = func(other nvml.Device) (nvml.GpuTopologyLevel, nvml.Return) {
+		j, ret := other.GetIndex()
+		if ret != nvml.SUCCESS || j < 0 || j >= len(s.gpus) {
+			return 0, nvml.ERROR_INVALID_ARGUMENT
+		}
+		if s.topology.GPUs[i].NUMANode == s.topology.GPUs[j].NUMANode {
+			return nvml.TOPOLOGY_NODE, nvml.SUCCESS
+		}
+		return nvml.TOPOLOGY_SYSTEM, nvml.SUCCESS
+	}
+
+	d.GetNvLinkStateFunc = func(link int) (nvml.EnableState, nvml.Return) {
+		if link < 0 || link >= nvml.NVLINK_MAX_LINKS {
+			return 0, nvml.ERROR_INVALID_ARGUMENT
+		}
+		if link < len(s.nvlinkPeers(i)) {
+			return nvml.FEATURE_ENABLED, nvml.SUCCESS
+		}
+		return nvml.FEATURE_DISABLED, nvml.SUCCESS
+	}
+	d.GetNvLinkRemotePciInfoFunc = func(link int) (nvml.PciInfo, nvml.Return) {
+		peers := s.nvlinkPeers(i)
+		if link < 0 || link >= len(peers) {
+			return nvml.PciInfo{}, nvml.ERROR_INVALID_ARGUMENT
+		}
+		return s.pciInfo(peers[link]), nvml.SUCCESS
+	}
+	d.GetNvLinkRemoteDeviceTypeFunc = func(link int) (nvml.IntNvLinkDeviceType, nvml.Return) {
+		if link < 0 || link >= len(s.nvlinkPeers(i)) {
+			return 0, nvml.ERROR_INVALID_ARGUMENT
+		}
+		return nvml.NVLINK_DEVICE_TYPE_GPU, nvml.SUCCESS
+	}
+
+	d.GetSupportedEventTypesFunc = func() (uint64, nvml.Return) {
+		return supportedEvents, nvml.SUCCESS
+	}
+	d.RegisterEventsFunc = func(mask uint64, set nvml.EventSet) nvml.Return {
+		es, ok := set.(*eventSet)
+		if !ok {
+			return nvml.ERROR_INVALID_ARGUMENT
+		}
+		es.register(i, mask)
+		return nvml.SUCCESS
+	}
+	d.GetRemappedRowsFunc = func() (int, int, bool, bool, nvml.Return) {
+		return 0, 0, false, false, nvml.SUCCESS
+	}
+	d.GetRetiredPagesPendingStatusFunc = func() (nvml.EnableState, nvml.Return) {
+		return nvml.FEATURE_DISABLED, nvml.SUCCESS
+	}
+	d.GetComputeRunningProcessesFunc = func() ([]nvml.ProcessInfo, nvml.Return) {
+		return nil, nvml.SUCCESS
+	}
+	d.GetGraphicsRunningProcessesFunc = func() ([]nvml.ProcessInfo, nvml.Return) {
+		return nil, nvml.SUCCESS
+	}
+	d.GetGpuFabricInfoFunc = func() (nvml.GpuFabricInfo, nvml.Return) {
+		return nvml.GpuFabricInfo{}, nvml.ERROR_NOT_SUPPORTED
+	}
+	d.IsMigDeviceHandleFunc = func() (bool, nvml.Return) {
+		return false, nvml.SUCCESS
+	}
+	d.GetDeviceHandleFromMigDeviceHandleFunc = func() (nvml.Device, nvml.Return) {
+		return nil, nvml.ERROR_INVALID_ARGUMENT
+	}
+
+	if !migCapable {
+		d.GetMigModeFunc = func() (int, int, nvml.Return) {
+			return 0, 0, nvml.ERROR_NOT_SUPPORTED
+		}
+		d.SetMigModeFunc = func(int) (nvml.Return, nvml.Return) {
+			return nvml.ERROR_NOT_SUPPORTED, nvml.ERROR_NOT_SUPPORTED
+		}
+		d.GetMaxMigDeviceCountFunc = func() (int, nvml.Return) {
+			return 0, nvml.ERROR_NOT_SUPPORTED
+		}
+		d.GetMigDeviceHandleByIndexFunc = func(int) (nvml.Device, nvml.Return) {
+			return nil, nvml.ERROR_NOT_SUPPORTED
+		}
+		return
+	}
+	setMigFuncs(d)
+}
+
+// pciInfo returns the PCI info of GPU i.
+func (s *Server) pciInfo(i int) nvml.PciInfo {
+	d := s.gpus[i]
+	info := nvml.PciInfo{
+		Domain:      0,
+		Bus:         uint32(i + 1),
+		PciDeviceId: d.Config.PciDeviceId,
+	}
+	for k, c := range []byte(d.PciBusID) {
+		info.BusId[k] = int8(c)
+	}
+	for k, c := range []byte(d.PciBusID[4:]) {
+		info.BusIdLegacy[k] = int8(c)
+	}
+	return info
+}
+
+// nvlinkPeers returns, for each NVLink of GPU i, the index of the GPU at its
+// other end.
+func (s *Server) nvlinkPeers(i int) []int {
+	var peers []int
+	for j := range s.gpus {
+		for range s.topology.nvlinks(i, j) {
+			peers = append(peers, j)
+		}
+	}
+	return peers
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+// Package fakenvml simulates NVML from a YAML topology, so the device plugin
+// can run on machines without GPUs.
+package fakenvml
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/NVIDIA/go-nvml/pkg/nvml/mock/gpus"
+	"gopkg.in/yaml.v2"
+)
+
+// Topology describes the simulated node.
+type Topology struct {
+	DriverVersion     string `yaml:"driverVersion"`
+	CUDADriverVersion int    `yaml:"cudaDriverVersion"`
+	GPUs              []GPU  `yaml:"gpus"`
+	// NVLinks[i][j] is the number of NVLinks between GPU i and GPU j. The
+	// matrix must be symmetric; GPUs it leaves out have no NVLinks.
+	NVLinks [][]int `yaml:"nvlinks"`
+}
+
+// GPU describes one simulated GPU.
+type GPU struct {
+	// Model is one of the models in Models, which come with their MIG
+	// profiles, or any other name for a GPU without MIG support.
+	Model string `yaml:"model"`
+	// UUID defaults to one derived from the GPU's index.
+	UUID string `yaml:"uuid"`
+	// MemoryMiB defaults to the model's memory and is required for other
+	// models.
+	MemoryMiB uint64 `yaml:"memoryMiB"`
+	// ComputeCapability such as "8.6" defaults to the model's, or "8.0".
+	ComputeCapability string `yaml:"computeCapability"`
+	// NUMANode decides the PCIe distance reported to other GPUs.
+	NUMANode int `yaml:"numaNode"`
+	// MigMode starts the GPU with MIG enabled.
+	MigMode bool `yaml:"migMode"`
+	// XIDs are reported to health checks once each is due.
+	XIDs []InjectedXID `yaml:"xids"`
+}
+
+// InjectedXID is an XID a GPU reports AfterSeconds after the topology is
+// loaded.
+type InjectedXID struct {
+	XID          uint64 `yaml:"xid"`
+	AfterSeconds int    `yaml:"afterSeconds"`
+}
+
+// Models are the GPU models simulated with MIG support.
+var Models = map[string]gpus.Config{
+	"A100-PCIE-40GB":  gpus.A100_PCIE_40GB,
+	"A100-PCIE-80GB":  gpus.A100_PCIE_80GB,
+	"A100-SXM4-40GB":  gpus.A100_SXM4_40GB,
+	"A100-SXM4-80GB":  gpus.A100_SXM4_80GB,
+	"A30":             gpus.A30_PCIE_24GB,
+	"H100-SXM5-80GB":  gpus.H100_SXM5_80GB,
+	"H200-SXM5-141GB": gpus.H200_SXM5_141GB,
+	"B200-SXM5-180GB": gpus.B200_SXM5_180GB,
+}
+
+// LoadTopology reads the topology at path.
+func LoadTopology(path string) (*Topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t Topology
+	if err := yaml.UnmarshalStrict(data, &t); err != nil {
+		return nil, fmt.Errorf("parse topology %s: %w", path, err)
+	}
+	return &t, t.validate()
+}
+
+func (t *Topology) validate() error {
+	if len(t.GPUs) == 0 {
+		return fmt.Errorf("topology has no GPUs")
+	}
+	for i, gpu := range t.GPUs {
+		if _, ok := Models[gpu.Model]; !ok && gpu.MemoryMiB == 0 {
+			return fmt.Errorf("gpus[%d]: memoryMiB is required for model %q", i, gpu.Model)
+		}
+		if gpu.MigMode {
+			if _, ok := Models[gpu.Model]; !ok {
+				return fmt.Errorf("gpus[%d]: model %q does not support MIG", i, gpu.Model)
+			}
+		}
+		if gpu.ComputeCapability != "" {
+			if _, _, err := parseComputeCapability(gpu.ComputeCapability); err != nil {
+				return fmt.Errorf("gpus[%d]: %w", i, err)
+			}
+		}
+	}
+	if len(t.NVLinks) > len(t.GPUs) {
+		return fmt.Errorf("nvlinks has %d rows for %d GPUs", len(t.NVLinks), len(t.GPUs))
+	}
+	for i, row := range t.NVLinks {
+		if len(row) > len(t.GPUs) {
+			return fmt.Errorf("nvlinks[%d] has %d columns for %d GPUs", i, len(row), len(t.GPUs))
+		}
+		total := 0
+		for j, links := range row {
+			total += links
+			if links < 0 {
+				return fmt.Errorf("nvlinks[%d][%d] is negative", i, j)
+			}
+			if links != t.nvlinks(j, i) {
+				return fmt.Errorf("nvlinks is not symmetric at [%d][%d]", i, j)
+			}
+			if i == j && links != 0 {
+				return fmt.Errorf("nvlinks[%d][%d] links a GPU to itself", i, j)
+			}
+		}
+		if total > nvml.NVLINK_MAX_LINKS {
+			return fmt.Errorf("nvlinks[%d] has %d links, more than the %d a GPU has", i, total, nvml.NVLINK_MAX_LINKS)
+		}
+	}
+	return nil
+}
+
+// nvlinks returns the number of NVLinks between GPUs i and j.
+func (t *Topology) nvlinks(i, j int) int {
+	if i >= len(t.NVLinks) || j >= len(t.NVLinks[i]) {
+		return 0
+	}
+	return t.NVLinks[i][j]
+}
+
+// config returns the simulated properties of gpu.
+func (gpu GPU) config() gpus.Config {
+	config, ok := Models[gpu.Model]
+	if !ok {
+		config = gpus.Config{Architecture: nvml.DEVICE_ARCH_AMPERE, Brand: nvml.BRAND_NVIDIA, CudaMajor: 8}
+	}
+	config.Name = "NVIDIA " + gpu.Model
+	if gpu.MemoryMiB > 0 {
+		config.MemoryMB = gpu.MemoryMiB
+	}
+	if gpu.ComputeCapability != "" {
+		config.CudaMajor, config.CudaMinor, _ = parseComputeCapability(gpu.ComputeCapability)
+	}
+	return config
+}
+
+func parseComputeCapability(s string) (int, int, error) {
+	major, minor, ok := strings.Cut(s, ".")
+	ma, err1 := strconv.Atoi(major)
+	mi, err2 := strconv.Atoi(minor)
+	if !ok || err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("invalid compute capability %q", s)
+	}
+	return ma, mi, nil
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/conformance"
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/cdi"
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const (
+	gpuA = "GPU-00000000-0000-0000-0000-00000000000a"
+	gpuB = "GPU-00000000-0000-0000-0000-00000000000b"
+)
+
+// startConformancePlugin serves a plugin for gpuA and gpuB, each split in
+// two, and registers it with k.
+func startConformancePlugin(t *testing.T, k *conformance.Kubelet, strategies ...string) *conformance.Plugin {
+	t.Helper()
+	setupInRequestDevices(t)
+	plugin := newTestPluginWithRM(t, map[string]*rm.Device{
+		gpuA: {Device: kubeletdevicepluginv1beta1.Device{ID: gpuA, Health: kubeletdevicepluginv1beta1.Healthy}},
+		gpuB: {Device: kubeletdevicepluginv1beta1.Device{ID: gpuB, Health: kubeletdevicepluginv1beta1.Healthy}},
+	})
+	prevHostHookPath := hostHookPath
+	hostHookPath = t.TempDir()
+	t.Cleanup(func() { hostHookPath = prevHostHookPath })
+	plugin.ctx = context.Background()
+	plugin.socket = k.PluginSocket("nvidia-gpu")
+	plugin.config.Flags.Plugin.DeviceIDStrategy = ptr(v1.DeviceIDStrategyUUID)
+	plugin.deviceListStrategies = mustStrategies(t, strategies...)
+	plugin.cdiHandler = &cdi.InterfaceMock{
+		QualifiedNameFunc:     func(c string, s string) string { return "nvidia.com/" + c + "=" + s },
+		AdditionalDevicesFunc: func() []string { return nil },
+	}
+	plugin.cdiAnnotationPrefix = "cdi.k8s.io/"
+	plugin.schedulerConfig.DeviceSplitCount = ptr(uint(2))
+
+	plugin.initialize()
+	require.NoError(t, plugin.Serve())
+	t.Cleanup(func() { plugin.Stop() })
+	require.NoError(t, plugin.Register(k.Socket))
+	return k.WaitForPlugin("nvidia.com/gpu")
+}
+
+func conformancePod(name string, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: c})
+	}
+	return pod
+}
+
+func TestConformanceHamiCore(t *testing.T) {
+	k := conformance.NewKubelet(t, "node1")
+	p := startConformancePlugin(t, k, "envvar")
+	require.Equal(t, kubeletdevicepluginv1beta1.Version, p.Registration.Version)
+
+	devices := p.ListAndWatch().Next()
+	available := conformance.DeviceIDs(devices)
+	require.ElementsMatch(t, []string{gpuA + "-0", gpuA + "-1", gpuB + "-0", gpuB + "-1"}, available)
+
+	pod := k.BindPod(conformancePod("pod1", "c0", "c1"), nvidia.NvidiaGPUDevice, device.PodSingleDevice{
+		{cd(gpuA, nvidia.NvidiaGPUDevice, 3000, 50)},
+		{cd(gpuB, nvidia.NvidiaGPUDevice, 4000, 60)},
+	})
+
+	preferred := p.PreferredAllocation(available, nil, 1)
+	require.Equal(t, []string{gpuA + "-0"}, preferred)
+	resp, err := p.Allocate(preferred...)
+	require.NoError(t, err)
+	require.Equal(t, gpuA, resp.Envs["NVIDIA_VISIBLE_DEVICES"])
+	conformance.RequireMemoryLimits(t, resp, 3000)
+	conformance.RequireCoreLimit(t, resp, 50)
+	conformance.RequireMount(t, resp, hostHookPath+"/vgpu/libvgpu.so", hostHookPath+"/vgpu/libvgpu.so", true)
+	conformance.RequireMount(t, resp, "/etc/ld.so.preload", hostHookPath+"/vgpu/ld.so.preload", true)
+	conformance.RequireMount(t, resp, "/tmp/vgpulock", "/tmp/vgpulock", false)
+	k.RequireBindPhase(pod, util.DeviceBindAllocating)
+
+	preferred = p.PreferredAllocation(available, nil, 1)
+	require.Equal(t, []string{gpuB + "-0"}, preferred)
+	resp, err = p.Allocate(preferred...)
+	require.NoError(t, err)
+	require.Equal(t, gpuB, resp.Envs["NVIDIA_VISIBLE_DEVICES"])
+	conformance.RequireMemoryLimits(t, resp, 4000)
+	conformance.RequireCoreLimit(t, resp, 60)
+	k.RequireBindPhase(pod, util.DeviceBindSuccess)
+}
+
+func TestConformanceCDIAnnotations(t *testing.T) {
+	k := conformance.NewKubelet(t, "node1")
+	p := startConformancePlugin(t, k, "cdi-annotations")
+
+	pod := k.BindPod(conformancePod("pod1", "c0"), nvidia.NvidiaGPUDevice, device.PodSingleDevice{
+		{cd(gpuA, nvidia.NvidiaGPUDevice, 1000, 20), cd(gpuB, nvidia.NvidiaGPUDevice, 2000, 20)},
+	})
+	resp, err := p.Allocate(gpuA+"-0", gpuB+"-1")
+	require.NoError(t, err)
+	conformance.RequireCDIDevices(t, resp, "cdi.k8s.io/", "nvidia.com/gpu="+gpuA, "nvidia.com/gpu="+gpuB)
+	require.NotContains(t, resp.Envs, "NVIDIA_VISIBLE_DEVICES")
+	conformance.RequireMemoryLimits(t, resp, 1000, 2000)
+	conformance.RequireCoreLimit(t, resp, 20)
+	k.RequireBindPhase(pod, util.DeviceBindSuccess)
+}
+
+func TestConformanceExclusive(t *testing.T) {
+	k := conformance.NewKubelet(t, "node1")
+	p := startConformancePlugin(t, k, "envvar")
+
+	pod := conformancePod("pod1", "c0")
+	pod.Annotations = map[string]string{nvidia.DeviceModesAnnotation: `{"` + gpuA + `":"exclusive"}`}
+	pod = k.BindPod(pod, nvidia.NvidiaGPUDevice, device.PodSingleDevice{
+		{cd(gpuA, nvidia.NvidiaGPUDevice, 40960, 100)},
+	})
+	resp, err := p.Allocate(gpuA + "-0")
+	require.NoError(t, err)
+	require.Equal(t, gpuA, resp.Envs["NVIDIA_VISIBLE_DEVICES"])
+	conformance.RequireNoLimits(t, resp)
+	conformance.RequireNoMount(t, resp, "/etc/ld.so.preload")
+	k.RequireBindPhase(pod, util.DeviceBindSuccess)
+}
+
+func TestConformanceAllocateMismatch(t *testing.T) {
+	k := conformance.NewKubelet(t, "node1")
+	p := startConformancePlugin(t, k, "envvar")
+
+	pod := k.BindPod(conformancePod("pod1", "c0"), nvidia.NvidiaGPUDevice, device.PodSingleDevice{
+		{cd(gpuA, nvidia.NvidiaGPUDevice, 1000, 20)},
+	})
+	_, err := p.Allocate(gpuA+"-0", gpuB+"-0")
+	require.ErrorContains(t, err, "device number not matched")
+	k.RequireBindPhase(pod, util.DeviceBindFailed)
+}
@@ -0,0 +1,294 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const (
+	// Node event reasons recorded on device health transitions.
+	deviceUnhealthyReason = "GPUUnhealthy"
+	deviceRecoveredReason = "GPURecovered"
+
+	// Recovery causes, reported in the GPURecovered event.
+	recoveredAfterReset = "a GPU reset"
+	recoveredAfterQuiet = "its XID condition cleared"
+)
+
+// unhealthyDevice is the health state of a device health checks failed on.
+type unhealthyDevice struct {
+	lastEvent time.Time
+	// needsReset is set once the device was seen needing a GPU reset.
+	needsReset bool
+}
+
+// deviceHealthTracker remembers the devices health checks marked unhealthy
+// and decides, from the recovery rules, when each may return to service. It
+// outlives plugin restarts so recovery counts are kept.
+type deviceHealthTracker struct {
+	mu         sync.Mutex
+	unhealthy  map[string]*unhealthyDevice
+	recoveries map[string]int32
+	now        func() time.Time
+}
+
+func newDeviceHealthTracker() *deviceHealthTracker {
+	return &deviceHealthTracker{
+		unhealthy:  make(map[string]*unhealthyDevice),
+		recoveries: make(map[string]int32),
+		now:        time.Now,
+	}
+}
+
+// markUnhealthy records a failed health check on uuid and reports whether the
+// device was healthy until now.
+func (t *deviceHealthTracker) markUnhealthy(uuid string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state, ok := t.unhealthy[uuid]; ok {
+		state.lastEvent = t.now()
+		return false
+	}
+	t.unhealthy[uuid] = &unhealthyDevice{lastEvent: t.now()}
+	return true
+}
+
+// unhealthyDevices returns the UUIDs currently marked unhealthy, sorted.
+func (t *deviceHealthTracker) unhealthyDevices() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]string, 0, len(t.unhealthy))
+	for uuid := range t.unhealthy {
+		out = append(out, uuid)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// recover returns the devices rules let return to service, each with the
+// cause of its recovery, and forgets them. probe reports why a device still
+// needs a GPU reset, or nil when it does not.
+func (t *deviceHealthTracker) recover(rules nvidia.DeviceHealthRecovery, probe func(uuid string) error) map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	recovered := make(map[string]string)
+	for uuid, state := range t.unhealthy {
+		if rules.MaxRecoveries > 0 && t.recoveries[uuid] >= rules.MaxRecoveries {
+			continue
+		}
+		if err := probe(uuid); err != nil {
+			klog.V(4).InfoS("Unhealthy device still needs a GPU reset", "uuid", uuid, "reason", err)
+			state.needsReset = true
+			continue
+		}
+		switch {
+		case state.needsReset && rules.RecoverOnReset:
+			recovered[uuid] = recoveredAfterReset
+		case rules.RecoverAfterSeconds > 0 && t.now().Sub(state.lastEvent) >= time.Duration(rules.RecoverAfterSeconds)*time.Second:
+			recovered[uuid] = recoveredAfterQuiet
+		}
+	}
+	for uuid := range recovered {
+		delete(t.unhealthy, uuid)
+		t.recoveries[uuid]++
+	}
+	return recovered
+}
+
+// probeDeviceHealth reports why the GPU uuid still needs a reset: NVML cannot
+// reach it, or it has rows or pages waiting for one. NVML must be initialized.
+var probeDeviceHealth = func(uuid string) error {
+	dev, ret := nvml.DeviceGetHandleByUUID(uuid)
+	if ret != nvml.SUCCESS {
+		return fmt.Errorf("get device handle: %s", nvml.ErrorString(ret))
+	}
+	if _, _, pending, failed, ret := dev.GetRemappedRows(); ret == nvml.SUCCESS && (pending || failed) {
+		return errors.New("row remapping pending or failed")
+	}
+	if status, ret := dev.GetRetiredPagesPendingStatus(); ret == nvml.SUCCESS && status == nvml.FEATURE_ENABLED {
+		return errors.New("page retirement pending")
+	}
+	return nil
+}
+
+func (plugin *NvidiaDevicePlugin) healthRecoveryRules() nvidia.DeviceHealthRecovery {
+	if rules := plugin.schedulerConfig.DeviceHealthRecovery; rules != nil {
+		return *rules
+	}
+	return nvidia.DeviceHealthRecovery{}
+}
+
+// deviceUnhealthy records that health checks failed on uuid. The first failure
+// cordons the device and is recorded as a node event.
+func (plugin *NvidiaDevicePlugin) deviceUnhealthy(uuid string) {
+	if plugin.deviceHealth == nil || !plugin.deviceHealth.markUnhealthy(uuid) {
+		return
+	}
+	message := fmt.Sprintf("Device %s failed health checks", uuid)
+	if plugin.healthRecoveryRules().AutoCordon {
+		message += " and is cordoned"
+	}
+	// Node updates must not hold up the ListAndWatch stream.
+	go func() {
+		if err := plugin.syncDeviceCordon(); err != nil {
+			klog.ErrorS(err, "Failed to cordon unhealthy device", "uuid", uuid)
+		}
+		recordDeviceHealthEvent(corev1.EventTypeWarning, deviceUnhealthyReason, message)
+	}()
+}
+
+// runDeviceHealthRecovery returns unhealthy devices to service as the
+// recovery rules allow, until stop is closed.
+func (plugin *NvidiaDevicePlugin) runDeviceHealthRecovery(stop <-chan any, interval time.Duration) {
+	// Entries left by a previous run belong to devices health checks have
+	// not failed on yet.
+	if err := plugin.syncDeviceCordon(); err != nil {
+		klog.ErrorS(err, "Failed to sync device cordon")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			plugin.recoverDevices()
+		}
+	}
+}
+
+// recoverDevices marks the devices the recovery rules let go healthy again,
+// uncordons them and records each recovery as a node event.
+func (plugin *NvidiaDevicePlugin) recoverDevices() {
+	rules := plugin.healthRecoveryRules()
+	if plugin.deviceHealth == nil || (rules.RecoverAfterSeconds == 0 && !rules.RecoverOnReset) || len(plugin.deviceHealth.unhealthyDevices()) == 0 {
+		return
+	}
+	if ret := nvmlInit(); ret != nvml.SUCCESS {
+		klog.ErrorS(errors.New(nvml.ErrorString(ret)), "Skipping device health recovery, nvml Init failed")
+		return
+	}
+	recovered := plugin.deviceHealth.recover(rules, probeDeviceHealth)
+	nvmlShutdown()
+	if len(recovered) == 0 {
+		return
+	}
+	for _, d := range plugin.Devices() {
+		if _, ok := recovered[d.GetUUID()]; ok {
+			d.Health = kubeletdevicepluginv1beta1.Healthy
+		}
+	}
+	select {
+	case plugin.healthRefresh <- struct{}{}:
+	default:
+	}
+	if err := plugin.syncDeviceCordon(); err != nil {
+		klog.ErrorS(err, "Failed to uncordon recovered devices")
+	}
+	uuids := make([]string, 0, len(recovered))
+	for uuid := range recovered {
+		uuids = append(uuids, uuid)
+	}
+	slices.Sort(uuids)
+	for _, uuid := range uuids {
+		klog.InfoS("Device recovered", "uuid", uuid, "cause", recovered[uuid])
+		recordDeviceHealthEvent(corev1.EventTypeNormal, deviceRecoveredReason, fmt.Sprintf("Device %s is healthy again after %s", uuid, recovered[uuid]))
+	}
+}
+
+// syncDeviceCordon makes the node's device-cordon annotation list exactly the
+// unhealthy devices next to the operator's own entries.
+func (plugin *NvidiaDevicePlugin) syncDeviceCordon() error {
+	plugin.cordonMu.Lock()
+	defer plugin.cordonMu.Unlock()
+	var unhealthy []string
+	if plugin.healthRecoveryRules().AutoCordon && plugin.deviceHealth != nil {
+		unhealthy = plugin.deviceHealth.unhealthyDevices()
+	}
+	node, err := util.GetNode(util.NodeName)
+	if err != nil {
+		return err
+	}
+	annos, changed := autoCordonAnnotations(node.Annotations, unhealthy)
+	if !changed {
+		return nil
+	}
+	klog.InfoS("Updating device cordon", "cordoned", annos[nvidia.DeviceCordonAnnotation], "auto", annos[nvidia.DeviceAutoCordonAnnotation])
+	return util.PatchNodeAnnotations(node, annos)
+}
+
+// autoCordonAnnotations returns the device-cordon annotations that cordon
+// unhealthy. Only entries recorded in DeviceAutoCordonAnnotation are removed,
+// and devices the operator cordoned already are not recorded there.
+func autoCordonAnnotations(annos map[string]string, unhealthy []string) (map[string]string, bool) {
+	cordoned := splitUUIDs(annos[nvidia.DeviceCordonAnnotation])
+	owned := splitUUIDs(annos[nvidia.DeviceAutoCordonAnnotation])
+	var list, auto []string
+	for _, uuid := range cordoned {
+		if slices.Contains(owned, uuid) {
+			if !slices.Contains(unhealthy, uuid) {
+				continue
+			}
+			auto = append(auto, uuid)
+		}
+		list = append(list, uuid)
+	}
+	for _, uuid := range unhealthy {
+		if !slices.Contains(cordoned, uuid) {
+			list = append(list, uuid)
+			auto = append(auto, uuid)
+		}
+	}
+	changed := !slices.Equal(list, cordoned) || !slices.Equal(auto, owned)
+	return map[string]string{
+		nvidia.DeviceCordonAnnotation:     strings.Join(list, ","),
+		nvidia.DeviceAutoCordonAnnotation: strings.Join(auto, ","),
+	}, changed
+}
+
+func splitUUIDs(raw string) []string {
+	var out []string
+	for uuid := range strings.SplitSeq(raw, ",") {
+		if uuid = strings.TrimSpace(uuid); uuid != "" {
+			out = append(out, uuid)
+		}
+	}
+	return out
+}
+
+// recordDeviceHealthEvent records a device health transition on the node.
+// Transitions are never merged with earlier ones.
+var recordDeviceHealthEvent = func(eventType, reason, message string) {
+	node, err := util.GetNode(util.NodeName)
+	if err != nil {
+		klog.ErrorS(err, "Failed to record device health event", "reason", reason)
+		return
+	}
+	if eventType == corev1.EventTypeWarning {
+		util.EmitNodeWarningEvent(node, reason, message, 0)
+		return
+	}
+	util.EmitNodeNormalEvent(node, reason, message, 0)
+}
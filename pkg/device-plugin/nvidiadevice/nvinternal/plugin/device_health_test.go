@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func TestDeviceHealthTrackerRecover(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tracker := newDeviceHealthTracker()
+	tracker.now = func() time.Time { return now }
+	healthy := func(string) error { return nil }
+	lost := func(string) error { return errors.New("gpu is lost") }
+
+	require.True(t, tracker.markUnhealthy("GPU-a"))
+	require.False(t, tracker.markUnhealthy("GPU-a"), "repeated XIDs are one transition")
+
+	rules := nvidia.DeviceHealthRecovery{RecoverOnReset: true}
+	require.Empty(t, tracker.recover(rules, healthy), "a device that never needed a reset is not recovered by one")
+	require.Empty(t, tracker.recover(rules, lost))
+	require.Equal(t, map[string]string{"GPU-a": recoveredAfterReset}, tracker.recover(rules, healthy))
+	require.Empty(t, tracker.unhealthyDevices())
+
+	rules = nvidia.DeviceHealthRecovery{RecoverAfterSeconds: 60, MaxRecoveries: 2}
+	tracker.markUnhealthy("GPU-a")
+	now = now.Add(30 * time.Second)
+	require.Empty(t, tracker.recover(rules, healthy))
+	now = now.Add(30 * time.Second)
+	require.Equal(t, map[string]string{"GPU-a": recoveredAfterQuiet}, tracker.recover(rules, healthy))
+
+	tracker.markUnhealthy("GPU-a")
+	now = now.Add(time.Hour)
+	require.Empty(t, tracker.recover(rules, healthy), "the device already recovered MaxRecoveries times")
+	require.Equal(t, []string{"GPU-a"}, tracker.unhealthyDevices())
+}
+
+func TestAutoCordonAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annos       map[string]string
+		unhealthy   []string
+		wantCordon  string
+		wantAuto    string
+		wantChanged bool
+	}{
+		{
+			name: "nothing to do",
+		},
+		{
+			name:        "cordon next to the operator's entries",
+			annos:       map[string]string{nvidia.DeviceCordonAnnotation: "GPU-op"},
+			unhealthy:   []string{"GPU-a"},
+			wantCordon:  "GPU-op,GPU-a",
+			wantAuto:    "GPU-a",
+			wantChanged: true,
+		},
+		{
+			name:        "uncordon only own entries",
+			annos:       map[string]string{nvidia.DeviceCordonAnnotation: "GPU-op, GPU-a", nvidia.DeviceAutoCordonAnnotation: "GPU-a"},
+			wantCordon:  "GPU-op",
+			wantChanged: true,
+		},
+		{
+			name:       "operator cordon is not taken over",
+			annos:      map[string]string{nvidia.DeviceCordonAnnotation: "GPU-op"},
+			unhealthy:  []string{"GPU-op"},
+			wantCordon: "GPU-op",
+		},
+		{
+			name:       "already cordoned",
+			annos:      map[string]string{nvidia.DeviceCordonAnnotation: "GPU-a", nvidia.DeviceAutoCordonAnnotation: "GPU-a"},
+			unhealthy:  []string{"GPU-a"},
+			wantCordon: "GPU-a",
+			wantAuto:   "GPU-a",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			annos, changed := autoCordonAnnotations(test.annos, test.unhealthy)
+			require.Equal(t, test.wantChanged, changed)
+			require.Equal(t, test.wantCordon, annos[nvidia.DeviceCordonAnnotation])
+			require.Equal(t, test.wantAuto, annos[nvidia.DeviceAutoCordonAnnotation])
+		})
+	}
+}
+
+func TestRecoverDevicesUncordonsAndRecordsEvents(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-node", Annotations: map[string]string{
+		nvidia.DeviceCordonAnnotation:     "GPU-op",
+		nvidia.DeviceAutoCordonAnnotation: "",
+	}}}
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(node)
+	defer func() { client.KubeClient = previousKubeClient }()
+	previousNodeName := util.NodeName
+	util.NodeName = node.Name
+	defer func() { util.NodeName = previousNodeName }()
+
+	previousInit, previousShutdown, previousProbe := nvmlInit, nvmlShutdown, probeDeviceHealth
+	nvmlInit = func() nvml.Return { return nvml.SUCCESS }
+	nvmlShutdown = func() nvml.Return { return nvml.SUCCESS }
+	probeDeviceHealth = func(string) error { return nil }
+	defer func() { nvmlInit, nvmlShutdown, probeDeviceHealth = previousInit, previousShutdown, previousProbe }()
+
+	gpu := &rm.Device{Device: kubeletdevicepluginv1beta1.Device{ID: "GPU-a", Health: kubeletdevicepluginv1beta1.Unhealthy}}
+	now := time.Now()
+	plugin := &NvidiaDevicePlugin{
+		rm: &rm.ResourceManagerMock{DevicesFunc: func() rm.Devices { return rm.Devices{"GPU-a": gpu} }},
+		schedulerConfig: nvidia.NvidiaConfig{NodeDefaultConfig: nvidia.NodeDefaultConfig{
+			DeviceHealthRecovery: &nvidia.DeviceHealthRecovery{AutoCordon: true, RecoverAfterSeconds: 60},
+		}},
+		deviceHealth:  newDeviceHealthTracker(),
+		healthRefresh: make(chan struct{}, 1),
+	}
+	plugin.deviceHealth.now = func() time.Time { return now }
+
+	plugin.deviceHealth.markUnhealthy("GPU-a")
+	require.NoError(t, plugin.syncDeviceCordon())
+	updated, err := client.KubeClient.CoreV1().Nodes().Get(context.Background(), node.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "GPU-op,GPU-a", updated.Annotations[nvidia.DeviceCordonAnnotation])
+
+	now = now.Add(time.Minute)
+	plugin.recoverDevices()
+	require.Equal(t, kubeletdevicepluginv1beta1.Healthy, gpu.Health)
+	require.Len(t, plugin.healthRefresh, 1, "ListAndWatch re-advertises the recovered device")
+	updated, err = client.KubeClient.CoreV1().Nodes().Get(context.Background(), node.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "GPU-op", updated.Annotations[nvidia.DeviceCordonAnnotation])
+	require.Empty(t, updated.Annotations[nvidia.DeviceAutoCordonAnnotation])
+
+	events, err := client.KubeClient.CoreV1().Events(corev1.NamespaceDefault).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	require.Equal(t, deviceRecoveredReason, events.Items[0].Reason)
+	require.Equal(t, corev1.EventTypeNormal, events.Items[0].Type)
+	require.Equal(t, "Device GPU-a is healthy again after its XID condition cleared", events.Items[0].Message)
+}
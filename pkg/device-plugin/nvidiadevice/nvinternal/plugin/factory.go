@@ -83,7 +83,7 @@ func New(ctx context.Context, infolib info.Interface, nvmllib nvml.Interface, de
 		o.cdiHandler = cdi.NewNullHandler()
 	}
 
-	sConfig, mode, err := LoadNvidiaDevicePluginConfig()
+	sConfig, modes, err := LoadNvidiaDevicePluginConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load nvidia plugin config: %v", err)
 	}
@@ -95,7 +95,7 @@ func New(ctx context.Context, infolib info.Interface, nvmllib nvml.Interface, de
 
 	var plugins []Interface
 	for _, resourceManager := range resourceManagers {
-		plugin, err := o.devicePluginForResource(ctx, o.config, resourceManager, sConfig, mode)
+		plugin, err := o.devicePluginForResource(ctx, o.config, resourceManager, sConfig, modes)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create plugin: %w", err)
 		}
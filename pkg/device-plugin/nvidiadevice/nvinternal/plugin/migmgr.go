@@ -12,12 +12,16 @@ package plugin
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 )
 
 var profileNameToGIProfileID = map[string]int{
@@ -41,10 +45,12 @@ var profileNameToCIProfileID = map[string]int{
 }
 
 type migAllocationKey struct {
-	GPUIndex int
-	Profile  string
-	Start    uint32
-	Size     uint32
+	GPUIndex     int
+	Profile      string
+	Start        uint32
+	Size         uint32
+	ComputeStart uint32
+	ComputeSize  uint32
 }
 
 // migInstance tracks the NVML-level identity of a live MIG GI+CI pair bound to
@@ -55,6 +61,9 @@ type migInstance struct {
 	GIID      uint32
 	CIID      uint32
 	MigUUID   string
+	// ComputePlacement is set when the CI shares its GI with other
+	// allocations; the GI then outlives the CI until the last one goes.
+	ComputePlacement nvml.ComputeInstancePlacement
 }
 
 // MigInstanceManager is the single authority over live MIG GI+CI state on a
@@ -113,6 +122,34 @@ func profileSliceKey(profile string) string {
 	return profile
 }
 
+// migProfileIDs resolves the NVML GPU and compute instance profile IDs of a
+// GPU instance profile such as 3g.20gb, or of a compute instance profile such
+// as 1c.3g.20gb that runs in a 3g GPU instance.
+func migProfileIDs(profile string) (int, int, error) {
+	giKey, ciKey := profileSliceKey(profile), profileSliceKey(profile)
+	if computeSlices, giProfile, ok := nvidia.ParseMigComputeProfile(profile); ok {
+		giKey, ciKey = profileSliceKey(giProfile), fmt.Sprintf("%dg", computeSlices)
+	}
+	giProfileID, ok := profileNameToGIProfileID[giKey]
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported MIG profile %q", profile)
+	}
+	ciProfileID, ok := profileNameToCIProfileID[ciKey]
+	if !ok {
+		return 0, 0, fmt.Errorf("unsupported MIG compute profile %q", profile)
+	}
+	return giProfileID, ciProfileID, nil
+}
+
+// migComputePlacement converts an annotated compute placement; a nil one
+// means the allocation owns its whole GPU instance.
+func migComputePlacement(placement *device.MigPlacement) nvml.ComputeInstancePlacement {
+	if placement == nil {
+		return nvml.ComputeInstancePlacement{}
+	}
+	return nvml.ComputeInstancePlacement{Start: placement.Start, Size: placement.Size}
+}
+
 // ResetIdleGPUs prepares idle MIG-capable GPUs for on-demand instance creation
 // through NVML. Busy GPUs are left untouched; idle GPUs
 // have MIG mode enabled and all existing GI/CI instances destroyed.
@@ -134,7 +171,7 @@ func (m *MigInstanceManager) ResetIdleGPUs(deviceCount int, inUse map[int]struct
 			lk.Unlock()
 			return reset, err
 		}
-		if err := destroyAllMigInstances(dev); err != nil {
+		if _, err := destroyAllMigInstances(dev); err != nil {
 			lk.Unlock()
 			return reset, err
 		}
@@ -158,6 +195,69 @@ func (m *MigInstanceManager) ResetIdleGPUs(deviceCount int, inUse map[int]struct
 	return reset, nil
 }
 
+// ConsolidateIdleGPUs destroys the instances left on MIG-enabled GPUs that no
+// tracked allocation and no process uses. Such instances pin placements the
+// scheduler considers free, so clearing them lets the GPU take its largest
+// profiles again. GPUs in busy are left alone; the reset GPUs are returned.
+func (m *MigInstanceManager) ConsolidateIdleGPUs(deviceCount int, busy map[int]struct{}) ([]int, error) {
+	reset := []int{}
+	for gpuIndex := 0; gpuIndex < deviceCount; gpuIndex++ {
+		if _, ok := busy[gpuIndex]; ok {
+			continue
+		}
+		destroyed, err := m.consolidateIdleGPU(gpuIndex)
+		if err != nil {
+			return reset, err
+		}
+		if destroyed > 0 {
+			reset = append(reset, gpuIndex)
+		}
+	}
+	return reset, nil
+}
+
+func (m *MigInstanceManager) consolidateIdleGPU(gpuIndex int) (int, error) {
+	lk := m.gpuLock(gpuIndex)
+	lk.Lock()
+	defer lk.Unlock()
+	if m.hasAllocations(gpuIndex) {
+		return 0, nil
+	}
+	dev, err := deviceHandleByIndex(gpuIndex)
+	if err != nil {
+		return 0, err
+	}
+	curMode, _, ret := dev.GetMigMode()
+	if ret != nvml.SUCCESS || curMode != nvml.DEVICE_MIG_ENABLE {
+		return 0, nil
+	}
+	return destroyAllMigInstances(dev)
+}
+
+func (m *MigInstanceManager) hasAllocations(gpuIndex int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.byAllocation {
+		if key.GPUIndex == gpuIndex {
+			return true
+		}
+	}
+	return false
+}
+
+// DisableMigMode turns MIG mode off on an idle GPU so it can run whole-GPU
+// modes again. It refuses while the manager still tracks allocations on the
+// GPU; leftover instances nothing tracks are destroyed.
+func (m *MigInstanceManager) DisableMigMode(gpuIndex int) error {
+	lk := m.gpuLock(gpuIndex)
+	lk.Lock()
+	defer lk.Unlock()
+	if m.hasAllocations(gpuIndex) {
+		return fmt.Errorf("gpu %d still has MIG allocations", gpuIndex)
+	}
+	return ensureMigModeDisabled(gpuIndex)
+}
+
 func deviceHandleByIndex(gpuIndex int) (nvml.Device, error) {
 	dev, ret := nvml.DeviceGetHandleByIndex(gpuIndex)
 	if ret != nvml.SUCCESS {
@@ -219,8 +319,65 @@ func ensureMigModeEnabled(gpuIndex int) error {
 	return fmt.Errorf("gpu %d mig mode is not enabled after set (current=%d pending=%d)", gpuIndex, curMode, pendingMode)
 }
 
-// destroyMigInstance destroys the tracked GI+CI on hardware. Returns
-// nil when the instance is already gone or was destroyed successfully.
+// ensureMigModeDisabled turns off MIG mode via NVML when the card is in MIG
+// mode and no process runs on it. No-op when MIG mode is unsupported or
+// already off.
+//
+// Like SetMigMode(enable), this may reset the device; callers must re-fetch
+// the device handle afterwards.
+func ensureMigModeDisabled(gpuIndex int) error {
+	dev, err := deviceHandleByIndex(gpuIndex)
+	if err != nil {
+		return err
+	}
+	curMode, pendingMode, ret := dev.GetMigMode()
+	if ret == nvml.ERROR_NOT_SUPPORTED {
+		return nil
+	}
+	if ret != nvml.SUCCESS {
+		return fmt.Errorf("gpu %d get mig mode: %s", gpuIndex, nvml.ErrorString(ret))
+	}
+	if curMode == nvml.DEVICE_MIG_DISABLE {
+		if pendingMode == nvml.DEVICE_MIG_DISABLE {
+			return nil
+		}
+		return fmt.Errorf("gpu %d mig mode enable is pending (current=disable pending=%d)", gpuIndex, pendingMode)
+	}
+	if deviceHasProcesses(dev) {
+		return fmt.Errorf("gpu %d is busy, cannot leave mig mode", gpuIndex)
+	}
+	if _, err := destroyAllMigInstances(dev); err != nil {
+		return err
+	}
+
+	activation, ret := dev.SetMigMode(nvml.DEVICE_MIG_DISABLE)
+	if ret != nvml.SUCCESS {
+		return fmt.Errorf("gpu %d set mig mode: %s", gpuIndex, nvml.ErrorString(ret))
+	}
+	if activation != nvml.SUCCESS {
+		return fmt.Errorf("gpu %d deactivate mig mode: %s", gpuIndex, nvml.ErrorString(activation))
+	}
+
+	dev, err = deviceHandleByIndex(gpuIndex)
+	if err != nil {
+		return err
+	}
+	curMode, pendingMode, ret = dev.GetMigMode()
+	if ret != nvml.SUCCESS {
+		return fmt.Errorf("gpu %d verify mig mode after set: %s", gpuIndex, nvml.ErrorString(ret))
+	}
+	if curMode == nvml.DEVICE_MIG_DISABLE {
+		return nil
+	}
+	if pendingMode == nvml.DEVICE_MIG_DISABLE {
+		return fmt.Errorf("gpu %d mig mode disable is pending after set; GPU reset required", gpuIndex)
+	}
+	return fmt.Errorf("gpu %d mig mode is not disabled after set (current=%d pending=%d)", gpuIndex, curMode, pendingMode)
+}
+
+// destroyMigInstance destroys the tracked GI+CI on hardware. A GI shared by
+// compute instance allocations is kept while other CIs still run in it.
+// Returns nil when the instance is already gone or was destroyed successfully.
 func destroyMigInstance(gpuIndex int, inst *migInstance) error {
 	if inst == nil {
 		return nil
@@ -243,15 +400,34 @@ func destroyMigInstance(gpuIndex int, inst *migInstance) error {
 	} else if r != nvml.ERROR_NOT_FOUND {
 		return fmt.Errorf("get CI %d on gpu %d: %s", inst.CIID, gpuIndex, nvml.ErrorString(r))
 	}
+	if inst.ComputePlacement.Size > 0 && hasComputeInstances(gi) {
+		return nil
+	}
 	if d := gi.Destroy(); d != nvml.SUCCESS {
 		return fmt.Errorf("destroy GI %d on gpu %d: %s", inst.GIID, gpuIndex, nvml.ErrorString(d))
 	}
 	return nil
 }
 
-// destroyAllMigInstances enumerates and destroys every GI+CI on the device.
-// It is used to reset idle GPUs before accepting scheduler allocations.
-func destroyAllMigInstances(dev nvml.Device) error {
+// hasComputeInstances reports whether any compute instance still runs in gi.
+func hasComputeInstances(gi nvml.GpuInstance) bool {
+	for ciProfileID := 0; ciProfileID < nvml.COMPUTE_INSTANCE_PROFILE_COUNT; ciProfileID++ {
+		ciInfo, r := gi.GetComputeInstanceProfileInfo(ciProfileID, nvml.COMPUTE_INSTANCE_ENGINE_PROFILE_SHARED)
+		if r != nvml.SUCCESS {
+			continue
+		}
+		if cis, r := gi.GetComputeInstances(&ciInfo); r == nvml.SUCCESS && len(cis) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// destroyAllMigInstances enumerates and destroys every GI+CI on the device
+// and returns the number of GPU instances destroyed. It is used to reset idle
+// GPUs before accepting scheduler allocations.
+func destroyAllMigInstances(dev nvml.Device) (int, error) {
+	destroyed := 0
 	for _, giProfileID := range []int{
 		nvml.GPU_INSTANCE_PROFILE_1_SLICE,
 		nvml.GPU_INSTANCE_PROFILE_2_SLICE,
@@ -281,16 +457,17 @@ func destroyAllMigInstances(dev nvml.Device) error {
 				}
 				for _, ci := range cis {
 					if d := ci.Destroy(); d != nvml.SUCCESS {
-						return fmt.Errorf("destroy compute instance: %s", nvml.ErrorString(d))
+						return destroyed, fmt.Errorf("destroy compute instance: %s", nvml.ErrorString(d))
 					}
 				}
 			}
 			if d := gi.Destroy(); d != nvml.SUCCESS {
-				return fmt.Errorf("destroy gpu instance: %s", nvml.ErrorString(d))
+				return destroyed, fmt.Errorf("destroy gpu instance: %s", nvml.ErrorString(d))
 			}
+			destroyed++
 		}
 	}
-	return nil
+	return destroyed, nil
 }
 
 // Release destroys the GI+CI bound to the given MIG UUID.
@@ -321,16 +498,22 @@ func (m *MigInstanceManager) Release(migUUID string) error {
 	klog.InfoS("released MIG allocation", "uuid", migUUID, "gpu", key.GPUIndex, "profile", key.Profile, "start", key.Start)
 	return nil
 }
-func allocationKey(gpuIndex int, profile string, placement nvml.GpuInstancePlacement) migAllocationKey {
-	return migAllocationKey{GPUIndex: gpuIndex, Profile: profile, Start: placement.Start, Size: placement.Size}
+
+func allocationKey(gpuIndex int, profile string, placement nvml.GpuInstancePlacement, compute nvml.ComputeInstancePlacement) migAllocationKey {
+	return migAllocationKey{
+		GPUIndex: gpuIndex, Profile: profile, Start: placement.Start, Size: placement.Size,
+		ComputeStart: compute.Start, ComputeSize: compute.Size,
+	}
 }
 
 // EnsureAllocation realizes exactly the scheduler-reserved profile and
 // placement. It returns whether this call created the instance, allowing the
 // caller to roll back only its own partial allocation. It never retries
-// another placement.
-func (m *MigInstanceManager) EnsureAllocation(gpuIndex int, profile string, placement nvml.GpuInstancePlacement) (string, bool, error) {
-	key := allocationKey(gpuIndex, profile, placement)
+// another placement. A non-empty compute placement creates a CI of a compute
+// instance profile inside the GI at placement, reusing the GI when other
+// allocations already run in it.
+func (m *MigInstanceManager) EnsureAllocation(gpuIndex int, profile string, placement nvml.GpuInstancePlacement, compute nvml.ComputeInstancePlacement) (string, bool, error) {
+	key := allocationKey(gpuIndex, profile, placement, compute)
 	lk := m.gpuLock(gpuIndex)
 	lk.Lock()
 	defer lk.Unlock()
@@ -346,14 +529,9 @@ func (m *MigInstanceManager) EnsureAllocation(gpuIndex int, profile string, plac
 	if err := ensureMigModeEnabled(gpuIndex); err != nil {
 		return "", false, err
 	}
-	profileKey := profileSliceKey(profile)
-	giProfileID, ok := profileNameToGIProfileID[profileKey]
-	if !ok {
-		return "", false, fmt.Errorf("unsupported MIG profile %q", profile)
-	}
-	ciProfileID, ok := profileNameToCIProfileID[profileKey]
-	if !ok {
-		return "", false, fmt.Errorf("unsupported MIG compute profile %q", profile)
+	giProfileID, ciProfileID, err := migProfileIDs(profile)
+	if err != nil {
+		return "", false, err
 	}
 	dev, err := deviceHandleByIndex(gpuIndex)
 	if err != nil {
@@ -377,48 +555,99 @@ func (m *MigInstanceManager) EnsureAllocation(gpuIndex int, profile string, plac
 	if !valid {
 		return "", false, fmt.Errorf("scheduler selected invalid placement %+v for profile %s", placement, profile)
 	}
-	gi, ret := dev.CreateGpuInstanceWithPlacement(&giInfo, &placement)
-	if ret != nvml.SUCCESS {
-		return "", false, fmt.Errorf("create GI profile=%s placement=%+v: %s", profile, placement, nvml.ErrorString(ret))
+	var gi nvml.GpuInstance
+	if compute.Size > 0 {
+		gi = findGpuInstance(dev, &giInfo, placement)
+	}
+	ownGI := gi == nil
+	if ownGI {
+		gi, ret = dev.CreateGpuInstanceWithPlacement(&giInfo, &placement)
+		if ret != nvml.SUCCESS {
+			return "", false, fmt.Errorf("create GI profile=%s placement=%+v: %s", profile, placement, nvml.ErrorString(ret))
+		}
+	}
+	destroyGI := func() {
+		if ownGI {
+			gi.Destroy()
+		}
 	}
 	giData, ret := gi.GetInfo()
 	if ret != nvml.SUCCESS {
-		gi.Destroy()
+		destroyGI()
 		return "", false, fmt.Errorf("get GI info: %s", nvml.ErrorString(ret))
 	}
 	ciInfo, ret := gi.GetComputeInstanceProfileInfo(ciProfileID, nvml.COMPUTE_INSTANCE_ENGINE_PROFILE_SHARED)
 	if ret != nvml.SUCCESS {
-		gi.Destroy()
+		destroyGI()
 		return "", false, fmt.Errorf("get CI profile info: %s", nvml.ErrorString(ret))
 	}
-	ci, ret := gi.CreateComputeInstance(&ciInfo)
-	if ret != nvml.SUCCESS {
-		gi.Destroy()
-		return "", false, fmt.Errorf("create CI: %s", nvml.ErrorString(ret))
+	ci, err := createComputeInstance(gi, &ciInfo, compute)
+	if err != nil {
+		destroyGI()
+		return "", false, fmt.Errorf("create CI profile=%s placement=%+v: %w", profile, compute, err)
 	}
 	ciData, ret := ci.GetInfo()
 	if ret != nvml.SUCCESS {
 		ci.Destroy()
-		gi.Destroy()
+		destroyGI()
 		return "", false, fmt.Errorf("get CI info: %s", nvml.ErrorString(ret))
 	}
-	migUUID, err := findMigUUIDForGI(dev, giData.Id)
+	migUUID, err := findMigUUID(dev, giData.Id, ciData.Id)
 	if err != nil {
 		ci.Destroy()
-		gi.Destroy()
+		destroyGI()
 		return "", false, err
 	}
-	inst := &migInstance{Profile: profile, Placement: placement, GIID: giData.Id, CIID: ciData.Id, MigUUID: migUUID}
+	inst := &migInstance{Profile: profile, Placement: placement, GIID: giData.Id, CIID: ciData.Id, MigUUID: migUUID, ComputePlacement: compute}
 	m.mu.Lock()
 	m.byAllocation[key] = inst
 	m.byAllocationMigUUID[migUUID] = key
 	m.mu.Unlock()
-	klog.InfoS("created scheduler-reserved MIG allocation", "uuid", migUUID, "gpu", gpuIndex, "profile", profile, "start", placement.Start, "size", placement.Size, "gpuInstanceID", giData.Id, "computeInstanceID", ciData.Id)
+	klog.InfoS("created scheduler-reserved MIG allocation", "uuid", migUUID, "gpu", gpuIndex, "profile", profile, "start", placement.Start, "size", placement.Size, "computeStart", compute.Start, "computeSize", compute.Size, "gpuInstanceID", giData.Id, "computeInstanceID", ciData.Id)
 	return migUUID, true, nil
 }
 
-func (m *MigInstanceManager) AllocationRuntimeInfo(gpuIndex int, profile string, placement nvml.GpuInstancePlacement) (migAllocationRuntimeInfo, bool) {
-	key := allocationKey(gpuIndex, profile, placement)
+// findGpuInstance returns the live GI of the given profile at placement, or
+// nil when there is none.
+func findGpuInstance(dev nvml.Device, giInfo *nvml.GpuInstanceProfileInfo, placement nvml.GpuInstancePlacement) nvml.GpuInstance {
+	instances, ret := dev.GetGpuInstances(giInfo)
+	if ret != nvml.SUCCESS {
+		return nil
+	}
+	for _, gi := range instances {
+		if info, r := gi.GetInfo(); r == nvml.SUCCESS && info.Placement == placement {
+			return gi
+		}
+	}
+	return nil
+}
+
+// createComputeInstance creates a CI filling gi, or at the given compute
+// placement when it is set.
+func createComputeInstance(gi nvml.GpuInstance, ciInfo *nvml.ComputeInstanceProfileInfo, compute nvml.ComputeInstancePlacement) (nvml.ComputeInstance, error) {
+	if compute.Size == 0 {
+		ci, ret := gi.CreateComputeInstance(ciInfo)
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("%s", nvml.ErrorString(ret))
+		}
+		return ci, nil
+	}
+	possible, ret := gi.GetComputeInstancePossiblePlacements(ciInfo)
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("get compute placements: %s", nvml.ErrorString(ret))
+	}
+	if !slices.Contains(possible, compute) {
+		return nil, fmt.Errorf("scheduler selected invalid compute placement %+v", compute)
+	}
+	ci, ret := gi.CreateComputeInstanceWithPlacement(ciInfo, &compute)
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("%s", nvml.ErrorString(ret))
+	}
+	return ci, nil
+}
+
+func (m *MigInstanceManager) AllocationRuntimeInfo(gpuIndex int, profile string, placement nvml.GpuInstancePlacement, compute nvml.ComputeInstancePlacement) (migAllocationRuntimeInfo, bool) {
+	key := allocationKey(gpuIndex, profile, placement, compute)
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	inst := m.byAllocation[key]
@@ -434,7 +663,7 @@ func (m *MigInstanceManager) AllocationRuntimeInfo(gpuIndex int, profile string,
 	}, true
 }
 
-func (m *MigInstanceManager) AdoptAllocation(gpuIndex int, profile, migUUID string, placement nvml.GpuInstancePlacement, gpuInstanceID, computeInstanceID uint32) error {
+func (m *MigInstanceManager) AdoptAllocation(gpuIndex int, profile, migUUID string, placement nvml.GpuInstancePlacement, compute nvml.ComputeInstancePlacement, gpuInstanceID, computeInstanceID uint32) error {
 	lk := m.gpuLock(gpuIndex)
 	lk.Lock()
 	defer lk.Unlock()
@@ -442,14 +671,9 @@ func (m *MigInstanceManager) AdoptAllocation(gpuIndex int, profile, migUUID stri
 	if err != nil {
 		return err
 	}
-	profileKey := profileSliceKey(profile)
-	giProfileID, ok := profileNameToGIProfileID[profileKey]
-	if !ok {
-		return fmt.Errorf("unsupported MIG profile %q", profile)
-	}
-	ciProfileID, ok := profileNameToCIProfileID[profileKey]
-	if !ok {
-		return fmt.Errorf("unsupported MIG compute profile %q", profile)
+	giProfileID, ciProfileID, err := migProfileIDs(profile)
+	if err != nil {
+		return err
 	}
 	profileInfo, ret := dev.GetGpuInstanceProfileInfo(giProfileID)
 	if ret != nvml.SUCCESS {
@@ -464,25 +688,24 @@ func (m *MigInstanceManager) AdoptAllocation(gpuIndex int, profile, migUUID stri
 		if r != nvml.SUCCESS || giInfo.Placement != placement || giInfo.Id != gpuInstanceID {
 			continue
 		}
-		actualUUID, findErr := findMigUUIDForGI(dev, giInfo.Id)
-		if findErr != nil || actualUUID != migUUID {
+		ci, r := gi.GetComputeInstanceById(int(computeInstanceID))
+		if r != nvml.SUCCESS {
 			continue
 		}
-		ciInfo, r := gi.GetComputeInstanceProfileInfo(ciProfileID, nvml.COMPUTE_INSTANCE_ENGINE_PROFILE_SHARED)
-		if r != nvml.SUCCESS {
+		ciData, r := ci.GetInfo()
+		if r != nvml.SUCCESS || ciData.ProfileId != uint32(ciProfileID) {
 			continue
 		}
-		cis, r := gi.GetComputeInstances(&ciInfo)
-		if r != nvml.SUCCESS || len(cis) == 0 {
+		if compute.Size > 0 && ciData.Placement != compute {
 			continue
 		}
-		ciData, r := cis[0].GetInfo()
-		if r != nvml.SUCCESS || ciData.Id != computeInstanceID {
+		actualUUID, findErr := findMigUUID(dev, giInfo.Id, ciData.Id)
+		if findErr != nil || actualUUID != migUUID {
 			continue
 		}
-		key := allocationKey(gpuIndex, profile, placement)
+		key := allocationKey(gpuIndex, profile, placement, compute)
 		m.mu.Lock()
-		m.byAllocation[key] = &migInstance{Profile: profile, Placement: placement, GIID: giInfo.Id, CIID: ciData.Id, MigUUID: migUUID}
+		m.byAllocation[key] = &migInstance{Profile: profile, Placement: placement, GIID: giInfo.Id, CIID: ciData.Id, MigUUID: migUUID, ComputePlacement: compute}
 		m.byAllocationMigUUID[migUUID] = key
 		m.mu.Unlock()
 		return nil
@@ -530,7 +753,8 @@ type migAllocationRuntimeInfo struct {
 	CIID      uint32
 }
 
-func findMigUUIDForGI(dev nvml.Device, giID uint32) (string, error) {
+// findMigUUID returns the UUID of the MIG device backing the given GI and CI.
+func findMigUUID(dev nvml.Device, giID, ciID uint32) (string, error) {
 	maxCount, ret := dev.GetMaxMigDeviceCount()
 	if ret != nvml.SUCCESS {
 		return "", fmt.Errorf("get max MIG device count: %s", nvml.ErrorString(ret))
@@ -544,7 +768,11 @@ func findMigUUIDForGI(dev nvml.Device, giID uint32) (string, error) {
 		if ret != nvml.SUCCESS {
 			continue
 		}
-		if uint32(gotGI) == giID {
+		if uint32(gotGI) != giID {
+			continue
+		}
+		gotCI, ret := migDev.GetComputeInstanceId()
+		if ret == nvml.SUCCESS && uint32(gotCI) == ciID {
 			uuid, ret := migDev.GetUUID()
 			if ret != nvml.SUCCESS {
 				return "", fmt.Errorf("get MIG UUID: %s", nvml.ErrorString(ret))
@@ -552,5 +780,5 @@ func findMigUUIDForGI(dev nvml.Device, giID uint32) (string, error) {
 			return uuid, nil
 		}
 	}
-	return "", fmt.Errorf("no MIG device found for GI %d", giID)
+	return "", fmt.Errorf("no MIG device found for GI %d CI %d", giID, ciID)
 }
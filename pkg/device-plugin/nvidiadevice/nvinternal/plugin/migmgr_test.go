@@ -0,0 +1,46 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+func TestMigProfileIDs(t *testing.T) {
+	gi, ci, err := migProfileIDs("3g.20gb")
+	require.NoError(t, err)
+	require.Equal(t, nvml.GPU_INSTANCE_PROFILE_3_SLICE, gi)
+	require.Equal(t, nvml.COMPUTE_INSTANCE_PROFILE_3_SLICE, ci)
+
+	gi, ci, err = migProfileIDs("1c.3g.20gb")
+	require.NoError(t, err)
+	require.Equal(t, nvml.GPU_INSTANCE_PROFILE_3_SLICE, gi)
+	require.Equal(t, nvml.COMPUTE_INSTANCE_PROFILE_1_SLICE, ci)
+
+	_, _, err = migProfileIDs("5g.20gb")
+	require.ErrorContains(t, err, `unsupported MIG profile "5g.20gb"`)
+	_, _, err = migProfileIDs("5c.3g.20gb")
+	require.ErrorContains(t, err, `unsupported MIG compute profile "5c.3g.20gb"`)
+}
+
+func TestAllocationKeySeparatesComputeInstances(t *testing.T) {
+	placement := nvml.GpuInstancePlacement{Start: 4, Size: 4}
+	first := allocationKey(0, "1c.3g.20gb", placement, migComputePlacement(&device.MigPlacement{Start: 0, Size: 1}))
+	second := allocationKey(0, "1c.3g.20gb", placement, migComputePlacement(&device.MigPlacement{Start: 1, Size: 1}))
+	require.NotEqual(t, first, second, "compute instances sharing a GPU instance need their own keys")
+	require.Equal(t, migAllocationKey{GPUIndex: 0, Profile: "3g.20gb", Start: 4, Size: 4},
+		allocationKey(0, "3g.20gb", placement, migComputePlacement(nil)))
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+// supportsMode reports whether the node's GPUs may run pods in mode.
+func (plugin *NvidiaDevicePlugin) supportsMode(mode string) bool {
+	return mode == plugin.operatingMode || slices.Contains(plugin.operatingModes, mode)
+}
+
+// registeredModes returns the modes a GPU is registered as switchable to, or
+// nil when the node runs every GPU in its operating mode. MIG is only offered
+// on GPUs with MIG profiles.
+func (plugin *NvidiaDevicePlugin) registeredModes(migCapable bool) []string {
+	if len(plugin.operatingModes) < 2 {
+		return nil
+	}
+	modes := make([]string, 0, len(plugin.operatingModes))
+	for _, mode := range plugin.operatingModes {
+		if mode != nvidia.MigMode || migCapable {
+			modes = append(modes, mode)
+		}
+	}
+	return modes
+}
+
+// deviceMode returns the mode the GPU last ran a pod in, or the node's
+// operating mode.
+func (plugin *NvidiaDevicePlugin) deviceMode(uuid string) string {
+	plugin.deviceModesMu.Lock()
+	defer plugin.deviceModesMu.Unlock()
+	if mode, ok := plugin.deviceModes[uuid]; ok {
+		return mode
+	}
+	return plugin.operatingMode
+}
+
+// allocationMode returns the mode the scheduler placed the container's devices
+// in. All devices of a container share the mode of the first one.
+func (plugin *NvidiaDevicePlugin) allocationMode(pod *corev1.Pod, devreq device.ContainerDevices) string {
+	if len(devreq) == 0 {
+		return plugin.operatingMode
+	}
+	if pod != nil {
+		modes, err := nvidia.DecodeDeviceModes(pod.Annotations[nvidia.DeviceModesAnnotation])
+		if err != nil {
+			klog.ErrorS(err, "Ignoring pod device modes", "pod", klog.KObj(pod))
+		}
+		if mode, ok := modes[devreq[0].UUID]; ok {
+			return mode
+		}
+	}
+	return plugin.deviceMode(devreq[0].UUID)
+}
+
+// switchDeviceModes puts the container's GPUs in mode. The scheduler only
+// switches idle GPUs. Entering MIG mode is left to the MIG manager when it
+// creates the instances; leaving it needs the GPU to be free of instances and
+// processes.
+func (plugin *NvidiaDevicePlugin) switchDeviceModes(devreq device.ContainerDevices, mode string) error {
+	if mode != nvidia.MigMode && plugin.migMgr != nil {
+		var leaving []string
+		for _, dev := range devreq {
+			if plugin.deviceMode(dev.UUID) == nvidia.MigMode {
+				leaving = append(leaving, dev.UUID)
+			}
+		}
+		if len(leaving) > 0 {
+			if err := plugin.reconcileActiveMigAllocations(); err != nil {
+				return fmt.Errorf("reconcile MIG allocations before leaving MIG mode: %w", err)
+			}
+		}
+		for _, uuid := range leaving {
+			gpuIndex, ok := gpuUUIDToIndex(uuid)
+			if !ok {
+				return fmt.Errorf("resolve GPU %s", uuid)
+			}
+			if err := plugin.migMgr.DisableMigMode(gpuIndex); err != nil {
+				return fmt.Errorf("switch GPU %s to %s mode: %w", uuid, mode, err)
+			}
+		}
+	}
+	if plugin.recordDeviceModes(devreq, mode) {
+		plugin.persistDeviceModes()
+	}
+	return nil
+}
+
+// recordDeviceModes records the container's GPUs as running in mode and
+// reports whether any of them was not already recorded so.
+func (plugin *NvidiaDevicePlugin) recordDeviceModes(devreq device.ContainerDevices, mode string) bool {
+	plugin.deviceModesMu.Lock()
+	defer plugin.deviceModesMu.Unlock()
+	if plugin.deviceModes == nil {
+		plugin.deviceModes = make(map[string]string)
+	}
+	changed := false
+	for _, dev := range devreq {
+		previous, ok := plugin.deviceModes[dev.UUID]
+		if ok && previous == mode {
+			continue
+		}
+		if ok {
+			klog.InfoS("Switched GPU isolation mode", "uuid", dev.UUID, "from", previous, "to", mode)
+		}
+		plugin.deviceModes[dev.UUID] = mode
+		changed = true
+	}
+	return changed
+}
+
+// persistDeviceModes records the GPU modes on the node. Failures are only
+// logged: the modes stay in effect until the plugin restarts.
+func (plugin *NvidiaDevicePlugin) persistDeviceModes() {
+	nodeName := util.NodeName
+	if client.GetClient() == nil || nodeName == "" {
+		return
+	}
+	plugin.deviceModesMu.Lock()
+	data, err := json.Marshal(plugin.deviceModes)
+	plugin.deviceModesMu.Unlock()
+	if err != nil {
+		klog.ErrorS(err, "Failed to encode GPU modes")
+		return
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	if err := util.PatchNodeAnnotations(node, map[string]string{nvidia.NodeDeviceModesAnnos: string(data)}); err != nil {
+		klog.ErrorS(err, "Failed to record GPU modes", "node", nodeName)
+	}
+}
+
+// restoreDeviceModes returns the GPU modes a previous run of the plugin
+// recorded on the node, dropping those the node may no longer run in.
+func restoreDeviceModes(nodeName string, modes []string) map[string]string {
+	if client.GetClient() == nil || nodeName == "" {
+		return nil
+	}
+	node, err := util.GetNode(nodeName)
+	if err != nil {
+		klog.ErrorS(err, "Failed to read recorded GPU modes", "node", nodeName)
+		return nil
+	}
+	recorded := make(map[string]string)
+	if raw := node.Annotations[nvidia.NodeDeviceModesAnnos]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &recorded); err != nil {
+			klog.ErrorS(err, "Ignoring recorded GPU modes", "node", nodeName)
+			return nil
+		}
+	}
+	for uuid, mode := range recorded {
+		if !slices.Contains(modes, mode) {
+			klog.InfoS("Dropping recorded GPU mode the node no longer supports", "uuid", uuid, "mode", mode)
+			delete(recorded, uuid)
+		}
+	}
+	return recorded
+}
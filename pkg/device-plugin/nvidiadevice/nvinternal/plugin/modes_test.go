@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func TestResolveNodeConfigOperatingModes(t *testing.T) {
+	configs := nvidia.DevicePluginConfigs{Nodeconfig: []nvidia.NodeConfig{
+		{Name: "gpu-node-1", OperatingMode: nvidia.MpsMode, OperatingModes: []string{nvidia.HamiCoreMode, nvidia.ExclusiveMode}},
+	}}
+	eff, err := resolveNodeConfig(&nvidia.NvidiaConfig{}, configs, "gpu-node-1", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{nvidia.MpsMode, nvidia.HamiCoreMode, nvidia.ExclusiveMode}, eff.modes())
+
+	eff, err = resolveNodeConfig(&nvidia.NvidiaConfig{}, configs, "gpu-node-2", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{nvidia.HamiCoreMode}, eff.modes())
+
+	configs.Nodeconfig[0].OperatingModes = []string{nvidia.HamiCoreMode, nvidia.MpsMode}
+	_, err = resolveNodeConfig(&nvidia.NvidiaConfig{}, configs, "gpu-node-1", nil)
+	require.ErrorContains(t, err, "no MPS control daemon is managed")
+
+	configs.Nodeconfig[0].OperatingModes = []string{"turbo"}
+	_, err = resolveNodeConfig(&nvidia.NvidiaConfig{}, configs, "gpu-node-1", nil)
+	require.ErrorContains(t, err, `gpu-node-1: unknown operating mode "turbo"`)
+}
+
+func TestRegisteredModes(t *testing.T) {
+	plugin := &NvidiaDevicePlugin{operatingMode: nvidia.HamiCoreMode, operatingModes: []string{nvidia.HamiCoreMode}}
+	require.Nil(t, plugin.registeredModes(true), "single-mode nodes do not advertise modes")
+
+	plugin.operatingModes = []string{nvidia.HamiCoreMode, nvidia.MigMode, nvidia.MpsMode}
+	require.Equal(t, []string{nvidia.HamiCoreMode, nvidia.MigMode, nvidia.MpsMode}, plugin.registeredModes(true))
+	require.Equal(t, []string{nvidia.HamiCoreMode, nvidia.MpsMode}, plugin.registeredModes(false))
+	require.True(t, plugin.supportsMode(nvidia.MpsMode))
+	require.False(t, plugin.supportsMode(nvidia.ExclusiveMode))
+}
+
+func TestAllocationMode(t *testing.T) {
+	plugin := &NvidiaDevicePlugin{operatingMode: nvidia.HamiCoreMode}
+	devreq := device.ContainerDevices{{UUID: "GPU-a"}}
+	require.Equal(t, nvidia.HamiCoreMode, plugin.allocationMode(&corev1.Pod{}, devreq))
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		nvidia.DeviceModesAnnotation: `{"GPU-a":"exclusive"}`,
+	}}}
+	require.Equal(t, nvidia.ExclusiveMode, plugin.allocationMode(pod, devreq))
+
+	require.NoError(t, plugin.switchDeviceModes(device.ContainerDevices{{UUID: "GPU-b"}}, nvidia.ExclusiveMode))
+	require.Equal(t, nvidia.ExclusiveMode, plugin.allocationMode(pod, device.ContainerDevices{{UUID: "GPU-b"}}), "devices the pod does not name keep their last mode")
+	require.Equal(t, nvidia.HamiCoreMode, plugin.deviceMode("GPU-c"))
+}
+
+func TestDeviceModesSurviveRestart(t *testing.T) {
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	defer func() { client.KubeClient = previousKubeClient }()
+	previousNodeName := util.NodeName
+	util.NodeName = "node1"
+	defer func() { util.NodeName = previousNodeName }()
+
+	modes := []string{nvidia.HamiCoreMode, nvidia.ExclusiveMode, nvidia.MigMode}
+	plugin := &NvidiaDevicePlugin{operatingMode: nvidia.HamiCoreMode, operatingModes: modes, migMgr: NewMigInstanceManager()}
+	// Leaving modes other than MIG neither lists pods nor touches MIG.
+	require.NoError(t, plugin.switchDeviceModes(device.ContainerDevices{{UUID: "GPU-a"}}, nvidia.ExclusiveMode))
+	require.NoError(t, plugin.switchDeviceModes(device.ContainerDevices{{UUID: "GPU-a"}}, nvidia.HamiCoreMode))
+	require.NoError(t, plugin.switchDeviceModes(device.ContainerDevices{{UUID: "GPU-b"}}, nvidia.ExclusiveMode))
+	for _, action := range client.KubeClient.(*fake.Clientset).Actions() {
+		require.NotEqual(t, "pods", action.GetResource().Resource, "switching modes outside MIG listed pods")
+	}
+
+	node, err := client.KubeClient.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"GPU-a":"hami-core","GPU-b":"exclusive"}`, node.Annotations[nvidia.NodeDeviceModesAnnos])
+
+	restored := &NvidiaDevicePlugin{operatingMode: nvidia.HamiCoreMode, deviceModes: restoreDeviceModes("node1", modes)}
+	require.Equal(t, nvidia.ExclusiveMode, restored.deviceMode("GPU-b"))
+	require.Equal(t, nvidia.HamiCoreMode, restored.deviceMode("GPU-a"))
+	require.Empty(t, restoreDeviceModes("node1", []string{nvidia.HamiCoreMode})["GPU-b"], "modes the node no longer runs are dropped")
+}
+
+func TestAllocateExclusiveModeSkipsHamiCore(t *testing.T) {
+	deviceListStrategies, _ := v1.NewDeviceListStrategies([]string{"envvar"})
+	deviceIDStrategy := v1.DeviceIDStrategyUUID
+	memScale := 1.0
+	logLevel := nvidia.Error
+
+	plugin := &NvidiaDevicePlugin{
+		config: &nvidia.DeviceConfig{
+			Config: &v1.Config{
+				Flags: v1.Flags{
+					CommandLineFlags: v1.CommandLineFlags{
+						Plugin: &v1.PluginCommandLineFlags{
+							DeviceIDStrategy: &deviceIDStrategy,
+						},
+					},
+				},
+			},
+		},
+		deviceListStrategies: deviceListStrategies,
+		operatingMode:        nvidia.HamiCoreMode,
+		operatingModes:       []string{nvidia.HamiCoreMode, nvidia.ExclusiveMode},
+		schedulerConfig: nvidia.NvidiaConfig{
+			NodeDefaultConfig: nvidia.NodeDefaultConfig{
+				DeviceMemoryScaling: &memScale,
+				LogLevel:            &logLevel,
+			},
+		},
+	}
+
+	previousInRequestDevice := device.InRequestDevices[nvidia.NvidiaGPUDevice]
+	device.InRequestDevices[nvidia.NvidiaGPUDevice] = "hami.io/vgpu-devices-to-allocate"
+	defer func() { device.InRequestDevices[nvidia.NvidiaGPUDevice] = previousInRequestDevice }()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "exclusive-pod",
+			Namespace: "default",
+			UID:       "exclusive-pod-uid",
+			Annotations: map[string]string{
+				"hami.io/vgpu-devices-to-allocate": "GPU-03f69c50-207a-2038-9b45-23cac89cb67a,NVIDIA,40000,100:;",
+				nvidia.DeviceModesAnnotation:       `{"GPU-03f69c50-207a-2038-9b45-23cac89cb67a":"exclusive"}`,
+			},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+	}
+
+	previousGetPendingPod := getPendingPod
+	getPendingPod = func(context.Context, string) (*corev1.Pod, error) { return pod, nil }
+	defer func() { getPendingPod = previousGetPendingPod }()
+
+	previousPodAllocationFailed := podAllocationFailed
+	podAllocationFailed = func(string, *corev1.Pod, string) {}
+	defer func() { podAllocationFailed = previousPodAllocationFailed }()
+
+	previousPodAllocationTrySuccess := podAllocationTrySuccess
+	podAllocationTrySuccess = func(string, string, string, *corev1.Pod) {}
+	defer func() { podAllocationTrySuccess = previousPodAllocationTrySuccess }()
+
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(pod)
+	defer func() { client.KubeClient = previousKubeClient }()
+
+	request := &kubeletdevicepluginv1beta1.AllocateRequest{
+		ContainerRequests: []*kubeletdevicepluginv1beta1.ContainerAllocateRequest{{
+			DevicesIds: []string{"GPU-03f69c50-207a-2038-9b45-23cac89cb67a-0"},
+		}},
+	}
+
+	response, err := plugin.Allocate(context.Background(), request)
+	require.NoError(t, err)
+	envs := response.ContainerResponses[0].Envs
+	require.NotContains(t, envs, "CUDA_DEVICE_MEMORY_LIMIT_0", "exclusive containers are not limited by hami-core")
+	require.NotContains(t, envs, "CUDA_DEVICE_SM_LIMIT")
+	require.Empty(t, response.ContainerResponses[0].Mounts)
+	require.Equal(t, nvidia.ExclusiveMode, plugin.deviceMode("GPU-03f69c50-207a-2038-9b45-23cac89cb67a"))
+}
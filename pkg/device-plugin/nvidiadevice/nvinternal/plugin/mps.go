@@ -23,14 +23,21 @@ package plugin
 
 import (
 	"context"
+	"fmt"
 	"os/exec"
+	"strings"
 
 	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
 
 	spec "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
+	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
 )
 
+// mpsMaxClients is the number of client CUDA contexts an MPS server accepts
+// per device on Volta and later GPUs.
+const mpsMaxClients = 48
+
 // tailer tails the contents of a file.
 type tailer struct {
 	filename string
@@ -67,3 +74,25 @@ func (m *mpsOptions) waitForDaemon() error {
 func (m *mpsOptions) updateResponse(response *kubeletdevicepluginv1beta1.ContainerAllocateResponse) {
 	return
 }
+
+// mpsLimitEnvs returns the MPS client controls enforcing the memory and core
+// shares the scheduler assigned to a container. Devices are numbered as the
+// container sees them, in allocation order. A share of 0 sets no limit.
+func mpsLimitEnvs(devreq device.ContainerDevices) map[string]string {
+	envs := make(map[string]string)
+	var memLimits []string
+	for i, dev := range devreq {
+		if dev.Usedmem > 0 {
+			memLimits = append(memLimits, fmt.Sprintf("%d=%dM", i, dev.Usedmem))
+		}
+	}
+	if len(memLimits) > 0 {
+		envs["CUDA_MPS_PINNED_DEVICE_MEM_LIMIT"] = strings.Join(memLimits, ",")
+	}
+	// The thread percentage applies to every device of the client, like
+	// CUDA_DEVICE_SM_LIMIT does for hami-core.
+	if len(devreq) > 0 && devreq[0].Usedcores > 0 && devreq[0].Usedcores < 100 {
+		envs["CUDA_MPS_ACTIVE_THREAD_PERCENTAGE"] = fmt.Sprint(devreq[0].Usedcores)
+	}
+	return envs
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func TestMpsLimitEnvs(t *testing.T) {
+	tests := []struct {
+		name   string
+		devreq device.ContainerDevices
+		want   map[string]string
+	}{
+		{
+			name:   "memory and cores",
+			devreq: device.ContainerDevices{{UUID: "GPU-a", Usedmem: 3000, Usedcores: 30}},
+			want: map[string]string{
+				"CUDA_MPS_PINNED_DEVICE_MEM_LIMIT":  "0=3000M",
+				"CUDA_MPS_ACTIVE_THREAD_PERCENTAGE": "30",
+			},
+		},
+		{
+			name: "devices are numbered as the container sees them",
+			devreq: device.ContainerDevices{
+				{UUID: "GPU-b", Idx: 3, Usedmem: 2000, Usedcores: 50},
+				{UUID: "GPU-a", Idx: 1, Usedmem: 1000, Usedcores: 50},
+			},
+			want: map[string]string{
+				"CUDA_MPS_PINNED_DEVICE_MEM_LIMIT":  "0=2000M,1=1000M",
+				"CUDA_MPS_ACTIVE_THREAD_PERCENTAGE": "50",
+			},
+		},
+		{
+			name:   "no shares set no limits",
+			devreq: device.ContainerDevices{{UUID: "GPU-a"}},
+			want:   map[string]string{},
+		},
+		{
+			name:   "a whole device sets no thread limit",
+			devreq: device.ContainerDevices{{UUID: "GPU-a", Usedmem: 1000, Usedcores: 100}},
+			want:   map[string]string{"CUDA_MPS_PINNED_DEVICE_MEM_LIMIT": "0=1000M"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, mpsLimitEnvs(test.devreq))
+		})
+	}
+}
+
+func TestRegisteredCapacity(t *testing.T) {
+	memScale, coreScale, splitCount := 2.0, 1.5, uint(60)
+	plugin := &NvidiaDevicePlugin{
+		schedulerConfig: nvidia.NvidiaConfig{
+			NodeDefaultConfig: nvidia.NodeDefaultConfig{
+				DeviceMemoryScaling: &memScale,
+				DeviceCoreScaling:   &coreScale,
+				DeviceSplitCount:    &splitCount,
+			},
+		},
+	}
+	tests := []struct {
+		mode                string
+		mem, cores, clients int32
+	}{
+		{mode: nvidia.HamiCoreMode, mem: 32000, cores: 150, clients: 60},
+		{mode: nvidia.MigMode, mem: 16000, cores: 100, clients: 60},
+		{mode: nvidia.MpsMode, mem: 16000, cores: 100, clients: mpsMaxClients},
+		{mode: nvidia.ExclusiveMode, mem: 16000, cores: 100, clients: 60},
+	}
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			mem, cores, clients := plugin.registeredCapacity(test.mode, 16000)
+			require.Equal(t, test.mem, mem)
+			require.Equal(t, test.cores, cores)
+			require.Equal(t, test.clients, clients)
+		})
+	}
+}
+
+func TestAllocateSetsMPSClientLimits(t *testing.T) {
+	deviceListStrategies, _ := v1.NewDeviceListStrategies([]string{"envvar"})
+	deviceIDStrategy := v1.DeviceIDStrategyUUID
+	memScale := 1.0
+	logLevel := nvidia.Error
+
+	plugin := &NvidiaDevicePlugin{
+		config: &nvidia.DeviceConfig{
+			Config: &v1.Config{
+				Flags: v1.Flags{
+					CommandLineFlags: v1.CommandLineFlags{
+						Plugin: &v1.PluginCommandLineFlags{
+							DeviceIDStrategy: &deviceIDStrategy,
+						},
+					},
+				},
+			},
+		},
+		deviceListStrategies: deviceListStrategies,
+		operatingMode:        nvidia.MpsMode,
+		schedulerConfig: nvidia.NvidiaConfig{
+			NodeDefaultConfig: nvidia.NodeDefaultConfig{
+				DeviceMemoryScaling: &memScale,
+				LogLevel:            &logLevel,
+			},
+		},
+	}
+
+	previousInRequestDevice := device.InRequestDevices[nvidia.NvidiaGPUDevice]
+	device.InRequestDevices[nvidia.NvidiaGPUDevice] = "hami.io/vgpu-devices-to-allocate"
+	defer func() { device.InRequestDevices[nvidia.NvidiaGPUDevice] = previousInRequestDevice }()
+
+	previousHostHookPath := hostHookPath
+	hostHookPath = t.TempDir()
+	defer func() { hostHookPath = previousHostHookPath }()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mps-pod",
+			Namespace: "default",
+			UID:       "mps-pod-uid",
+			Annotations: map[string]string{
+				"hami.io/vgpu-devices-to-allocate": "GPU-03f69c50-207a-2038-9b45-23cac89cb67a,NVIDIA,3000,25:;",
+			},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+	}
+
+	previousGetPendingPod := getPendingPod
+	getPendingPod = func(context.Context, string) (*corev1.Pod, error) { return pod, nil }
+	defer func() { getPendingPod = previousGetPendingPod }()
+
+	previousPodAllocationFailed := podAllocationFailed
+	podAllocationFailed = func(string, *corev1.Pod, string) {}
+	defer func() { podAllocationFailed = previousPodAllocationFailed }()
+
+	previousPodAllocationTrySuccess := podAllocationTrySuccess
+	podAllocationTrySuccess = func(string, string, string, *corev1.Pod) {}
+	defer func() { podAllocationTrySuccess = previousPodAllocationTrySuccess }()
+
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(pod)
+	defer func() { client.KubeClient = previousKubeClient }()
+
+	request := &kubeletdevicepluginv1beta1.AllocateRequest{
+		ContainerRequests: []*kubeletdevicepluginv1beta1.ContainerAllocateRequest{{
+			DevicesIds: []string{"GPU-03f69c50-207a-2038-9b45-23cac89cb67a-0"},
+		}},
+	}
+
+	response, err := plugin.Allocate(context.Background(), request)
+	require.NoError(t, err)
+	envs := response.ContainerResponses[0].Envs
+	require.Equal(t, "0=3000M", envs["CUDA_MPS_PINNED_DEVICE_MEM_LIMIT"])
+	require.Equal(t, "25", envs["CUDA_MPS_ACTIVE_THREAD_PERCENTAGE"])
+	require.Equal(t, "3000m", envs["CUDA_DEVICE_MEMORY_LIMIT_0"], "hami-core keeps limiting MPS clients")
+	require.Equal(t, "25", envs["CUDA_DEVICE_SM_LIMIT"])
+	require.True(t, hasLdSoPreloadMount(response.ContainerResponses[0].Mounts))
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/imdario/mergo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+// effectiveNodeConfig is the device plugin config resolved for this node. It
+// is reported in the nvidia.NodeConfigAnnos node annotation for debugging.
+type effectiveNodeConfig struct {
+	nvidia.NodeDefaultConfig
+	OperatingMode                string               `json:"operatingmode"`
+	OperatingModes               []string             `json:"operatingmodes,omitempty"`
+	FilterDevice                 *nvidia.FilterDevice `json:"filterdevices,omitempty"`
+	EnableGetPreferredAllocation bool                 `json:"enablegetpreferredallocation"`
+	// MatchedEntries lists the nodeconfig entries that were applied, in merge order.
+	MatchedEntries []string `json:"matchedentries"`
+}
+
+var getNodeLabels = func(nodeName string) (map[string]string, error) {
+	node, err := util.GetNode(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return node.Labels, nil
+}
+
+func hasNodeSelector(entries []nvidia.NodeConfig) bool {
+	for _, entry := range entries {
+		if entry.NodeSelector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeConfigEntryName(i int, entry nvidia.NodeConfig) string {
+	if entry.Name != "" {
+		return entry.Name
+	}
+	return fmt.Sprintf("nodeconfig[%d]", i)
+}
+
+// matchNodeConfigs returns the indexes of the entries that apply to the node,
+// in merge order: entries selected by labels only first, then entries naming
+// the node, each group in file order.
+func matchNodeConfigs(entries []nvidia.NodeConfig, nodeName string, nodeLabels map[string]string) ([]int, error) {
+	var bySelector, byName []int
+	for i, entry := range entries {
+		if entry.Name == "" && entry.NodeSelector == nil {
+			continue
+		}
+		if entry.Name != "" && entry.Name != nodeName {
+			continue
+		}
+		if entry.NodeSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(entry.NodeSelector)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid nodeselector: %w", nodeConfigEntryName(i, entry), err)
+			}
+			if !selector.Matches(labels.Set(nodeLabels)) {
+				continue
+			}
+		}
+		if entry.Name != "" {
+			byName = append(byName, i)
+		} else {
+			bySelector = append(bySelector, i)
+		}
+	}
+	return append(bySelector, byName...), nil
+}
+
+// resolveNodeConfig merges the entries matching the node onto sConfig's
+// NodeDefaultConfig, later entries overriding earlier ones field by field.
+func resolveNodeConfig(sConfig *nvidia.NvidiaConfig, deviceConfigs nvidia.DevicePluginConfigs, nodeName string, nodeLabels map[string]string) (*effectiveNodeConfig, error) {
+	matched, err := matchNodeConfigs(deviceConfigs.Nodeconfig, nodeName, nodeLabels)
+	if err != nil {
+		return nil, err
+	}
+	eff := &effectiveNodeConfig{OperatingMode: nvidia.HamiCoreMode, MatchedEntries: []string{}}
+	for _, i := range matched {
+		val := deviceConfigs.Nodeconfig[i]
+		name := nodeConfigEntryName(i, val)
+		klog.Infof("Reading config from file %s", name)
+		if err := mergo.Merge(&sConfig.NodeDefaultConfig, val.NodeDefaultConfig, mergo.WithOverride); err != nil {
+			return nil, err
+		}
+		if val.FilterDevice != nil && (len(val.FilterDevice.UUID) > 0 || len(val.FilterDevice.Index) > 0) {
+			eff.FilterDevice = val.FilterDevice
+		}
+		if len(val.OperatingMode) > 0 {
+			eff.OperatingMode = val.OperatingMode
+		}
+		if len(val.OperatingModes) > 0 {
+			if err := nvidia.ValidateModes(val.OperatingModes); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			// Switching a GPU to MPS needs an MPS control daemon for it, which
+			// the plugin does not manage yet.
+			if slices.Contains(val.OperatingModes, nvidia.MpsMode) {
+				return nil, fmt.Errorf("%s: GPUs cannot switch to %s mode, no MPS control daemon is managed for them", name, nvidia.MpsMode)
+			}
+			eff.OperatingModes = val.OperatingModes
+		}
+		// A bool cannot tell "unset" from "false", so any matching entry
+		// enabling it wins.
+		if val.EnableGetPreferredAllocation {
+			eff.EnableGetPreferredAllocation = true
+		}
+		eff.MatchedEntries = append(eff.MatchedEntries, name)
+	}
+	eff.NodeDefaultConfig = sConfig.NodeDefaultConfig
+	return eff, nil
+}
+
+// modes returns the modes the node's GPUs may run in, OperatingMode first.
+func (eff *effectiveNodeConfig) modes() []string {
+	modes := []string{eff.OperatingMode}
+	for _, mode := range eff.OperatingModes {
+		if !slices.Contains(modes, mode) {
+			modes = append(modes, mode)
+		}
+	}
+	return modes
+}
+
+// reportNodeConfig records the effective config on the node. Failures are only
+// logged, the annotation is informational.
+func reportNodeConfig(nodeName string, eff *effectiveNodeConfig) {
+	if client.GetClient() == nil || nodeName == "" {
+		return
+	}
+	data, err := json.Marshal(eff)
+	if err != nil {
+		klog.ErrorS(err, "Failed to encode effective node config")
+		return
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	if err := util.PatchNodeAnnotations(node, map[string]string{nvidia.NodeConfigAnnos: string(data)}); err != nil {
+		klog.ErrorS(err, "Failed to report effective node config", "node", nodeName)
+	}
+}
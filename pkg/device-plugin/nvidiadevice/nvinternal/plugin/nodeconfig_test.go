@@ -0,0 +1,124 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+const labelNodeConfigs = `{
+  "nodeconfig": [
+    {"name": "gpu-node-7", "devicesplitcount": 4},
+    {"nodeselector": {"matchLabels": {"node.kubernetes.io/instance-type": "p4d.24xlarge"}}, "devicesplitcount": 8, "devicememoryscaling": 1.5, "operatingmode": "mig"},
+    {"nodeselector": {"matchExpressions": [{"key": "pool", "operator": "In", "values": ["training", "batch"]}]}, "devicesplitcount": 2, "enablegetpreferredallocation": true},
+    {"name": "other-node", "devicesplitcount": 1},
+    {"nodeselector": {}, "devicecorescaling": 2}
+  ]
+}`
+
+func TestMatchNodeConfigs(t *testing.T) {
+	var configs nvidia.DevicePluginConfigs
+	require.NoError(t, json.Unmarshal([]byte(labelNodeConfigs), &configs))
+
+	tests := []struct {
+		name     string
+		nodeName string
+		labels   map[string]string
+		want     []int
+	}{
+		{
+			name:     "catch-all selector only",
+			nodeName: "plain",
+			want:     []int{4},
+		},
+		{
+			name:     "selectors in file order, name last",
+			nodeName: "gpu-node-7",
+			labels:   map[string]string{"node.kubernetes.io/instance-type": "p4d.24xlarge", "pool": "batch"},
+			want:     []int{1, 2, 4, 0},
+		},
+		{
+			name:     "match expressions",
+			nodeName: "gpu-node-8",
+			labels:   map[string]string{"pool": "training"},
+			want:     []int{2, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchNodeConfigs(configs.Nodeconfig, tt.nodeName, tt.labels)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := matchNodeConfigs([]nvidia.NodeConfig{{
+		NodeSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "pool", Operator: "Bogus"}}},
+	}}, "n", nil)
+	require.ErrorContains(t, err, "nodeconfig[0]: invalid nodeselector")
+}
+
+func TestReadFromConfigFileWithNodeSelector(t *testing.T) {
+	t.Setenv("NODE_NAME", "gpu-node-7")
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "gpu-node-7",
+		Labels: map[string]string{"node.kubernetes.io/instance-type": "p4d.24xlarge", "pool": "batch"},
+	}}
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(node)
+	defer func() { client.KubeClient = previousKubeClient }()
+	previousEnable := enableGetPreferredAllocation
+	defer func() { enableGetPreferredAllocation = previousEnable }()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(labelNodeConfigs), 0o644))
+	nvconfig := nvidia.NvidiaConfig{
+		NodeDefaultConfig: nvidia.NodeDefaultConfig{
+			DeviceSplitCount:    ptr(uint(10)),
+			DeviceMemoryScaling: ptr(1.0),
+			DeviceCoreScaling:   ptr(1.0),
+		},
+	}
+	eff, err := readFromConfigFile(&nvconfig, path)
+	require.NoError(t, err)
+
+	require.Equal(t, uint(4), *nvconfig.DeviceSplitCount, "the name entry has the highest precedence")
+	require.Equal(t, 1.5, *nvconfig.DeviceMemoryScaling, "fields the name entry leaves unset come from selector entries")
+	require.Equal(t, 2.0, *nvconfig.DeviceCoreScaling)
+	require.Equal(t, "mig", eff.OperatingMode)
+	require.True(t, enableGetPreferredAllocation)
+	require.Equal(t, []string{"nodeconfig[1]", "nodeconfig[2]", "nodeconfig[4]", "gpu-node-7"}, eff.MatchedEntries)
+
+	reportNodeConfig("gpu-node-7", eff)
+	updated, err := client.KubeClient.CoreV1().Nodes().Get(context.Background(), "gpu-node-7", metav1.GetOptions{})
+	require.NoError(t, err)
+	var reported effectiveNodeConfig
+	require.NoError(t, json.Unmarshal([]byte(updated.Annotations[nvidia.NodeConfigAnnos]), &reported))
+	require.Equal(t, *eff, reported)
+}
@@ -0,0 +1,251 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+	podresourcesv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+// PodResourcesSocket is the kubelet PodResources API socket used to tell
+// which pod an Allocate call is for. When empty, Allocate relies on the
+// pending-pod lookup alone and handles one pod at a time.
+var PodResourcesSocket *string
+
+const podResourcesTimeout = 5 * time.Second
+
+var (
+	podResourcesOnce   sync.Once
+	podResourcesClient podresourcesv1.PodResourcesListerClient
+)
+
+// checkConcurrentAllocations refuses to start with several pods allocating on
+// the node at once unless the PodResources API can tell them apart; the
+// pending pod lookup alone would hand one pod's devices to another.
+func checkConcurrentAllocations(limit int32, client podresourcesv1.PodResourcesListerClient) error {
+	if limit > 1 && client == nil {
+		return fmt.Errorf("concurrentAllocations is %d, which requires --pod-resources-socket to tell allocating pods apart", limit)
+	}
+	return nil
+}
+
+// kubeletPodResources returns the client of the PodResources API, or nil when
+// it is not configured. The connection is shared by all plugins and survives
+// their restarts.
+func kubeletPodResources() podresourcesv1.PodResourcesListerClient {
+	podResourcesOnce.Do(func() {
+		if PodResourcesSocket == nil || *PodResourcesSocket == "" {
+			return
+		}
+		conn, err := grpc.NewClient("unix://"+*PodResourcesSocket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			klog.ErrorS(err, "Failed to create kubelet PodResources client, falling back to pending pod lookup", "socket", *PodResourcesSocket)
+			return
+		}
+		podResourcesClient = podresourcesv1.NewPodResourcesListerClient(conn)
+	})
+	return podResourcesClient
+}
+
+// lockAllocatingPod finds the pod an Allocate request is for and serialises
+// its allocation. A pod told apart through the PodResources API only excludes
+// other Allocate calls for the same pod, so several pods on the node allocate
+// concurrently. Otherwise, and always in MIG mode whose GI/CI preparation is
+// node-global, the pending-pod lookup and the allocation run under applyMutex.
+func (plugin *NvidiaDevicePlugin) lockAllocatingPod(ctx context.Context, nodename string, reqs *kubeletdevicepluginv1beta1.AllocateRequest) (*corev1.Pod, func(), error) {
+	if plugin.podResources != nil && !plugin.supportsMode(nvidia.MigMode) && len(reqs.ContainerRequests) > 0 {
+		first := reqs.ContainerRequests[0]
+		var deviceIDs []string
+		if enableGetPreferredAllocation {
+			// Only then does the kubelet pick the devices of the annotation.
+			deviceIDs = first.DevicesIds
+		}
+		current, err := plugin.resolveAllocatingPod(ctx, nodename, len(first.DevicesIds), deviceIDs)
+		if err != nil {
+			klog.ErrorS(err, "Failed to resolve allocating pod through the kubelet, falling back to pending pod lookup")
+		}
+		if current != nil {
+			return current, plugin.podLocks.lock(string(current.UID)), nil
+		}
+	}
+	plugin.applyMutex.Lock()
+	current, err := getPendingPod(ctx, nodename)
+	if err != nil {
+		plugin.applyMutex.Unlock()
+		return nil, nil, err
+	}
+	unlockPod := plugin.podLocks.lock(string(current.UID))
+	return current, func() {
+		unlockPod()
+		plugin.applyMutex.Unlock()
+	}, nil
+}
+
+// resolveAllocatingPod returns the binding pod on nodename whose next
+// container waiting for devices needs size of them, or nil if that is not
+// exactly one pod. The kubelet admits pods one at a time and only lists the
+// devices of containers it already allocated, so the pod being admitted is
+// the one it knows about that still has containers without devices. When
+// deviceIDs is set, the annotated devices must also be the ones requested.
+func (plugin *NvidiaDevicePlugin) resolveAllocatingPod(ctx context.Context, nodename string, size int, deviceIDs []string) (*corev1.Pod, error) {
+	ctx, cancel := context.WithTimeout(ctx, podResourcesTimeout)
+	defer cancel()
+	resp, err := plugin.podResources.List(ctx, &podresourcesv1.ListPodResourcesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list kubelet pod resources: %w", err)
+	}
+	resourceName := string(plugin.rm.Resource())
+	kubeletPods := make(map[string]map[string]bool, len(resp.GetPodResources()))
+	for _, pr := range resp.GetPodResources() {
+		allocated := make(map[string]bool, len(pr.GetContainers()))
+		for _, ctr := range pr.GetContainers() {
+			for _, dev := range ctr.GetDevices() {
+				if dev.GetResourceName() == resourceName && len(dev.GetDeviceIds()) > 0 {
+					allocated[ctr.GetName()] = true
+				}
+			}
+		}
+		kubeletPods[pr.GetNamespace()+"/"+pr.GetName()] = allocated
+	}
+
+	podList, err := client.GetClient().CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s", nodename),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods on node %s: %w", nodename, err)
+	}
+	var matches []*corev1.Pod
+	for i := range podList.Items {
+		p := &podList.Items[i]
+		if !util.IsBindingPod(p, nodename) {
+			continue
+		}
+		allocated, ok := kubeletPods[p.Namespace+"/"+p.Name]
+		if !ok || !waitsForDevices(p, corev1.ResourceName(resourceName), allocated) {
+			continue
+		}
+		next := nextContainerDevices(p)
+		if len(next) != size || (len(deviceIDs) > 0 && !sameDevices(next, deviceIDs)) {
+			continue
+		}
+		matches = append(matches, p)
+	}
+	if len(matches) != 1 {
+		klog.V(4).InfoS("No single allocating pod matches the request", "node", nodename, "size", size, "candidates", len(matches))
+		return nil, nil
+	}
+	return matches[0], nil
+}
+
+// waitsForDevices reports whether a container of p requests resourceName but
+// has no devices of it in the kubelet's allocation yet.
+func waitsForDevices(p *corev1.Pod, resourceName corev1.ResourceName, allocated map[string]bool) bool {
+	for _, ctrs := range [][]corev1.Container{p.Spec.InitContainers, p.Spec.Containers} {
+		for _, ctr := range ctrs {
+			quantity, ok := ctr.Resources.Limits[resourceName]
+			if !ok {
+				quantity, ok = ctr.Resources.Requests[resourceName]
+			}
+			if ok && !quantity.IsZero() && !allocated[ctr.Name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nextContainerDevices returns the devices the next Allocate call for p hands
+// out, without consuming them.
+func nextContainerDevices(p *corev1.Pod) device.ContainerDevices {
+	podSingleDev, err := decodePodSingleDevice(nvidia.NvidiaGPUDevice, p)
+	if err != nil {
+		return nil
+	}
+	for _, ctrDevs := range podSingleDev {
+		if len(ctrDevs) > 0 {
+			return ctrDevs
+		}
+	}
+	return nil
+}
+
+// sameDevices reports whether the kubelet device IDs are replicas of exactly
+// the annotated devices.
+func sameDevices(devs device.ContainerDevices, deviceIDs []string) bool {
+	if len(devs) != len(deviceIDs) {
+		return false
+	}
+	remaining := make(map[string]int, len(devs))
+	for _, dev := range devs {
+		remaining[dev.UUID]++
+	}
+	for _, id := range deviceIDs {
+		physicalID := physicalDeviceID(id)
+		if remaining[physicalID] == 0 {
+			return false
+		}
+		remaining[physicalID]--
+	}
+	return true
+}
+
+// keyedMutex hands out one mutex per key and forgets it once nobody holds or
+// waits for it.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*refMutex
+}
+
+type refMutex struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks the mutex of key and returns the function that unlocks it.
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*refMutex)
+	}
+	m, ok := k.locks[key]
+	if !ok {
+		m = &refMutex{}
+		k.locks[key] = m
+	}
+	m.refs++
+	k.mu.Unlock()
+
+	m.Lock()
+	return func() {
+		m.Unlock()
+		k.mu.Lock()
+		m.refs--
+		if m.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+	podresourcesv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+type fakePodResources struct {
+	podresourcesv1.PodResourcesListerClient
+	pods []*podresourcesv1.PodResources
+	err  error
+}
+
+func (f *fakePodResources) List(context.Context, *podresourcesv1.ListPodResourcesRequest, ...grpc.CallOption) (*podresourcesv1.ListPodResourcesResponse, error) {
+	return &podresourcesv1.ListPodResourcesResponse{PodResources: f.pods}, f.err
+}
+
+// kubeletPod lists name as the kubelet does, with the devices of the
+// containers it already allocated.
+func kubeletPod(name string, allocated map[string][]string) *podresourcesv1.PodResources {
+	pr := &podresourcesv1.PodResources{Name: name, Namespace: "default"}
+	for ctr, ids := range allocated {
+		cr := &podresourcesv1.ContainerResources{Name: ctr}
+		if len(ids) > 0 {
+			cr.Devices = []*podresourcesv1.ContainerDevices{{ResourceName: "nvidia.com/gpu", DeviceIds: ids}}
+		}
+		pr.Containers = append(pr.Containers, cr)
+	}
+	return pr
+}
+
+func bindingPod(name, uuid string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       k8stypes.UID("uid-" + name),
+			Annotations: map[string]string{
+				util.AssignedNodeAnnotations:       "node-a",
+				util.BindTimeAnnotations:           "1700000000",
+				util.DeviceBindPhase:               util.DeviceBindAllocating,
+				"hami.io/vgpu-devices-to-allocate": uuid + ",NVIDIA,3000,50:;",
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-a",
+			Containers: []corev1.Container{{
+				Name: "main",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"nvidia.com/gpu": resource.MustParse("1"),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+func setupPodResourcesTest(t *testing.T, pods ...*corev1.Pod) {
+	t.Helper()
+	previousInRequestDevice := device.InRequestDevices[nvidia.NvidiaGPUDevice]
+	device.InRequestDevices[nvidia.NvidiaGPUDevice] = "hami.io/vgpu-devices-to-allocate"
+	t.Cleanup(func() { device.InRequestDevices[nvidia.NvidiaGPUDevice] = previousInRequestDevice })
+
+	objects := make([]runtime.Object, 0, len(pods))
+	for _, pod := range pods {
+		objects = append(objects, pod)
+	}
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(objects...)
+	t.Cleanup(func() { client.KubeClient = previousKubeClient })
+}
+
+func TestResolveAllocatingPod(t *testing.T) {
+	admitted := bindingPod("admitted", "GPU-a")
+	queued := bindingPod("queued", "GPU-b")
+	done := bindingPod("done", "GPU-a")
+	setupPodResourcesTest(t, admitted, queued, done)
+
+	lister := &fakePodResources{}
+	plugin := &NvidiaDevicePlugin{
+		rm:           &rm.ResourceManagerMock{ResourceFunc: func() v1.ResourceName { return "nvidia.com/gpu" }},
+		podResources: lister,
+	}
+	ctx := context.Background()
+
+	// Only the pod the kubelet is admitting has a container without devices.
+	lister.pods = []*podresourcesv1.PodResources{
+		kubeletPod("admitted", map[string][]string{"main": nil}),
+		kubeletPod("done", map[string][]string{"main": {"GPU-a-0"}}),
+	}
+	got, err := plugin.resolveAllocatingPod(ctx, "node-a", 1, nil)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.Equal(t, "admitted", got.Name)
+
+	// Two pods waiting for a device cannot be told apart by count alone...
+	lister.pods = append(lister.pods, kubeletPod("queued", map[string][]string{"main": nil}))
+	got, err = plugin.resolveAllocatingPod(ctx, "node-a", 1, nil)
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	// ...but by the devices the kubelet picked from the preferred allocation.
+	got, err = plugin.resolveAllocatingPod(ctx, "node-a", 1, []string{"GPU-b::1"})
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.Equal(t, "queued", got.Name)
+
+	got, err = plugin.resolveAllocatingPod(ctx, "node-a", 2, nil)
+	require.NoError(t, err)
+	require.Nil(t, got, "no pod waits for two devices")
+
+	lister.err = errors.New("connection refused")
+	_, err = plugin.resolveAllocatingPod(ctx, "node-a", 1, nil)
+	require.Error(t, err)
+}
+
+func TestAllocateResolvesPodThroughKubelet(t *testing.T) {
+	deviceListStrategies, _ := v1.NewDeviceListStrategies([]string{"envvar"})
+	deviceIDStrategy := v1.DeviceIDStrategyUUID
+	memScale := 1.0
+	logLevel := nvidia.Error
+
+	admitted := bindingPod("admitted", "GPU-a")
+	admitted.Annotations["hami.io/vgpu-devices-to-allocate"] = "GPU-a,NVIDIA,2000,30:;"
+	lockOwner := bindingPod("lock-owner", "GPU-a")
+	setupPodResourcesTest(t, admitted, lockOwner)
+
+	plugin := &NvidiaDevicePlugin{
+		rm: &rm.ResourceManagerMock{ResourceFunc: func() v1.ResourceName { return "nvidia.com/gpu" }},
+		config: &nvidia.DeviceConfig{
+			Config: &v1.Config{
+				Flags: v1.Flags{
+					CommandLineFlags: v1.CommandLineFlags{
+						Plugin: &v1.PluginCommandLineFlags{DeviceIDStrategy: &deviceIDStrategy},
+					},
+				},
+			},
+		},
+		deviceListStrategies: deviceListStrategies,
+		schedulerConfig: nvidia.NvidiaConfig{
+			NodeDefaultConfig: nvidia.NodeDefaultConfig{DeviceMemoryScaling: &memScale, LogLevel: &logLevel},
+		},
+		podResources: &fakePodResources{pods: []*podresourcesv1.PodResources{
+			kubeletPod("admitted", map[string][]string{"main": nil}),
+		}},
+	}
+
+	// The pending-pod lookup would hand out the node lock owner.
+	previousGetPendingPod := getPendingPod
+	getPendingPod = func(context.Context, string) (*corev1.Pod, error) { return lockOwner, nil }
+	t.Cleanup(func() { getPendingPod = previousGetPendingPod })
+	previousPodAllocationTrySuccess := podAllocationTrySuccess
+	var succeeded []string
+	podAllocationTrySuccess = func(_ string, _ string, _ string, pod *corev1.Pod) { succeeded = append(succeeded, pod.Name) }
+	t.Cleanup(func() { podAllocationTrySuccess = previousPodAllocationTrySuccess })
+	t.Setenv(util.NodeNameEnvName, "node-a")
+
+	response, err := plugin.Allocate(context.Background(), &kubeletdevicepluginv1beta1.AllocateRequest{
+		ContainerRequests: []*kubeletdevicepluginv1beta1.ContainerAllocateRequest{{DevicesIds: []string{"GPU-a-0"}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "2000m", response.ContainerResponses[0].Envs["CUDA_DEVICE_MEMORY_LIMIT_0"])
+	require.Equal(t, []string{"admitted"}, succeeded)
+}
+
+func TestCheckConcurrentAllocations(t *testing.T) {
+	require.NoError(t, checkConcurrentAllocations(1, nil))
+	require.NoError(t, checkConcurrentAllocations(4, &fakePodResources{}))
+	require.ErrorContains(t, checkConcurrentAllocations(4, nil), "requires --pod-resources-socket")
+}
+
+func TestKeyedMutex(t *testing.T) {
+	var k keyedMutex
+	unlockA := k.lock("a")
+	// Other keys are not blocked.
+	k.lock("b")()
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		k.lock("a")()
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("the same key was locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	<-acquired
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() { k.lock("c")() })
+	}
+	wg.Wait()
+	require.Empty(t, k.locks)
+}
@@ -86,8 +86,33 @@ func GetNumaNode(d nvml.Device) (bool, int, error) {
 	return true, node, nil
 }
 
+// registeredCapacity returns the memory in MiB, cores and number of tasks a
+// device with memMiB of memory running in mode is registered with. Only
+// hami-core enforces scaled limits: the MIG profiles and MPS pinned memory and
+// thread limits are physical and an exclusive pod gets the whole device, so
+// those modes register the device as it is, and MPS also caps the tasks at the
+// clients its server accepts.
+func (plugin *NvidiaDevicePlugin) registeredCapacity(mode string, memMiB int32) (int32, int32, int32) {
+	count := int32(*plugin.schedulerConfig.DeviceSplitCount)
+	switch mode {
+	case nvidia.MigMode, nvidia.ExclusiveMode:
+		return memMiB, 100, count
+	case nvidia.MpsMode:
+		return memMiB, 100, min(count, mpsMaxClients)
+	}
+	if *plugin.schedulerConfig.DeviceMemoryScaling != 1 {
+		memMiB = int32(float64(memMiB) * *plugin.schedulerConfig.DeviceMemoryScaling)
+	}
+	return memMiB, int32(*plugin.schedulerConfig.DeviceCoreScaling * 100), count
+}
+
 // nvmlInit is overridable in tests to simulate NVML init failures without a real driver.
-var nvmlInit = nvml.Init
+// It looks nvml.Init up on each call, so a simulated NVML installed at startup is used.
+var nvmlInit = func() nvml.Return { return nvml.Init() }
+
+// nvmlShutdown pairs with nvmlInit so tests faking a successful Init do not
+// shut down a library that was never loaded.
+var nvmlShutdown = func() nvml.Return { return nvml.Shutdown() }
 
 func (plugin *NvidiaDevicePlugin) getAPIDevices() *[]*device.DeviceInfo {
 	devs := plugin.Devices()
@@ -101,9 +126,8 @@ func (plugin *NvidiaDevicePlugin) getAPIDevices() *[]*device.DeviceInfo {
 	res := make([]*device.DeviceInfo, 0, len(devs))
 
 	// Log mode-related warnings once per scan instead of per device
-	isMigMode := plugin.operatingMode == nvidia.MigMode
-	if isMigMode {
-		klog.V(3).Info("MIG mode enabled: memory scaling and core scaling are not applied")
+	if len(plugin.operatingModes) > 1 || (plugin.operatingMode != "" && plugin.operatingMode != nvidia.HamiCoreMode) {
+		klog.V(3).InfoS("Memory scaling and core scaling are only applied to GPUs in hami-core mode", "mode", plugin.operatingMode)
 	}
 
 	for UUID := range devs {
@@ -145,9 +169,9 @@ func (plugin *NvidiaDevicePlugin) getAPIDevices() *[]*device.DeviceInfo {
 			panic(0)
 		}
 
-		registeredmem := int32(memoryTotal / 1024 / 1024)
-		if *plugin.schedulerConfig.DeviceMemoryScaling != 1 && !isMigMode {
-			registeredmem = int32(float64(registeredmem) * *plugin.schedulerConfig.DeviceMemoryScaling)
+		mode := plugin.deviceMode(UUID)
+		registeredmem, devcore, count := plugin.registeredCapacity(mode, int32(memoryTotal/1024/1024))
+		if registeredmem != int32(memoryTotal/1024/1024) {
 			klog.V(3).Infof("Device id=%v: MemoryScaling=%v, registeredmem=%v", idx, *plugin.schedulerConfig.DeviceMemoryScaling, registeredmem)
 		}
 		health := true
@@ -172,23 +196,22 @@ func (plugin *NvidiaDevicePlugin) getAPIDevices() *[]*device.DeviceInfo {
 			// This is to handle cases where the model name might not be in the expected format.
 			Model = fmt.Sprintf("NVIDIA-%s", Model)
 		}
-		devcore := int32(100)
-		if !isMigMode {
-			devcore = int32(*plugin.schedulerConfig.DeviceCoreScaling * 100)
-		}
 		info := &device.DeviceInfo{
 			ID:      UUID,
 			Index:   uint(idx),
-			Count:   int32(*plugin.schedulerConfig.DeviceSplitCount),
+			Count:   count,
 			Devmem:  registeredmem,
 			Devcore: devcore,
 			Type:    Model,
 			Numa:    numa,
-			Mode:    plugin.operatingMode,
+			Mode:    mode,
 			Health:  health,
 		}
-		if isMigMode {
+		if plugin.supportsMode(nvidia.MigMode) {
 			info.MIGProfiles = plugin.discoverMigProfiles(ndev, Model)
+		}
+		info.Modes = plugin.registeredModes(len(info.MIGProfiles) > 0)
+		if mode == nvidia.MigMode {
 			if len(info.MIGProfiles) == 0 {
 				klog.InfoS("skip MIG device with no discovered profile capacity", "id", UUID, "model", Model)
 				continue
@@ -221,7 +244,12 @@ func (plugin *NvidiaDevicePlugin) discoverMigProfiles(dev nvml.Device, model str
 		}
 		klog.InfoS("discovering MIG profile capabilities", "model", model, "profiles", allowed.Profiles)
 		for _, profileName := range allowed.Profiles {
-			profileID, ok := profileNameToGIProfileID[profileSliceKey(profileName)]
+			giProfileName := profileName
+			computeSlices, parent, isCompute := nvidia.ParseMigComputeProfile(profileName)
+			if isCompute {
+				giProfileName = parent
+			}
+			profileID, ok := profileNameToGIProfileID[profileSliceKey(giProfileName)]
 			if !ok {
 				continue
 			}
@@ -245,6 +273,21 @@ func (plugin *NvidiaDevicePlugin) discoverMigProfiles(dev nvml.Device, model str
 			for _, placement := range placements {
 				profile.Placements = append(profile.Placements, device.MigPlacement{Start: placement.Start, Size: placement.Size})
 			}
+			if isCompute {
+				// A compute instance unit gets its share of the GPU instance,
+				// and every GPU instance holds several of them. Only the SMs
+				// are split: the memory share is what the scheduler accounts,
+				// not a limit, as compute instances share their GPU
+				// instance's memory.
+				if computeSlices > profileInfo.SliceCount {
+					klog.InfoS("skip MIG compute profile larger than its GPU instance", "profile", profileName)
+					continue
+				}
+				profile.MemoryMB = int32(profileInfo.MemorySizeMB * uint64(computeSlices) / uint64(profileInfo.SliceCount))
+				profile.Core = profile.Core * int32(computeSlices) / int32(profileInfo.SliceCount)
+				profile.InstanceCount *= profileInfo.SliceCount / computeSlices
+				profile.ComputeSlices = computeSlices
+			}
 			out = append(out, profile)
 		}
 		break
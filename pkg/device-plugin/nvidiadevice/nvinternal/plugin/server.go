@@ -37,10 +37,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -49,7 +51,7 @@ import (
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	spec "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
 	"github.com/google/uuid"
-	"github.com/imdario/mergo"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	corev1 "k8s.io/api/core/v1"
@@ -57,6 +59,7 @@ import (
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+	podresourcesv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
 	cdiapi "tags.cncf.io/container-device-interface/pkg/cdi"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
@@ -67,6 +70,7 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
 	"github.com/Project-HAMi/HAMi/pkg/util"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 // Constants for use by the 'volume-mounts' device list strategy
@@ -102,6 +106,8 @@ type NvidiaDevicePlugin struct {
 	schedulerConfig      nvidia.NvidiaConfig
 
 	applyMutex                 sync.Mutex
+	podLocks                   keyedMutex
+	podResources               podresourcesv1.PodResourcesListerClient
 	disableHealthChecks        chan bool
 	ackDisableHealthChecks     chan bool
 	disableWatchAndRegister    chan bool
@@ -111,61 +117,80 @@ type NvidiaDevicePlugin struct {
 	cdiAnnotationPrefix string
 
 	operatingMode string
-	deviceCache   string
+	// operatingModes lists every mode the node's GPUs may be switched
+	// between, operatingMode first. GPUs start in operatingMode.
+	operatingModes []string
+	deviceModesMu  sync.Mutex
+	deviceModes    map[string]string
+	deviceCache    string
 
 	// migMgr tracks live MIG GI+CI instances so we can destroy and recreate
 	// them per-task rather than resharding the whole card. Only set when
-	// operatingMode == "mig".
+	// the node supports the "mig" mode.
 	migMgr *MigInstanceManager
 
 	imexChannels imex.Channels
 
-	server *grpc.Server
-	health chan *rm.Device
-	stop   chan any
+	// deviceHealth tracks the devices health checks failed on until the
+	// recovery rules return them to service.
+	deviceHealth *deviceHealthTracker
+	cordonMu     sync.Mutex
+	// xidEvents receives every XID health checks classify by the XID policy.
+	xidEvents chan rm.XIDEvent
+
+	server        *grpc.Server
+	health        chan *rm.Device
+	healthRefresh chan struct{}
+	stop          chan any
 }
 
-func readFromConfigFile(sConfig *nvidia.NvidiaConfig, path string) (string, error) {
+func readFromConfigFile(sConfig *nvidia.NvidiaConfig, path string) (*effectiveNodeConfig, error) {
 	jsonbyte, err := os.ReadFile(path)
-	mode := "hami-core"
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	var deviceConfigs nvidia.DevicePluginConfigs
 	err = json.Unmarshal(jsonbyte, &deviceConfigs)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	klog.Infof("Device Plugin Configs: %v", fmt.Sprintf("%v", deviceConfigs))
-	for _, val := range deviceConfigs.Nodeconfig {
-		if os.Getenv(util.NodeNameEnvName) == val.Name {
-			klog.Infof("Reading config from file %s", val.Name)
-			if err := mergo.Merge(&sConfig.NodeDefaultConfig, val.NodeDefaultConfig, mergo.WithOverride); err != nil {
-				return "", err
-			}
-			if val.FilterDevice != nil && (len(val.FilterDevice.UUID) > 0 || len(val.FilterDevice.Index) > 0) {
-				nvidia.DevicePluginFilterDevice = val.FilterDevice
-			}
-			if len(val.OperatingMode) > 0 {
-				mode = val.OperatingMode
-			}
-			enableGetPreferredAllocation = val.EnableGetPreferredAllocation
-			klog.Infof("FilterDevice: %v", val.FilterDevice)
+	nodeName := os.Getenv(util.NodeNameEnvName)
+	var nodeLabels map[string]string
+	if hasNodeSelector(deviceConfigs.Nodeconfig) {
+		nodeLabels, err = getNodeLabels(nodeName)
+		if err != nil {
+			klog.ErrorS(err, "Failed to get node labels, nodeconfig entries with a nodeselector are only matched against no labels", "node", nodeName)
 		}
 	}
-	return mode, nil
+	eff, err := resolveNodeConfig(sConfig, deviceConfigs, nodeName, nodeLabels)
+	if err != nil {
+		return nil, err
+	}
+	if eff.FilterDevice != nil {
+		nvidia.DevicePluginFilterDevice = eff.FilterDevice
+	}
+	enableGetPreferredAllocation = eff.EnableGetPreferredAllocation
+	klog.Infof("FilterDevice: %v", eff.FilterDevice)
+	return eff, nil
 }
 
-func LoadNvidiaDevicePluginConfig() (*config.Config, string, error) {
+// LoadNvidiaDevicePluginConfig returns the device config and the modes the
+// node's GPUs may run in, the operating mode first.
+func LoadNvidiaDevicePluginConfig() (*config.Config, []string, error) {
 	sConfig, err := config.LoadConfig(*ConfigFile)
 	if err != nil {
 		klog.Fatalf(`failed to load device config file %s: %v`, *ConfigFile, err)
 	}
-	mode, err := readFromConfigFile(&sConfig.NvidiaConfig, ConfigFilePath)
+	var modes []string
+	eff, err := readFromConfigFile(&sConfig.NvidiaConfig, ConfigFilePath)
 	if err != nil {
 		klog.Errorf("readFromConfigFile err:%s", err.Error())
+	} else {
+		modes = eff.modes()
+		reportNodeConfig(os.Getenv(util.NodeNameEnvName), eff)
 	}
-	return sConfig, mode, nil
+	return sConfig, modes, nil
 }
 
 // getPluginSocketPath returns the socket to use for the specified resource.
@@ -176,7 +201,7 @@ func getPluginSocketPath(resource spec.ResourceName) string {
 }
 
 // NewNvidiaDevicePlugin returns an initialized NvidiaDevicePlugin
-func (o *options) devicePluginForResource(ctx context.Context, nvconfig *nvidia.DeviceConfig, resourceManager rm.ResourceManager, sConfig *config.Config, mode string) (Interface, error) {
+func (o *options) devicePluginForResource(ctx context.Context, nvconfig *nvidia.DeviceConfig, resourceManager rm.ResourceManager, sConfig *config.Config, modes []string) (Interface, error) {
 	_, name := resourceManager.Resource().Split()
 
 	deviceListStrategies, _ := spec.NewDeviceListStrategies(*nvconfig.Flags.Plugin.DeviceListStrategy)
@@ -190,8 +215,24 @@ func (o *options) devicePluginForResource(ctx context.Context, nvconfig *nvidia.
 	if err := nvidia.ValidateMigProfileAllowlist(sConfig.NvidiaConfig.MigProfileAllowlist); err != nil {
 		return nil, fmt.Errorf("validate MIG profile allowlist: %w", err)
 	}
+	xidPolicy, err := rm.NewXIDPolicy(sConfig.NvidiaConfig.XIDPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("validate XID policy: %w", err)
+	}
+	xidEvents := make(chan rm.XIDEvent, 64)
+	if reporter, ok := resourceManager.(rm.XIDReporter); ok {
+		reporter.SetXIDPolicy(xidPolicy, xidEvents)
+	}
+	podResources := kubeletPodResources()
+	if err := checkConcurrentAllocations(sConfig.NvidiaConfig.ConcurrentAllocations, podResources); err != nil {
+		return nil, err
+	}
+	mode := ""
+	if len(modes) > 0 {
+		mode = modes[0]
+	}
 	var migMgr *MigInstanceManager
-	if mode == "mig" {
+	if slices.Contains(modes, nvidia.MigMode) {
 		migMgr = NewMigInstanceManager()
 		if err := migMgr.Init(); err != nil {
 			return nil, fmt.Errorf("init MIG instance manager: %w", err)
@@ -213,8 +254,13 @@ func (o *options) devicePluginForResource(ctx context.Context, nvconfig *nvidia.
 		cdiAnnotationPrefix:        *o.config.Flags.Plugin.CDIAnnotationPrefix,
 		schedulerConfig:            sConfig.NvidiaConfig,
 		operatingMode:              mode,
+		operatingModes:             modes,
 		migMgr:                     migMgr,
+		deviceModes:                restoreDeviceModes(util.NodeName, modes),
+		podResources:               podResources,
 		deviceCache:                "",
+		deviceHealth:               newDeviceHealthTracker(),
+		xidEvents:                  xidEvents,
 
 		// These will be reinitialized every
 		// time the plugin server is restarted.
@@ -227,6 +273,7 @@ func (o *options) devicePluginForResource(ctx context.Context, nvconfig *nvidia.
 func (plugin *NvidiaDevicePlugin) initialize() {
 	plugin.server = grpc.NewServer([]grpc.ServerOption{}...)
 	plugin.health = make(chan *rm.Device)
+	plugin.healthRefresh = make(chan struct{}, 1)
 	plugin.stop = make(chan any)
 	plugin.disableHealthChecks = make(chan bool, 1)
 	plugin.ackDisableHealthChecks = make(chan bool, 1)
@@ -238,6 +285,7 @@ func (plugin *NvidiaDevicePlugin) cleanup() {
 	close(plugin.stop)
 	plugin.server = nil
 	plugin.health = nil
+	plugin.healthRefresh = nil
 	plugin.stop = nil
 	plugin.disableHealthChecks = nil
 	plugin.ackDisableHealthChecks = nil
@@ -287,7 +335,7 @@ func (plugin *NvidiaDevicePlugin) Start(kubeletSocket string) error {
 			break
 		}
 	}
-	if plugin.operatingMode == "mig" {
+	if plugin.supportsMode(nvidia.MigMode) {
 		if deviceSupportMig {
 			inUse, detectErr := collectInUseGPUs(plugin.ctx, os.Getenv(util.NodeNameEnvName))
 			if detectErr != nil {
@@ -338,7 +386,9 @@ func (plugin *NvidiaDevicePlugin) Start(kubeletSocket string) error {
 	go func() {
 		plugin.WatchAndRegister(plugin.disableWatchAndRegister, plugin.ackDisableWatchAndRegister)
 	}()
-	if plugin.operatingMode == "mig" {
+	go plugin.runDeviceHealthRecovery(plugin.stop, 10*time.Second)
+	go plugin.handleXIDEvents(plugin.stop)
+	if plugin.supportsMode(nvidia.MigMode) {
 		// Pod annotations are the allocation source of truth. Periodically
 		// reconcile the manager with live Pods so completed or deleted Pods
 		// release their exact profile+placement allocation.
@@ -371,7 +421,7 @@ func activeMigAllocationKeys(pods []corev1.Pod) (map[migAllocationKey]struct{},
 			if !ok {
 				return nil, fmt.Errorf("resolve active MIG parent GPU %q", allocation.GPUUUID)
 			}
-			active[allocationKey(gpuIndex, allocation.Profile, nvml.GpuInstancePlacement{Start: allocation.Placement.Start, Size: allocation.Placement.Size})] = struct{}{}
+			active[allocationKey(gpuIndex, allocation.Profile, nvml.GpuInstancePlacement{Start: allocation.Placement.Start, Size: allocation.Placement.Size}, migComputePlacement(allocation.ComputePlacement))] = struct{}{}
 		}
 	}
 	return active, nil
@@ -388,7 +438,7 @@ func (plugin *NvidiaDevicePlugin) annotateMigRuntimeInfo(pod *corev1.Pod) error
 		if !ok {
 			return fmt.Errorf("resolve MIG parent GPU %q", allocations[i].GPUUUID)
 		}
-		info, ok := plugin.migMgr.AllocationRuntimeInfo(gpuIndex, allocations[i].Profile, nvml.GpuInstancePlacement{Start: allocations[i].Placement.Start, Size: allocations[i].Placement.Size})
+		info, ok := plugin.migMgr.AllocationRuntimeInfo(gpuIndex, allocations[i].Profile, nvml.GpuInstancePlacement{Start: allocations[i].Placement.Start, Size: allocations[i].Placement.Size}, migComputePlacement(allocations[i].ComputePlacement))
 		if !ok {
 			continue // A later Allocate call may own another container's allocation.
 		}
@@ -473,6 +523,7 @@ func (plugin *NvidiaDevicePlugin) primeMigManagerFromAnnotations(_ []string) err
 				allocation.Profile,
 				allocation.MigUUID,
 				nvml.GpuInstancePlacement{Start: allocation.Placement.Start, Size: allocation.Placement.Size},
+				migComputePlacement(allocation.ComputePlacement),
 				*allocation.GPUInstanceID,
 				*allocation.ComputeInstanceID,
 			); err != nil {
@@ -495,11 +546,38 @@ func (plugin *NvidiaDevicePlugin) runMigAnnotationReconciler(interval time.Durat
 				// Reconciliation is destructive, so API or annotation errors are
 				// fail-closed and leave current MIG instances untouched.
 				klog.InfoS("periodic MIG reconciliation skipped", "err", err)
+				continue
+			}
+			if plugin.schedulerConfig.MigIdleReset != nil && *plugin.schedulerConfig.MigIdleReset {
+				plugin.consolidateIdleMigGPUs()
 			}
 		}
 	}
 }
 
+// consolidateIdleMigGPUs clears the instances left on MIG GPUs that nothing
+// runs on, once reconciliation has released those of finished pods.
+func (plugin *NvidiaDevicePlugin) consolidateIdleMigGPUs() {
+	busy, err := nvmlBusyGPUs()
+	if err != nil {
+		klog.InfoS("MIG idle reset skipped", "err", err)
+		return
+	}
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		klog.InfoS("MIG idle reset skipped", "err", nvml.ErrorString(ret))
+		return
+	}
+	reset, err := plugin.migMgr.ConsolidateIdleGPUs(count, busy)
+	if err != nil {
+		klog.InfoS("MIG idle reset failed", "err", err, "resetGPUs", reset)
+		return
+	}
+	if len(reset) > 0 {
+		klog.InfoS("Reset idle MIG GPUs", "resetGPUs", reset)
+	}
+}
+
 // Stop stops the gRPC server.
 func (plugin *NvidiaDevicePlugin) Stop() error {
 	if plugin == nil || plugin.server == nil {
@@ -616,9 +694,16 @@ func (plugin *NvidiaDevicePlugin) ListAndWatch(e *kubeletdevicepluginv1beta1.Emp
 		case <-plugin.stop:
 			return nil
 		case d := <-plugin.health:
-			// FIXME: there is no way to recover from the Unhealthy state.
+			// Devices return to service through runDeviceHealthRecovery.
 			d.Health = kubeletdevicepluginv1beta1.Unhealthy
 			klog.Infof("'%s' device marked unhealthy: %s", plugin.rm.Resource(), d.ID)
+			plugin.deviceUnhealthy(d.GetUUID())
+			if err := s.Send(&kubeletdevicepluginv1beta1.ListAndWatchResponse{Devices: plugin.apiDevices()}); err != nil {
+				klog.Errorf("Failed to send health-update ListAndWatch response: %v", err)
+				return nil
+			}
+		case <-plugin.healthRefresh:
+			klog.Infof("'%s' devices recovered, re-advertising", plugin.rm.Resource())
 			if err := s.Send(&kubeletdevicepluginv1beta1.ListAndWatchResponse{Devices: plugin.apiDevices()}); err != nil {
 				klog.Errorf("Failed to send health-update ListAndWatch response: %v", err)
 				return nil
@@ -634,7 +719,7 @@ func (plugin *NvidiaDevicePlugin) GetPreferredAllocation(ctx context.Context, r
 	var annotatedRequests device.PodSingleDevice
 	nodename := os.Getenv(util.NodeNameEnvName)
 	if nodename != "" {
-		current, err := getPendingPod(ctx, nodename)
+		current, err := plugin.preferredAllocationPod(ctx, nodename, r)
 		if err == nil && current != nil {
 			if podRequests, decodeErr := device.DecodePodDevices(device.InRequestDevices, current.Annotations); decodeErr == nil {
 				annotatedRequests = podRequests[nvidia.NvidiaGPUDevice]
@@ -668,6 +753,21 @@ func (plugin *NvidiaDevicePlugin) GetPreferredAllocation(ctx context.Context, r
 	return response, nil
 }
 
+// preferredAllocationPod returns the pod a GetPreferredAllocation request is
+// most likely for.
+func (plugin *NvidiaDevicePlugin) preferredAllocationPod(ctx context.Context, nodename string, r *kubeletdevicepluginv1beta1.PreferredAllocationRequest) (*corev1.Pod, error) {
+	if plugin.podResources != nil && !plugin.supportsMode(nvidia.MigMode) && len(r.ContainerRequests) > 0 {
+		current, err := plugin.resolveAllocatingPod(ctx, nodename, int(r.ContainerRequests[0].AllocationSize), nil)
+		if err != nil {
+			klog.ErrorS(err, "Failed to resolve allocating pod through the kubelet, falling back to pending pod lookup")
+		}
+		if current != nil {
+			return current, nil
+		}
+	}
+	return getPendingPod(ctx, nodename)
+}
+
 func (plugin *NvidiaDevicePlugin) selectPreferredDeviceIDsFromAnnotatedDevices(available, required []string, desired device.ContainerDevices, allocationSize int) ([]string, error) {
 	if len(desired) < allocationSize {
 		return nil, fmt.Errorf("annotated devices %d smaller than requested allocation size %d", len(desired), allocationSize)
@@ -744,22 +844,24 @@ func (plugin *NvidiaDevicePlugin) alignContainerDevicesWithAllocatedIDs(devreq d
 }
 
 // Allocate which return list of devices.
-func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdevicepluginv1beta1.AllocateRequest) (*kubeletdevicepluginv1beta1.AllocateResponse, error) {
+func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdevicepluginv1beta1.AllocateRequest) (_ *kubeletdevicepluginv1beta1.AllocateResponse, err error) {
 	// Kubelet may issue Allocate calls concurrently. The pending-pod
 	// annotation protocol and dynamic MIG preparation are node-global, so keep
-	// pod selection, GI/CI creation, and annotation consumption atomic.
-	plugin.applyMutex.Lock()
-	defer plugin.applyMutex.Unlock()
-
+	// pod selection, GI/CI creation, and annotation consumption atomic unless
+	// the kubelet tells which pod the request is for.
 	klog.InfoS("Allocate", "request", reqs)
 	responses := kubeletdevicepluginv1beta1.AllocateResponse{}
 	nodename := os.Getenv(util.NodeNameEnvName)
-	current, err := getPendingPod(ctx, nodename)
+	current, unlock, err := plugin.lockAllocatingPod(ctx, nodename, reqs)
 	if err != nil {
 		//nodelock.ReleaseNodeLock(nodename, NodeLockNvidia, current)
 		return &kubeletdevicepluginv1beta1.AllocateResponse{}, err
 	}
+	defer unlock()
 	klog.Infof("Allocate pod name is %s/%s, annotation is %+v", current.Namespace, current.Name, current.Annotations)
+	_, span := tracing.StartPodSpan(ctx, current, "NvidiaDevicePlugin.Allocate")
+	span.SetAttributes(attribute.String("k8s.node.name", nodename))
+	defer func() { tracing.End(span, err) }()
 
 	podSingleDev, err := decodePodSingleDevice(nvidia.NvidiaGPUDevice, current)
 	if err != nil {
@@ -804,7 +906,8 @@ func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdev
 				return &kubeletdevicepluginv1beta1.AllocateResponse{}, errors.New("device number not matched")
 			}
 
-			if enableGetPreferredAllocation && plugin.operatingMode != "mig" {
+			mode := plugin.allocationMode(current, devreq)
+			if enableGetPreferredAllocation && mode != nvidia.MigMode {
 				alignedDevreq, err := plugin.alignContainerDevicesWithAllocatedIDs(devreq, reqs.ContainerRequests[idx].DevicesIds)
 				if err != nil {
 					PodAllocationFailed(nodename, current, NodeLockNvidia)
@@ -812,12 +915,16 @@ func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdev
 				}
 				devreq = alignedDevreq
 			}
+			if err := plugin.switchDeviceModes(devreq, mode); err != nil {
+				PodAllocationFailed(nodename, current, NodeLockNvidia)
+				return nil, err
+			}
 			requestIDs, err := plugin.GetContainerDeviceStrArray(devreq, current, currentCtr.Name)
 			if err != nil {
 				PodAllocationFailed(nodename, current, NodeLockNvidia)
 				return nil, fmt.Errorf("resolve allocated NVIDIA devices: %w", err)
 			}
-			if plugin.operatingMode == "mig" {
+			if mode == nvidia.MigMode {
 				if err := plugin.annotateMigRuntimeInfo(current); err != nil {
 					PodAllocationFailed(nodename, current, NodeLockNvidia)
 					return nil, fmt.Errorf("record MIG runtime placement: %w", err)
@@ -829,7 +936,12 @@ func (plugin *NvidiaDevicePlugin) Allocate(ctx context.Context, reqs *kubeletdev
 				return nil, fmt.Errorf("failed to get allocate response: %v", err)
 			}
 
-			if plugin.operatingMode != "mig" {
+			if mode == nvidia.MpsMode {
+				maps.Copy(response.Envs, mpsLimitEnvs(devreq))
+			}
+			// No MPS control daemon is managed yet, so MPS clients keep the
+			// hami-core limits until one enforces the MPS client controls.
+			if mode != nvidia.MigMode && mode != nvidia.ExclusiveMode {
 				for i, dev := range devreq {
 					limitKey := fmt.Sprintf("CUDA_DEVICE_MEMORY_LIMIT_%v", i)
 					response.Envs[limitKey] = fmt.Sprintf("%vm", dev.Usedmem)
@@ -369,14 +369,7 @@ func Test_configOverride(t *testing.T) {
 	coreScale2 := 1.4
 
 	config := nvidia.DevicePluginConfigs{
-		Nodeconfig: []struct {
-			nvidia.NodeDefaultConfig     `json:",inline"`
-			Name                         string               `json:"name"`
-			OperatingMode                string               `json:"operatingmode"`
-			Migstrategy                  string               `json:"migstrategy"`
-			FilterDevice                 *nvidia.FilterDevice `json:"filterdevices"`
-			EnableGetPreferredAllocation bool                 `json:"enablegetpreferredallocation"`
-		}{
+		Nodeconfig: []nvidia.NodeConfig{
 			{
 				NodeDefaultConfig: nvidia.NodeDefaultConfig{
 					DeviceSplitCount:    &split1,
@@ -289,7 +289,7 @@ func containsModel(target string, models []string) bool {
 }
 
 func (nv *NvidiaDevicePlugin) GetContainerDeviceStrArray(c device.ContainerDevices, pod *corev1.Pod, containerName string) ([]string, error) {
-	if nv.operatingMode != "mig" {
+	if nv.allocationMode(pod, c) != nvidia.MigMode {
 		out := make([]string, 0, len(c))
 		for _, value := range c {
 			out = append(out, value.UUID)
@@ -355,7 +355,7 @@ func (nv *NvidiaDevicePlugin) GetContainerDeviceStrArray(c device.ContainerDevic
 		if !ok {
 			return nil, fmt.Errorf("resolve parent GPU %s", reservation.GPUUUID)
 		}
-		migUUID, created, err := nv.migMgr.EnsureAllocation(gpuIndex, reservation.Profile, nvml.GpuInstancePlacement{Start: reservation.Placement.Start, Size: reservation.Placement.Size})
+		migUUID, created, err := nv.migMgr.EnsureAllocation(gpuIndex, reservation.Profile, nvml.GpuInstancePlacement{Start: reservation.Placement.Start, Size: reservation.Placement.Size}, migComputePlacement(reservation.ComputePlacement))
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+const (
+	// Node event reasons recorded for the warn and drain XID actions.
+	xidWarningReason = "GPUXid"
+	nodeDrainReason  = "GPUNodeDrain"
+)
+
+// XIDEventsTotal counts the XIDs health checks saw, partitioned by device,
+// XID and the action the XID policy took on them.
+var XIDEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "hami_device_plugin_xid_events_total",
+	Help: "Number of XIDs reported by GPUs, partitioned by device UUID, XID and action (ignore, warn, unhealthy or drain).",
+}, []string{"deviceuuid", "xid", "action"})
+
+// handleXIDEvents counts the XIDs health checks report and acts on the warn
+// and drain ones until stop is closed. Unhealthy devices reach ListAndWatch
+// through the health channel instead.
+func (plugin *NvidiaDevicePlugin) handleXIDEvents(stop <-chan any) {
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-plugin.xidEvents:
+			handleXIDEvent(event)
+		}
+	}
+}
+
+func handleXIDEvent(event rm.XIDEvent) {
+	device := "an unknown device"
+	uuid := ""
+	if event.Device != nil {
+		uuid = event.Device.GetUUID()
+		device = "device " + uuid
+	}
+	XIDEventsTotal.WithLabelValues(uuid, strconv.FormatUint(event.XID, 10), string(event.Action)).Inc()
+	switch event.Action {
+	case nvidia.XIDWarn:
+		recordDeviceHealthEvent(corev1.EventTypeWarning, xidWarningReason, fmt.Sprintf("Xid %d on %s", event.XID, device))
+	case nvidia.XIDDrain:
+		message := fmt.Sprintf("Xid %d on %s; the node is cordoned", event.XID, device)
+		drained, err := cordonNode()
+		if err != nil {
+			klog.ErrorS(err, "Failed to cordon node", "xid", event.XID, "uuid", uuid)
+			return
+		}
+		if drained {
+			recordDeviceHealthEvent(corev1.EventTypeWarning, nodeDrainReason, message)
+		}
+	}
+}
+
+// cordonNode marks the node unschedulable and reports whether it was not
+// already. Evicting the pods already running is left to the operator's
+// remediation tooling.
+func cordonNode() (bool, error) {
+	node, err := util.GetNode(util.NodeName)
+	if err != nil {
+		return false, err
+	}
+	if node.Spec.Unschedulable {
+		return false, nil
+	}
+	klog.InfoS("Cordoning node after a drain XID", "node", node.Name)
+	_, err = client.GetClient().CoreV1().Nodes().Patch(context.Background(), node.Name, types.StrategicMergePatchType,
+		[]byte(`{"spec":{"unschedulable":true}}`), metav1.PatchOptions{})
+	return err == nil, err
+}
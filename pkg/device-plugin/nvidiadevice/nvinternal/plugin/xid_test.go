@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2026, HAMi.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/rm"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func TestHandleXIDEvent(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-node"}}
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(node)
+	defer func() { client.KubeClient = previousKubeClient }()
+	previousNodeName := util.NodeName
+	util.NodeName = node.Name
+	defer func() { util.NodeName = previousNodeName }()
+
+	var recorded []string
+	previousRecord := recordDeviceHealthEvent
+	recordDeviceHealthEvent = func(eventType, reason, message string) {
+		recorded = append(recorded, eventType+" "+reason+": "+message)
+	}
+	defer func() { recordDeviceHealthEvent = previousRecord }()
+
+	gpu := &rm.Device{Device: kubeletdevicepluginv1beta1.Device{ID: "GPU-xid"}}
+	handleXIDEvent(rm.XIDEvent{Device: gpu, XID: 13, Action: nvidia.XIDIgnore})
+	handleXIDEvent(rm.XIDEvent{Device: gpu, XID: 13, Action: nvidia.XIDIgnore})
+	handleXIDEvent(rm.XIDEvent{Device: gpu, XID: 94, Action: nvidia.XIDWarn})
+	handleXIDEvent(rm.XIDEvent{Device: gpu, XID: 79, Action: nvidia.XIDDrain})
+	handleXIDEvent(rm.XIDEvent{XID: 79, Action: nvidia.XIDDrain})
+
+	require.InDelta(t, 2, testutil.ToFloat64(XIDEventsTotal.WithLabelValues("GPU-xid", "13", "ignore")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(XIDEventsTotal.WithLabelValues("GPU-xid", "94", "warn")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(XIDEventsTotal.WithLabelValues("", "79", "drain")), 0)
+
+	updated, err := client.KubeClient.CoreV1().Nodes().Get(context.Background(), node.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, updated.Spec.Unschedulable)
+	require.Equal(t, []string{
+		"Warning GPUXid: Xid 94 on device GPU-xid",
+		"Warning GPUNodeDrain: Xid 79 on device GPU-xid; the node is cordoned",
+	}, recorded, "a node that is cordoned already is not drained again")
+}
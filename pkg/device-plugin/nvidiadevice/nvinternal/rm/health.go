@@ -40,6 +40,8 @@ import (
 
 	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 )
 
 const (
@@ -78,6 +80,7 @@ func (r *nvmlResourceManager) checkHealth(stop <-chan interface{}, devices Devic
 	}()
 
 	klog.Infof("Using XIDs for health checks: %v", xids)
+	overrides := getHealthCheckXidOverrides()
 
 	eventSet, ret := r.nvml.EventSetCreate()
 	if ret != nvml.SUCCESS {
@@ -156,14 +159,16 @@ func (r *nvmlResourceManager) checkHealth(stop <-chan interface{}, devices Devic
 			continue
 		}
 
-		if xids.IsDisabled(e.EventData) {
-			klog.Infof("Skipping event %+v", e)
-			continue
-		}
-
+		action := r.xidAction(overrides, e.EventData)
+		marksUnhealthy := action == nvidia.XIDUnhealthy || action == nvidia.XIDDrain
 		klog.Infof("Processing event %+v", e)
 		eventUUID, ret := e.Device.GetUUID()
 		if ret != nvml.SUCCESS {
+			r.reportXID(XIDEvent{XID: e.EventData, Action: action})
+			if !marksUnhealthy {
+				klog.Infof("Skipping event %+v", e)
+				continue
+			}
 			// If we cannot reliably determine the device UUID, we mark all devices as unhealthy.
 			klog.Infof("Failed to determine uuid for event %v: %v; Marking all devices as unhealthy.", e, ret)
 			for _, d := range devices {
@@ -187,6 +192,11 @@ func (r *nvmlResourceManager) checkHealth(stop <-chan interface{}, devices Devic
 			klog.Infof("Event for mig device %v (gi=%v, ci=%v)", d.ID, gi, ci)
 		}
 
+		r.reportXID(XIDEvent{Device: d, XID: e.EventData, Action: action})
+		if !marksUnhealthy {
+			klog.Infof("XidCriticalError: Xid=%d on Device=%s; action is %s.", e.EventData, d.ID, action)
+			continue
+		}
 		klog.Infof("XidCriticalError: Xid=%d on Device=%s; marking device as unhealthy.", e.EventData, d.ID)
 		unhealthy <- d
 	}
@@ -236,16 +246,7 @@ func (h disabledXIDs) IsDisabled(xid uint64) bool {
 // Note that if an XID is explicitly enabled, this takes precedence over it
 // having been disabled either explicitly or implicitly.
 func getHealthCheckXids() disabledXIDs {
-	disabled := newHealthCheckXIDs(
-		// TODO: We should not read the envvar here directly, but instead
-		// "upgrade" this to a top-level config option.
-		strings.Split(strings.ToLower(os.Getenv(envDisableHealthChecks)), ",")...,
-	)
-	enabled := newHealthCheckXIDs(
-		// TODO: We should not read the envvar here directly, but instead
-		// "upgrade" this to a top-level config option.
-		strings.Split(strings.ToLower(os.Getenv(envEnableHealthChecks)), ",")...,
-	)
+	disabled := getHealthCheckXidOverrides()
 
 	// Add the list of hardcoded disabled (ignored) XIDs:
 	// FIXME: formalize the full list and document it.
@@ -260,9 +261,27 @@ func getHealthCheckXids() disabledXIDs {
 		109, // Context Switch Timeout Error
 	}
 	for _, ignored := range ignoredXids {
-		disabled[ignored] = true
+		// An explicitly enabled XID stays enabled.
+		if _, ok := disabled[ignored]; !ok {
+			disabled[ignored] = true
+		}
 	}
+	return disabled
+}
 
+// getHealthCheckXidOverrides returns the XIDs explicitly disabled or enabled
+// through the environment. Explicitly enabled XIDs take precedence.
+func getHealthCheckXidOverrides() disabledXIDs {
+	disabled := newHealthCheckXIDs(
+		// TODO: We should not read the envvar here directly, but instead
+		// "upgrade" this to a top-level config option.
+		strings.Split(strings.ToLower(os.Getenv(envDisableHealthChecks)), ",")...,
+	)
+	enabled := newHealthCheckXIDs(
+		// TODO: We should not read the envvar here directly, but instead
+		// "upgrade" this to a top-level config option.
+		strings.Split(strings.ToLower(os.Getenv(envEnableHealthChecks)), ",")...,
+	)
 	// Explicitly ENABLE specific XIDs,
 	for enabled := range enabled {
 		disabled[enabled] = false
@@ -48,9 +48,13 @@ import (
 type nvmlResourceManager struct {
 	resourceManager
 	nvml nvml.Interface
+
+	xidPolicy *XIDPolicy
+	xidEvents chan<- XIDEvent
 }
 
 var _ ResourceManager = (*nvmlResourceManager)(nil)
+var _ XIDReporter = (*nvmlResourceManager)(nil)
 
 // NewNVMLResourceManagers returns a set of ResourceManagers, one for each NVML resource in 'config'.
 func NewNVMLResourceManagers(infolib info.Interface, nvmllib nvml.Interface, devicelib device.Interface, config *spec.Config) ([]ResourceManager, error) {
@@ -0,0 +1,139 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rm
+
+import (
+	"fmt"
+	"slices"
+
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+// DefaultXIDRules ignore the application errors in NVIDIA's XID catalog
+// (https://docs.nvidia.com/deploy/xid-errors/), which leave the GPU healthy.
+// Every other XID marks the device unhealthy, as health checks always have;
+// XIDs such as 63, 92 and 94 that the catalog does not treat as fatal can be
+// downgraded to warn through the xidPolicy config.
+var DefaultXIDRules = []nvidia.XIDRule{
+	{
+		XIDs:   []string{"13", "31", "43", "45", "68", "109"},
+		Action: nvidia.XIDIgnore,
+	},
+}
+
+var defaultXIDPolicy = mustXIDPolicy(nil)
+
+// XIDEvent is an XID a device reported and the action the policy took on it.
+// Device is nil when NVML could not tell which device reported it.
+type XIDEvent struct {
+	Device *Device
+	XID    uint64
+	Action nvidia.XIDAction
+}
+
+// XIDReporter is implemented by resource managers whose health checks
+// classify XIDs. Every classified XID is sent on events without blocking.
+type XIDReporter interface {
+	SetXIDPolicy(policy *XIDPolicy, events chan<- XIDEvent)
+}
+
+type xidRange struct {
+	first, last uint64
+	action      nvidia.XIDAction
+}
+
+// XIDPolicy classifies XIDs into the action health checks take on them.
+type XIDPolicy struct {
+	ranges []xidRange
+}
+
+// NewXIDPolicy returns the policy that applies rules in order, then
+// DefaultXIDRules.
+func NewXIDPolicy(rules []nvidia.XIDRule) (*XIDPolicy, error) {
+	p := &XIDPolicy{}
+	for i, rule := range rules {
+		if err := nvidia.ValidateXIDRule(rule); err != nil {
+			return nil, fmt.Errorf("xidPolicy[%d]: %w", i, err)
+		}
+	}
+	for _, rule := range append(slices.Clone(rules), DefaultXIDRules...) {
+		for _, xids := range rule.XIDs {
+			first, last, _ := nvidia.ParseXIDRange(xids)
+			p.ranges = append(p.ranges, xidRange{first: first, last: last, action: rule.Action})
+		}
+	}
+	return p, nil
+}
+
+func mustXIDPolicy(rules []nvidia.XIDRule) *XIDPolicy {
+	p, err := NewXIDPolicy(rules)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Action returns the action for xid. XIDs no rule matches mark the device
+// unhealthy. A nil policy applies DefaultXIDRules.
+func (p *XIDPolicy) Action(xid uint64) nvidia.XIDAction {
+	if p == nil {
+		p = defaultXIDPolicy
+	}
+	for _, r := range p.ranges {
+		if xid >= r.first && xid <= r.last {
+			return r.action
+		}
+	}
+	return nvidia.XIDUnhealthy
+}
+
+// SetXIDPolicy makes health checks classify XIDs by policy and report them
+// on events.
+func (r *nvmlResourceManager) SetXIDPolicy(policy *XIDPolicy, events chan<- XIDEvent) {
+	r.xidPolicy = policy
+	r.xidEvents = events
+}
+
+// xidAction returns the action for xid. The DP_DISABLE_HEALTHCHECKS and
+// DP_ENABLE_HEALTHCHECKS overrides take precedence over the policy.
+func (r *nvmlResourceManager) xidAction(overrides disabledXIDs, xid uint64) nvidia.XIDAction {
+	disabled, ok := overrides[xid]
+	if !ok {
+		disabled, ok = overrides[allXIDs]
+	}
+	switch {
+	case !ok:
+		return r.xidPolicy.Action(xid)
+	case disabled:
+		return nvidia.XIDIgnore
+	default:
+		return nvidia.XIDUnhealthy
+	}
+}
+
+func (r *nvmlResourceManager) reportXID(event XIDEvent) {
+	if r.xidEvents == nil {
+		return
+	}
+	select {
+	case r.xidEvents <- event:
+	default:
+		klog.Warningf("Dropping report of Xid=%d, the XID event queue is full", event.XID)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	mock "github.com/NVIDIA/go-nvml/pkg/nvml/mock"
+	"github.com/stretchr/testify/require"
+	kubeletdevicepluginv1beta1 "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+func TestXIDPolicyAction(t *testing.T) {
+	var defaults *XIDPolicy
+	require.Equal(t, nvidia.XIDIgnore, defaults.Action(13))
+	require.Equal(t, nvidia.XIDUnhealthy, defaults.Action(94))
+	require.Equal(t, nvidia.XIDUnhealthy, defaults.Action(48))
+	require.Equal(t, nvidia.XIDUnhealthy, defaults.Action(79))
+
+	policy, err := NewXIDPolicy([]nvidia.XIDRule{
+		{XIDs: []string{"79"}, Action: nvidia.XIDDrain},
+		{XIDs: []string{"90-94"}, Action: nvidia.XIDIgnore},
+		{XIDs: []string{"13"}, Action: nvidia.XIDWarn},
+	})
+	require.NoError(t, err)
+	require.Equal(t, nvidia.XIDDrain, policy.Action(79))
+	require.Equal(t, nvidia.XIDIgnore, policy.Action(92), "configured rules come before the defaults")
+	require.Equal(t, nvidia.XIDWarn, policy.Action(13))
+	require.Equal(t, nvidia.XIDIgnore, policy.Action(31), "XIDs the configuration does not list keep their default")
+	require.Equal(t, nvidia.XIDUnhealthy, policy.Action(95))
+
+	_, err = NewXIDPolicy([]nvidia.XIDRule{{XIDs: []string{"48"}, Action: "reset"}})
+	require.ErrorContains(t, err, `xidPolicy[0]: action must be one of ignore, warn, unhealthy, drain, got "reset"`)
+	_, err = NewXIDPolicy([]nvidia.XIDRule{{XIDs: []string{"64-61"}, Action: nvidia.XIDWarn}})
+	require.ErrorContains(t, err, `invalid XID range "64-61"`)
+}
+
+func TestXIDActionEnvOverrides(t *testing.T) {
+	policy, err := NewXIDPolicy([]nvidia.XIDRule{{XIDs: []string{"48", "79"}, Action: nvidia.XIDDrain}})
+	require.NoError(t, err)
+	r := &nvmlResourceManager{xidPolicy: policy}
+
+	t.Setenv(envDisableHealthChecks, "48")
+	t.Setenv(envEnableHealthChecks, "13")
+	overrides := getHealthCheckXidOverrides()
+	require.Equal(t, nvidia.XIDIgnore, r.xidAction(overrides, 48))
+	require.Equal(t, nvidia.XIDUnhealthy, r.xidAction(overrides, 13))
+	require.Equal(t, nvidia.XIDDrain, r.xidAction(overrides, 79))
+
+	t.Setenv(envDisableHealthChecks, "")
+	t.Setenv(envEnableHealthChecks, "all")
+	overrides = getHealthCheckXidOverrides()
+	require.Equal(t, nvidia.XIDUnhealthy, r.xidAction(overrides, 31))
+	require.Equal(t, nvidia.XIDUnhealthy, r.xidAction(overrides, 79))
+}
+
+func TestCheckHealthAppliesXIDPolicy(t *testing.T) {
+	xids := []uint64{13, 94, 79, 48}
+	events := make(chan nvml.EventData, len(xids))
+	gpu := &mock.Device{
+		GetUUIDFunc:                func() (string, nvml.Return) { return "GPU-a", nvml.SUCCESS },
+		GetSupportedEventTypesFunc: func() (uint64, nvml.Return) { return uint64(nvml.EventTypeXidCriticalError), nvml.SUCCESS },
+		RegisterEventsFunc:         func(uint64, nvml.EventSet) nvml.Return { return nvml.SUCCESS },
+	}
+	for _, xid := range xids {
+		events <- nvml.EventData{Device: gpu, EventType: nvml.EventTypeXidCriticalError, EventData: xid}
+	}
+	eventSet := &mock.EventSet{
+		FreeFunc: func() nvml.Return { return nvml.SUCCESS },
+		WaitFunc: func(uint32) (nvml.EventData, nvml.Return) {
+			select {
+			case e := <-events:
+				return e, nvml.SUCCESS
+			default:
+				time.Sleep(time.Millisecond)
+				return nvml.EventData{}, nvml.ERROR_TIMEOUT
+			}
+		},
+	}
+	policy, err := NewXIDPolicy([]nvidia.XIDRule{
+		{XIDs: []string{"79"}, Action: nvidia.XIDDrain},
+		{XIDs: []string{"94"}, Action: nvidia.XIDWarn},
+	})
+	require.NoError(t, err)
+	reported := make(chan XIDEvent, len(xids))
+	r := &nvmlResourceManager{nvml: &mock.Interface{
+		InitFunc:                  func() nvml.Return { return nvml.SUCCESS },
+		ShutdownFunc:              func() nvml.Return { return nvml.SUCCESS },
+		EventSetCreateFunc:        func() (nvml.EventSet, nvml.Return) { return eventSet, nvml.SUCCESS },
+		DeviceGetHandleByUUIDFunc: func(string) (nvml.Device, nvml.Return) { return gpu, nvml.SUCCESS },
+	}}
+	r.SetXIDPolicy(policy, reported)
+
+	d := &Device{Device: kubeletdevicepluginv1beta1.Device{ID: "GPU-a", Health: kubeletdevicepluginv1beta1.Healthy}}
+	stop := make(chan interface{})
+	unhealthy := make(chan *Device, len(xids))
+	done := make(chan error, 1)
+	go func() { done <- r.checkHealth(stop, Devices{"GPU-a": d}, unhealthy, make(chan bool)) }()
+
+	var got []XIDEvent
+	for range xids {
+		select {
+		case e := <-reported:
+			got = append(got, e)
+		case <-time.After(10 * time.Second):
+			t.Fatal("checkHealth did not report every XID")
+		}
+	}
+	close(stop)
+	require.NoError(t, <-done)
+
+	require.Equal(t, []XIDEvent{
+		{Device: d, XID: 13, Action: nvidia.XIDIgnore},
+		{Device: d, XID: 94, Action: nvidia.XIDWarn},
+		{Device: d, XID: 79, Action: nvidia.XIDDrain},
+		{Device: d, XID: 48, Action: nvidia.XIDUnhealthy},
+	}, got)
+	require.Len(t, unhealthy, 2, "only drain and unhealthy XIDs mark the device unhealthy")
+}
@@ -23,6 +23,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -82,6 +83,11 @@ type MigProfile struct {
 	Core       int32          `json:"core"`
 	SliceCount uint32         `json:"sliceCount"`
 	Placements []MigPlacement `json:"placements"`
+	// ComputeSlices is set on compute instance profiles such as 1c.3g.20gb,
+	// units of ComputeSlices compute slices sharing a GPU instance placed at
+	// Placements. MemoryMB and Core are the unit's share of the instance;
+	// the memory is shared by all of them, not isolated per unit.
+	ComputeSlices uint32 `json:"computeSlices,omitempty"`
 
 	// InstanceCount is only used by the device plugin to derive the physical
 	// GPU's advertised replica count. It is not part of the scheduler wire
@@ -96,6 +102,9 @@ type MigAllocation struct {
 	GPUInstanceID     uint32
 	ComputeInstanceID uint32
 	RuntimeReady      bool
+	// ComputePlacement is the compute instance's slices within the GPU
+	// instance for compute instance profiles, and zero otherwise.
+	ComputePlacement MigPlacement
 }
 
 type AllowedMigProfiles struct {
@@ -113,6 +122,7 @@ type DeviceUsage struct {
 	Totalcore           int32
 	Usedcores           int32
 	Mode                string
+	Modes               []string
 	MigProfiles         []MigProfile
 	MigAllocationsInUse []MigAllocation
 	MigTemplate         []Geometry // Deprecated: unused by dynamic NVIDIA MIG.
@@ -133,6 +143,7 @@ type DeviceInfo struct {
 	Type         string         `json:"type,omitempty"`
 	Numa         int            `json:"numa,omitempty"`
 	Mode         string         `json:"mode,omitempty"`
+	Modes        []string       `json:"modes,omitempty"` // Modes an idle device can switch to; empty when fixed to Mode.
 	MIGProfiles  []MigProfile   `json:"migProfiles,omitempty"`
 	MIGTemplate  []Geometry     `json:"migtemplate,omitempty"` // Deprecated.
 	Health       bool           `json:"health,omitempty"`
@@ -151,6 +162,7 @@ type DevicePairScore struct {
 
 func (d DeviceInfo) DeepCopy() DeviceInfo {
 	dup := d
+	dup.Modes = slices.Clone(d.Modes)
 
 	if d.MIGProfiles != nil {
 		dup.MIGProfiles = make([]MigProfile, len(d.MIGProfiles))
@@ -198,6 +210,9 @@ type ResourceNames struct {
 	ResourceCountName  string
 	ResourceMemoryName string
 	ResourceCoreName   string
+	// ResourceMemoryPercentageName requests memory as a percentage of each
+	// device's. Backends without it leave it empty.
+	ResourceMemoryPercentageName string
 	// MemoryFactor is the scale GenerateResourceRequests applies to the memory
 	// value read off the container spec. Recorded usage is in scaled units
 	// while a ResourceQuota limit is written in unscaled ones, so a quota check
@@ -249,8 +264,22 @@ var (
 	SupportDevices     map[string]string
 	DevicesMap         map[string]Devices
 	DevicesToHandle    []string
+
+	// devicesLock guards swapping DevicesMap and DevicesToHandle. A published
+	// map is never mutated, so callers may keep ranging over the result of
+	// GetDevices after a concurrent SetDevices.
+	devicesLock sync.RWMutex
+
+	// configLock keeps a device config reload, which rebuilds the backends
+	// and rewrites the package settings their Init functions own, from
+	// running while a request is reading them.
+	configLock sync.RWMutex
 )
 
+// configLockPoll is how often LockConfig retries while requests hold the
+// device config.
+const configLockPoll = 10 * time.Millisecond
+
 func init() {
 	InRequestDevices = make(map[string]string)
 	SupportDevices = make(map[string]string)
@@ -270,6 +299,7 @@ func (d *DeviceUsage) DeepCopy() *DeviceUsage {
 		Totalcore: d.Totalcore,
 		Usedcores: d.Usedcores,
 		Mode:      d.Mode,
+		Modes:     slices.Clone(d.Modes),
 		Numa:      d.Numa,
 		Type:      d.Type,
 		Health:    d.Health,
@@ -311,9 +341,45 @@ func (m MigInUse) DeepCopy() MigInUse {
 }
 
 func GetDevices() map[string]Devices {
+	devicesLock.RLock()
+	defer devicesLock.RUnlock()
 	return DevicesMap
 }
 
+// SetDevices replaces the registered device backends in one step, so readers
+// either see the previous set or the new one, never a partial rebuild.
+func SetDevices(devices map[string]Devices, toHandle []string) {
+	devicesLock.Lock()
+	defer devicesLock.Unlock()
+	DevicesMap = devices
+	DevicesToHandle = toHandle
+}
+
+// RLockConfig holds off device config reloads until RUnlockConfig, so a
+// request sees a single config while it runs device code. Callers release it
+// before API calls, which can take long. Calls must not nest.
+func RLockConfig() {
+	configLock.RLock()
+}
+
+func RUnlockConfig() {
+	configLock.RUnlock()
+}
+
+// LockConfig waits for in-flight requests and blocks new ones while the
+// device config is swapped. It polls rather than queueing on the lock: a
+// queued writer would hold every new RLockConfig caller, such as the
+// admission webhook, behind the slowest request in flight.
+func LockConfig() {
+	for !configLock.TryLock() {
+		time.Sleep(configLockPoll)
+	}
+}
+
+func UnlockConfig() {
+	configLock.Unlock()
+}
+
 func DecodeNodeDevices(str string) ([]*DeviceInfo, error) {
 	if !strings.Contains(str, OneContainerMultiDeviceSplitSymbol) {
 		return nil, fmt.Errorf("node annotation missing device separator")
@@ -432,6 +498,7 @@ func MarshalNodeDevices(dlist []*DeviceInfo) string {
 			Health:      val.Health,
 			Index:       val.Index,
 			Mode:        val.Mode,
+			Modes:       val.Modes,
 			MIGProfiles: val.MIGProfiles,
 		})
 	}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -1869,3 +1870,37 @@ func TestDecodeNodeDevicesLegacyFormat(t *testing.T) {
 		},
 	}, decoded)
 }
+
+func TestLockConfigDoesNotBlockNewReaders(t *testing.T) {
+	RLockConfig()
+	locked := make(chan struct{})
+	go func() {
+		LockConfig()
+		close(locked)
+		UnlockConfig()
+	}()
+	time.Sleep(5 * configLockPoll)
+
+	read := make(chan struct{})
+	go func() {
+		RLockConfig()
+		RUnlockConfig()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("a reader waited behind a pending config reload")
+	}
+	select {
+	case <-locked:
+		t.Fatal("config reload ran while a request held the config")
+	default:
+	}
+	RUnlockConfig()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("config reload did not run once the request released the config")
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// CoreBurstRatioAnnotation opts a pod into elastic core limits. Its value is
+// the share, between 0 and 1, of the cores above its limit that a container
+// may borrow while the other tenants of its GPUs are idle. vGPUmonitor takes
+// the borrowed cores back as soon as a co-tenant becomes active.
+const CoreBurstRatioAnnotation = "nvidia.com/vgpu-core-burst-ratio"
+
+// ParseCoreBurstRatio validates a CoreBurstRatioAnnotation value.
+func ParseCoreBurstRatio(value string) (float64, error) {
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(ratio >= 0 && ratio <= 1) {
+		return 0, fmt.Errorf("invalid %s annotation %q: must be a number between 0 and 1", CoreBurstRatioAnnotation, value)
+	}
+	return ratio, nil
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseCoreBurstRatio(t *testing.T) {
+	for _, value := range []string{"0", "0.25", "1"} {
+		_, err := ParseCoreBurstRatio(value)
+		assert.NilError(t, err, value)
+	}
+	for _, value := range []string{"", "-0.1", "1.5", "NaN", "half"} {
+		_, err := ParseCoreBurstRatio(value)
+		assert.ErrorContains(t, err, "must be a number between 0 and 1", value)
+	}
+}
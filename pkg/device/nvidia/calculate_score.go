@@ -81,10 +81,14 @@ type deviceListBuilder struct {
 	devicelib device.Interface
 }
 
+// NewNVML returns the NVML library the device topology is read from. The
+// device plugin replaces it when it runs against a simulated NVML.
+var NewNVML = func() nvml.Interface { return nvml.New() }
+
 // NewDevices creates a list of Devices from all available nvml.Devices using the specified options.
 func NewDevices() (DeviceList, error) {
 	o := &deviceListBuilder{}
-	o.nvmllib = nvml.New()
+	o.nvmllib = NewNVML()
 	o.devicelib = device.New(o.nvmllib)
 	return o.build()
 }
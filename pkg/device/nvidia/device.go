@@ -21,6 +21,7 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -29,6 +30,7 @@ import (
 	spec "github.com/NVIDIA/k8s-device-plugin/api/config/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
@@ -41,11 +43,14 @@ const (
 	HandshakeAnnos       = "hami.io/node-handshake"
 	RegisterAnnos        = "hami.io/node-nvidia-register"
 	RegisterGPUPairScore = "hami.io/node-nvidia-score"
-	NvidiaGPUDevice      = "NVIDIA"
-	GPUInUse             = "nvidia.com/use-gputype"
-	GPUNoUse             = "nvidia.com/nouse-gputype"
-	NumaBind             = "nvidia.com/numa-bind"
-	NodeLockNvidia       = "hami.io/mutex.lock"
+	// AllocatedDevicesAnnos is the pod annotation holding the NVIDIA devices
+	// and per-device limits assigned to each container.
+	AllocatedDevicesAnnos = "hami.io/vgpu-devices-allocated"
+	NvidiaGPUDevice       = "NVIDIA"
+	GPUInUse              = "nvidia.com/use-gputype"
+	GPUNoUse              = "nvidia.com/nouse-gputype"
+	NumaBind              = "nvidia.com/numa-bind"
+	NodeLockNvidia        = "hami.io/mutex.lock"
 	// GPUUseUUID annotation specifies a comma-separated list of GPU UUIDs to use.
 	GPUUseUUID = "nvidia.com/use-gpuuuid"
 	// GPUNoUseUUID annotation specifies a comma-separated list of GPU UUIDs to exclude.
@@ -57,10 +62,23 @@ const (
 	// unlike FilterDeviceToRegister, it takes effect immediately and needs no
 	// device-plugin restart.
 	DeviceCordonAnnotation = "hami.io/device-cordon"
+	// DeviceAutoCordonAnnotation lists the UUIDs the device plugin itself added
+	// to DeviceCordonAnnotation because health checks failed, so that recovery
+	// only removes its own entries and never an operator's.
+	DeviceAutoCordonAnnotation = "hami.io/node-device-auto-cordon"
+	// NodeConfigAnnos reports the device plugin config in effect on a node
+	// after merging the matching nodeconfig entries onto the defaults.
+	NodeConfigAnnos = "hami.io/node-nvidia-config"
+	// NodeDeviceModesAnnos records, as JSON from GPU UUID to mode, the modes
+	// the device plugin switched the node's GPUs to, so a restarted plugin
+	// registers them in the mode they are in.
+	NodeDeviceModesAnnos = "hami.io/node-nvidia-device-modes"
 
 	MigMode      = "mig"
 	HamiCoreMode = "hami-core"
 	MpsMode      = "mps"
+	// ExclusiveMode hands a whole GPU to a single pod without hami-core.
+	ExclusiveMode = "exclusive"
 )
 
 var (
@@ -111,6 +129,13 @@ type NvidiaConfig struct {
 	GPUCorePolicy GPUCoreUtilizationPolicy `yaml:"gpuCorePolicy"`
 	// RuntimeClassName is the name of the runtime class to be added to pod.spec.runtimeClassName
 	RuntimeClassName string `yaml:"runtimeClassName"`
+	// ConcurrentAllocations is how many pods may be allocating NVIDIA devices
+	// on a node at once. 0 or 1 keeps the node-wide lock; more needs the device
+	// plugin to resolve Allocate calls through the kubelet PodResources API.
+	ConcurrentAllocations int32 `yaml:"concurrentAllocations"`
+	// XIDPolicy decides what the device plugin does on each XID. The first
+	// matching rule wins; XIDs no rule matches follow the built-in table.
+	XIDPolicy []XIDRule `yaml:"xidPolicy"`
 }
 
 // These configs can be specified for each node by using Nodeconfig.
@@ -125,6 +150,30 @@ type NodeDefaultConfig struct {
 	// replica so kubelet's TopologyManager can align CPU and GPU NUMA nodes.
 	// Defaults to false to preserve existing admission behavior.
 	EnableNUMATopology *bool `yaml:"enableNumaTopology" json:"enablenumatopology"`
+	// MigIdleReset lets the device plugin destroy the instances left on
+	// MIG GPUs no allocation uses, so their whole geometry is free again.
+	MigIdleReset *bool `yaml:"migIdleReset" json:"migidlereset"`
+	// DeviceHealthRecovery controls cordoning of devices that fail health
+	// checks and when they are returned to service.
+	DeviceHealthRecovery *DeviceHealthRecovery `yaml:"deviceHealthRecovery" json:"devicehealthrecovery"`
+}
+
+// DeviceHealthRecovery holds the rules the device plugin applies to devices
+// its health checks mark unhealthy. Without any recovery rule a device stays
+// unhealthy until the device plugin restarts.
+type DeviceHealthRecovery struct {
+	// AutoCordon adds unhealthy devices to the DeviceCordonAnnotation and
+	// removes them once they recover.
+	AutoCordon bool `yaml:"autoCordon" json:"autocordon"`
+	// RecoverAfterSeconds recovers a device once it has gone this long
+	// without a new critical XID and still answers NVML. 0 disables it.
+	RecoverAfterSeconds int64 `yaml:"recoverAfterSeconds" json:"recoverafterseconds"`
+	// RecoverOnReset recovers a device that stopped answering NVML, or had
+	// pages or rows pending retirement, once a GPU reset cleared that.
+	RecoverOnReset bool `yaml:"recoverOnReset" json:"recoveronreset"`
+	// MaxRecoveries caps how often a device may recover before it stays
+	// unhealthy. 0 means no limit.
+	MaxRecoveries int32 `yaml:"maxRecoveries" json:"maxrecoveries"`
 }
 
 type FilterDevice struct {
@@ -134,16 +183,25 @@ type FilterDevice struct {
 	Index []uint `json:"index"`
 }
 
+// NodeConfig is one entry of the device plugin's nodeconfig list. An entry
+// applies to a node when Name equals the node name or NodeSelector matches the
+// node labels; when both are set, both must match. Entries selected by labels
+// only are applied first, in file order, and entries naming the node last, so
+// a name always wins over a node group default.
+type NodeConfig struct {
+	// These configs is shared and will overwrite those in NvidiaConfig.
+	NodeDefaultConfig            `json:",inline"`
+	Name                         string                `json:"name"`
+	NodeSelector                 *metav1.LabelSelector `json:"nodeselector,omitempty"`
+	OperatingMode                string                `json:"operatingmode"`
+	OperatingModes               []string              `json:"operatingmodes,omitempty"` // Further modes idle GPUs may switch to.
+	Migstrategy                  string                `json:"migstrategy"`
+	FilterDevice                 *FilterDevice         `json:"filterdevices"`
+	EnableGetPreferredAllocation bool                  `json:"enablegetpreferredallocation"`
+}
+
 type DevicePluginConfigs struct {
-	Nodeconfig []struct {
-		// These configs is shared and will overwrite those in NvidiaConfig.
-		NodeDefaultConfig            `json:",inline"`
-		Name                         string        `json:"name"`
-		OperatingMode                string        `json:"operatingmode"`
-		Migstrategy                  string        `json:"migstrategy"`
-		FilterDevice                 *FilterDevice `json:"filterdevices"`
-		EnableGetPreferredAllocation bool          `json:"enablegetpreferredallocation"`
-	} `json:"nodeconfig"`
+	Nodeconfig []NodeConfig `json:"nodeconfig"`
 }
 
 type DeviceConfig struct {
@@ -165,7 +223,7 @@ func InitNvidiaDevice(nvconfig NvidiaConfig) *NvidiaGPUDevices {
 	_, ok := device.InRequestDevices[NvidiaGPUDevice]
 	if !ok {
 		device.InRequestDevices[NvidiaGPUDevice] = "hami.io/vgpu-devices-to-allocate"
-		device.SupportDevices[NvidiaGPUDevice] = "hami.io/vgpu-devices-allocated"
+		device.SupportDevices[NvidiaGPUDevice] = AllocatedDevicesAnnos
 		util.HandshakeAnnos[NvidiaGPUDevice] = HandshakeAnnos
 	}
 	if err := ValidateMigProfileAllowlist(nvconfig.MigProfileAllowlist); err != nil {
@@ -282,7 +340,7 @@ func (dev *NvidiaGPUDevices) LockNode(n *corev1.Node, p *corev1.Pod) error {
 	if !found {
 		return nil
 	}
-	return nodelock.LockNode(n.Name, NodeLockNvidia, p)
+	return nodelock.LockNodeForPod(n.Name, NodeLockNvidia, p, int(dev.config.ConcurrentAllocations))
 }
 
 func (dev *NvidiaGPUDevices) ReleaseNodeLock(n *corev1.Node, p *corev1.Pod) error {
@@ -492,8 +550,7 @@ func assertNuma(annos map[string]string) bool {
 
 func (dev *NvidiaGPUDevices) checkType(annos map[string]string, d device.DeviceUsage, n device.ContainerDeviceRequest) (bool, bool) {
 	typeCheck := checkGPUtype(annos, d.Type)
-	mode, ok := annos[AllocateMode]
-	if ok && !strings.Contains(mode, d.Mode) {
+	if _, ok := selectDeviceMode(annos, d); !ok {
 		typeCheck = false
 	}
 	if strings.Compare(n.Type, NvidiaGPUDevice) == 0 {
@@ -511,6 +568,9 @@ func (dev *NvidiaGPUDevices) PatchAnnotations(pod *corev1.Pod, annoinput *map[st
 		if allocationData, hasAllocations := EncodeMigAllocations(devlist); hasAllocations {
 			(*annoinput)[MigAllocationsAnnotation] = allocationData
 		}
+		if modes, hasModes := EncodeDeviceModes(devlist); hasModes {
+			(*annoinput)[DeviceModesAnnotation] = modes
+		}
 		klog.V(5).Infof("pod add notation key [%s], values is [%s]", device.InRequestDevices[NvidiaGPUDevice], deviceStr)
 		klog.V(5).Infof("pod add notation key [%s], values is [%s]", device.SupportDevices[NvidiaGPUDevice], deviceStr)
 	}
@@ -601,24 +661,28 @@ func (dev *NvidiaGPUDevices) GenerateResourceRequests(ctr *corev1.Container) dev
 
 func (dev *NvidiaGPUDevices) CustomFilterRule(allocated *device.PodDevices, request device.ContainerDeviceRequest, toAllocate device.ContainerDevices, devusage *device.DeviceUsage) bool {
 	if devusage.Mode == MigMode {
-		occupied := occupiedMigPlacements(devusage.MigAllocationsInUse)
+		allocations := slices.Clone(devusage.MigAllocationsInUse)
 		for _, existing := range toAllocate {
 			if existing.UUID != devusage.ID {
 				continue
 			}
-			_, placement, ok := selectMigCandidate(devusage.MigProfiles, occupied, existing.Usedmem)
+			_, allocation, ok := selectMigCandidate(devusage.MigProfiles, allocations, existing.Usedmem)
 			if !ok {
 				return false
 			}
-			occupied = append(occupied, placement)
+			allocations = append(allocations, allocation)
 		}
-		_, _, ok := selectMigCandidate(devusage.MigProfiles, occupied, request.Memreq)
+		_, _, ok := selectMigCandidate(devusage.MigProfiles, allocations, request.Memreq)
 		return ok
 	}
 	return true
 }
 
-func selectMigCandidate(profiles []device.MigProfile, occupied []device.MigPlacement, memory int32) (device.MigProfile, device.MigPlacement, bool) {
+// selectMigCandidate picks the smallest profile holding memory that can still
+// be placed next to allocations, and returns the allocation it would make.
+// Compute instance profiles share the memory of their GPU instance, so they
+// are only used when no GPU instance profile can be placed.
+func selectMigCandidate(profiles []device.MigProfile, allocations []device.MigAllocation, memory int32) (device.MigProfile, device.MigAllocation, bool) {
 	candidates := append([]device.MigProfile(nil), profiles...)
 	sort.SliceStable(candidates, func(i, j int) bool {
 		if candidates[i].MemoryMB != candidates[j].MemoryMB {
@@ -626,16 +690,25 @@ func selectMigCandidate(profiles []device.MigProfile, occupied []device.MigPlace
 		}
 		return candidates[i].SliceCount < candidates[j].SliceCount
 	})
+	occupied := occupiedMigPlacements(allocations)
 	for _, profile := range candidates {
-		if profile.MemoryMB < memory {
+		if profile.MemoryMB < memory || profile.ComputeSlices > 0 {
 			continue
 		}
 		placement, ok := selectMigPlacement(profiles, occupied, profile.Name)
 		if ok {
-			return profile, placement, true
+			return profile, device.MigAllocation{Profile: profile.Name, Placement: placement}, true
+		}
+	}
+	for _, profile := range candidates {
+		if profile.MemoryMB < memory || profile.ComputeSlices == 0 {
+			continue
+		}
+		if allocation, ok := selectMigComputePlacement(profiles, allocations, profile); ok {
+			return profile, allocation, true
 		}
 	}
-	return device.MigProfile{}, device.MigPlacement{}, false
+	return device.MigProfile{}, device.MigAllocation{}, false
 }
 
 func (dev *NvidiaGPUDevices) ScoreNode(node *corev1.Node, podDevices device.PodSingleDevice, previous []*device.DeviceUsage, policy string) float32 {
@@ -643,8 +716,11 @@ func (dev *NvidiaGPUDevices) ScoreNode(node *corev1.Node, podDevices device.PodS
 }
 
 func (dev *NvidiaGPUDevices) AddResourceUsage(pod *corev1.Pod, n *device.DeviceUsage, ctr *device.ContainerDevice) error {
+	if mode, ok := ctr.CustomInfo[DeviceModeCustomInfo].(string); ok {
+		n.Mode = mode
+	}
 	if n.Mode == MigMode {
-		profile, placement, ok := selectMigCandidate(n.MigProfiles, occupiedMigPlacements(n.MigAllocationsInUse), ctr.Usedmem)
+		profile, allocation, ok := selectMigCandidate(n.MigProfiles, n.MigAllocationsInUse, ctr.Usedmem)
 		if !ok {
 			return errors.New("MIG profile and placement allocation failed")
 		}
@@ -654,8 +730,11 @@ func (dev *NvidiaGPUDevices) AddResourceUsage(pod *corev1.Pod, n *device.DeviceU
 			ctr.CustomInfo = make(map[string]any)
 		}
 		ctr.CustomInfo[MigProfileCustomInfo] = profile.Name
-		ctr.CustomInfo[MigPlacementCustomInfo] = placement
-		n.MigAllocationsInUse = append(n.MigAllocationsInUse, device.MigAllocation{Profile: profile.Name, Placement: placement})
+		ctr.CustomInfo[MigPlacementCustomInfo] = allocation.Placement
+		if allocation.ComputePlacement.Size > 0 {
+			ctr.CustomInfo[MigComputePlacementCustomInfo] = allocation.ComputePlacement
+		}
+		n.MigAllocationsInUse = append(n.MigAllocationsInUse, allocation)
 	}
 	n.Used++
 	n.Usedcores += ctr.Usedcores
@@ -724,6 +803,7 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 	needTopology := util.PolicyContains(gpuPolicy, util.GPUSchedulerPolicyTopology)
 	isMutex := util.PolicyContains(gpuPolicy, util.GPUSchedulerPolicyMutex)
 	cordoned := cordonedDevices(nodeInfo)
+	devices = preferUnfragmentedMigDevices(devices, pod.GetAnnotations(), k)
 	for i := len(devices) - 1; i >= 0; i-- {
 		dev := devices[i]
 		klog.V(4).InfoS("scoring pod", "pod", klog.KObj(pod), "device", dev.ID, "Memreq", k.Memreq, "MemPercentagereq", k.MemPercentagereq, "Coresreq", k.Coresreq, "Nums", k.Nums, "device index", i)
@@ -743,6 +823,7 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			klog.V(5).InfoS(common.CardTypeMismatch, "pod", klog.KObj(pod), "device", dev.ID, dev.Type, k.Type)
 			continue
 		}
+		mode, _ := selectDeviceMode(pod.GetAnnotations(), *dev)
 		if numa && prevnuma != dev.Numa {
 			if k.Nums != originReq {
 				reason[common.NumaNotFit] += len(tmpDevs[k.Type])
@@ -764,7 +845,7 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			klog.V(5).InfoS(common.CardTimeSlicingExhausted, "pod", klog.KObj(pod), "device", dev.ID, "count", dev.Count, "used", dev.Used)
 			continue
 		}
-		if isMutex && dev.Used > 0 {
+		if (isMutex || mode == ExclusiveMode) && dev.Used > 0 {
 			reason[common.ExclusiveDeviceAllocateConflict]++
 			klog.V(5).InfoS(common.ExclusiveDeviceAllocateConflict, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "used", dev.Used)
 			continue
@@ -781,9 +862,14 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			//This incurs an issue
 			memreq = dev.Totalmem * k.MemPercentagereq / 100
 		}
-		if !fitQuota(pod, tmpDevs, allocated, pod.Namespace, dev.ID, int64(memreq), int64(k.Coresreq)) {
+		coresreq := k.Coresreq
+		if mode == ExclusiveMode {
+			// An exclusive pod is accounted the whole device.
+			memreq, coresreq = dev.Totalmem, dev.Totalcore
+		}
+		if !fitQuota(pod, tmpDevs, allocated, pod.Namespace, dev.ID, int64(memreq), int64(coresreq)) {
 			reason[common.ResourceQuotaNotFit]++
-			klog.V(3).InfoS(common.ResourceQuotaNotFit, "pod", pod.Name, "memreq", memreq, "coresreq", k.Coresreq)
+			klog.V(3).InfoS(common.ResourceQuotaNotFit, "pod", pod.Name, "memreq", memreq, "coresreq", coresreq)
 			continue
 		}
 		if dev.Totalmem-dev.Usedmem < memreq {
@@ -791,19 +877,19 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			klog.V(5).InfoS(common.CardInsufficientMemory, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total memory", dev.Totalmem, "device used memory", dev.Usedmem, "request memory", memreq)
 			continue
 		}
-		if dev.Totalcore-dev.Usedcores < k.Coresreq {
+		if dev.Totalcore-dev.Usedcores < coresreq {
 			reason[common.CardInsufficientCore]++
-			klog.V(5).InfoS(common.CardInsufficientCore, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total core", dev.Totalcore, "device used core", dev.Usedcores, "request cores", k.Coresreq)
+			klog.V(5).InfoS(common.CardInsufficientCore, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "device total core", dev.Totalcore, "device used core", dev.Usedcores, "request cores", coresreq)
 			continue
 		}
 		// Coresreq=100 indicates it want this card exclusively
-		if dev.Totalcore == 100 && k.Coresreq == 100 && dev.Used > 0 {
+		if dev.Totalcore == 100 && coresreq == 100 && dev.Used > 0 {
 			reason[common.ExclusiveDeviceAllocateConflict]++
 			klog.V(5).InfoS(common.ExclusiveDeviceAllocateConflict, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "used", dev.Used)
 			continue
 		}
 		// You can't allocate core=0 job to an already full GPU
-		if dev.Totalcore != 0 && dev.Usedcores == dev.Totalcore && k.Coresreq == 0 {
+		if dev.Totalcore != 0 && dev.Usedcores == dev.Totalcore && coresreq == 0 {
 			reason[common.CardComputeUnitsExhausted]++
 			klog.V(5).InfoS(common.CardComputeUnitsExhausted, "pod", klog.KObj(pod), "device", dev.ID, "device index", i)
 			continue
@@ -811,12 +897,20 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 		// CustomFilterRule must see the resolved memory request, not the raw (possibly zero) Memreq field.
 		resolvedReq := request
 		resolvedReq.Memreq = memreq
-		if !nv.CustomFilterRule(allocated, resolvedReq, tmpDevs[k.Type], dev) {
+		resolvedReq.Coresreq = coresreq
+		candidate := dev
+		if mode != dev.Mode {
+			// Filter as if the idle device had been switched already.
+			switched := *dev
+			switched.Mode = mode
+			candidate = &switched
+		}
+		if !nv.CustomFilterRule(allocated, resolvedReq, tmpDevs[k.Type], candidate) {
 			// In MIG mode, CustomFilterRule rejects when the requested memory
 			// does not fit an allowed profile with a free placement on this
 			// device. Surface this as a distinct reason so users can tell
 			// placement infeasibility apart from generic filter failure.
-			if dev.Mode == MigMode {
+			if mode == MigMode {
 				reason[common.CardMigTopologyInfeasible]++
 				klog.V(5).InfoS(common.CardMigTopologyInfeasible, "pod", klog.KObj(pod), "device", dev.ID, "device index", i, "allocations", dev.MigAllocationsInUse)
 				continue
@@ -831,19 +925,23 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 			if !needTopology {
 				k.Nums--
 			}
-			tmpDevs[k.Type] = append(tmpDevs[k.Type], device.ContainerDevice{
+			ctrdev := device.ContainerDevice{
 				Idx:       int(dev.Index),
 				UUID:      dev.ID,
 				Type:      k.Type,
 				Usedmem:   memreq,
-				Usedcores: k.Coresreq,
-			})
+				Usedcores: coresreq,
+			}
+			if len(dev.Modes) > 0 {
+				ctrdev.CustomInfo = map[string]any{DeviceModeCustomInfo: mode}
+			}
+			tmpDevs[k.Type] = append(tmpDevs[k.Type], ctrdev)
 		}
 		if k.Nums == 0 && !needTopology {
 			klog.V(4).InfoS("device allocate success", "pod", klog.KObj(pod), "allocate device", tmpDevs)
 			return true, tmpDevs, ""
 		}
-		if dev.Mode == "mig" {
+		if mode == MigMode {
 			i++
 		}
 	}
@@ -877,10 +975,11 @@ func (nv *NvidiaGPUDevices) Fit(devices []*device.DeviceUsage, request device.Co
 
 func (dev *NvidiaGPUDevices) GetResourceNames() device.ResourceNames {
 	return device.ResourceNames{
-		ResourceCountName:  dev.config.ResourceCountName,
-		ResourceMemoryName: dev.config.ResourceMemoryName,
-		ResourceCoreName:   dev.config.ResourceCoreName,
-		MemoryFactor:       dev.config.MemoryFactor,
+		ResourceCountName:            dev.config.ResourceCountName,
+		ResourceMemoryName:           dev.config.ResourceMemoryName,
+		ResourceCoreName:             dev.config.ResourceCoreName,
+		ResourceMemoryPercentageName: dev.config.ResourceMemoryPercentageName,
+		MemoryFactor:                 dev.config.MemoryFactor,
 	}
 }
 
@@ -27,6 +27,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
@@ -2286,6 +2287,36 @@ func TestLockNode(t *testing.T) {
 	}
 }
 
+func TestLockNodeConcurrentAllocations(t *testing.T) {
+	client.KubeClient = fake.NewClientset()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "test-node"}}
+	_, err := client.KubeClient.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+	assert.NilError(t, err)
+
+	dev := InitNvidiaDevice(NvidiaConfig{ResourceCountName: "nvidia.com/gpu", ConcurrentAllocations: 2})
+	for _, name := range []string{"a", "b"} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID("uid-" + name)},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "gpu-app",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"nvidia.com/gpu": *resource.NewQuantity(1, resource.BinarySI),
+				}},
+			}}},
+		}
+		_, err := client.KubeClient.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		assert.NilError(t, dev.LockNode(node, pod), "pod %s", name)
+	}
+
+	updated, err := client.KubeClient.CoreV1().Nodes().Get(context.Background(), "test-node", metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, ok := updated.Annotations[nodelock.NodeLockKey]
+	assert.Assert(t, !ok, "the node-wide lock must not be taken")
+	assert.Assert(t, updated.Annotations[nodelock.PodLockKeyPrefix+"uid-a"] != "")
+	assert.Assert(t, updated.Annotations[nodelock.PodLockKeyPrefix+"uid-b"] != "")
+}
+
 func TestMutateAdmission_Priority(t *testing.T) {
 	dev := &NvidiaGPUDevices{
 		config: NvidiaConfig{
@@ -2641,6 +2672,33 @@ func TestFit_MutexPolicy(t *testing.T) {
 	assert.Equal(t, fit, false)
 }
 
+func TestFit_MpsAccountsLikeHamiCore(t *testing.T) {
+	nv := InitNvidiaDevice(NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+	})
+	for _, mode := range []string{HamiCoreMode, MpsMode} {
+		t.Run(mode, func(t *testing.T) {
+			devices := []*device.DeviceUsage{
+				{ID: "dev-0", Index: 0, Used: 1, Count: 10, Usedmem: 6000, Totalmem: 8000, Usedcores: 80, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, Mode: mode},
+			}
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AllocateMode: mode}}}
+
+			fit, result, _ := nv.Fit(devices, device.ContainerDeviceRequest{Nums: 1, Memreq: 2000, Coresreq: 20, Type: NvidiaGPUDevice}, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, true)
+			assert.Equal(t, result[NvidiaGPUDevice][0].Usedmem, int32(2000))
+			assert.Equal(t, result[NvidiaGPUDevice][0].Usedcores, int32(20))
+
+			fit, _, _ = nv.Fit(devices, device.ContainerDeviceRequest{Nums: 1, Memreq: 3000, Coresreq: 20, Type: NvidiaGPUDevice}, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, false, "memory beyond what is left must not fit")
+			fit, _, _ = nv.Fit(devices, device.ContainerDeviceRequest{Nums: 1, Memreq: 1000, Coresreq: 30, Type: NvidiaGPUDevice}, pod, &device.NodeInfo{}, &device.PodDevices{})
+			assert.Equal(t, fit, false, "cores beyond what is left must not fit")
+		})
+	}
+}
+
 func TestFit_MigPercentageRequestRejectsUndersizedTemplate(t *testing.T) {
 	config := NvidiaConfig{
 		ResourceCountName:            "nvidia.com/gpu",
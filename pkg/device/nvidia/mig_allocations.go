@@ -28,6 +28,9 @@ const MigAllocationsAnnotation = "hami.io/vgpu-mig-allocations"
 const (
 	MigProfileCustomInfo   = "migProfile"
 	MigPlacementCustomInfo = "migPlacement"
+	// MigComputePlacementCustomInfo holds the compute instance placement of
+	// compute instance profiles.
+	MigComputePlacementCustomInfo = "migComputePlacement"
 )
 
 // MigAllocation is the complete scheduler reservation for one MIG device.
@@ -43,6 +46,9 @@ type MigAllocation struct {
 	MigUUID           string              `json:"migUUID,omitempty"`
 	GPUInstanceID     *uint32             `json:"gpuInstanceID,omitempty"`
 	ComputeInstanceID *uint32             `json:"computeInstanceID,omitempty"`
+	// ComputePlacement is set for compute instance profiles such as
+	// 1c.3g.20gb, whose allocations share the GPU instance at Placement.
+	ComputePlacement *device.MigPlacement `json:"computePlacement,omitempty"`
 }
 
 func EncodeMigAllocations(pd device.PodSingleDevice) (string, bool) {
@@ -57,13 +63,17 @@ func EncodeMigAllocations(pd device.PodSingleDevice) (string, bool) {
 			if !profileOK || profile == "" || !placementOK || placement.Size == 0 {
 				continue
 			}
-			out = append(out, MigAllocation{
+			allocation := MigAllocation{
 				ContainerIndex: containerIndex,
 				DeviceIndex:    deviceIndex,
 				GPUUUID:        dev.UUID,
 				Profile:        profile,
 				Placement:      placement,
-			})
+			}
+			if compute, ok := dev.CustomInfo[MigComputePlacementCustomInfo].(device.MigPlacement); ok && compute.Size > 0 {
+				allocation.ComputePlacement = &compute
+			}
+			out = append(out, allocation)
 		}
 	}
 	if len(out) == 0 {
@@ -99,6 +109,9 @@ func DecodeMigAllocations(raw string) ([]MigAllocation, error) {
 		if allocation.ComputeInstanceID != nil {
 			runtimeFields++
 		}
+		if allocation.ComputePlacement != nil && allocation.ComputePlacement.Size == 0 {
+			return nil, fmt.Errorf("MIG allocation %d has an empty compute placement", i)
+		}
 		if runtimeFields != 0 && runtimeFields != 3 {
 			return nil, fmt.Errorf("MIG allocation %d has partial runtime identity", i)
 		}
@@ -0,0 +1,163 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"testing"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+// a100ComputeMigProfiles adds a 1c.3g.20gb unit, a third of a 3g.20gb GPU
+// instance, to the A100 fixture.
+func a100ComputeMigProfiles() []device.MigProfile {
+	profiles := a100MigProfiles()
+	gi := profiles[2]
+	return append(profiles, device.MigProfile{
+		Name: "1c.3g.20gb", MemoryMB: gi.MemoryMB / 3, Core: gi.Core / 3,
+		SliceCount: gi.SliceCount, InstanceCount: gi.InstanceCount * 3, ComputeSlices: 1, Placements: gi.Placements,
+	})
+}
+
+func TestParseMigComputeProfile(t *testing.T) {
+	tests := []struct {
+		profile   string
+		slices    uint32
+		giProfile string
+		ok        bool
+	}{
+		{profile: "1c.3g.20gb", slices: 1, giProfile: "3g.20gb", ok: true},
+		{profile: "2c.4g.20gb", slices: 2, giProfile: "4g.20gb", ok: true},
+		{profile: "3g.20gb"},
+		{profile: "0c.3g.20gb"},
+		{profile: "xc.3g.20gb"},
+		{profile: "1c.20gb"},
+	}
+	for _, test := range tests {
+		slices, giProfile, ok := ParseMigComputeProfile(test.profile)
+		if slices != test.slices || giProfile != test.giProfile || ok != test.ok {
+			t.Fatalf("ParseMigComputeProfile(%q)=(%d,%q,%v), want (%d,%q,%v)", test.profile, slices, giProfile, ok, test.slices, test.giProfile, test.ok)
+		}
+	}
+
+	valid := []device.AllowedMigProfiles{{Models: []string{"A100"}, Profiles: []string{"3g.20gb", "1c.3g.20gb"}}}
+	if err := ValidateMigProfileAllowlist(valid); err != nil {
+		t.Fatalf("compute instance profile rejected: %v", err)
+	}
+	invalid := []device.AllowedMigProfiles{{Models: []string{"A100"}, Profiles: []string{"0c.3g.20gb"}}}
+	if err := ValidateMigProfileAllowlist(invalid); err == nil {
+		t.Fatal("compute instance profile without slices should fail")
+	}
+}
+
+func TestAddResourceUsagePacksComputeInstancesIntoGPUInstance(t *testing.T) {
+	dev := &NvidiaGPUDevices{}
+	// Only the compute instance unit is allowed, as GPU instance profiles
+	// that fit would be picked first.
+	computeOnly := a100ComputeMigProfiles()[3:]
+	usage := &device.DeviceUsage{ID: "GPU-a", Mode: MigMode, MigProfiles: computeOnly}
+	var placements []device.MigPlacement
+	for want := uint32(0); want < 3; want++ {
+		ctr := &device.ContainerDevice{UUID: "GPU-a", Usedmem: 6000}
+		if err := dev.AddResourceUsage(nil, usage, ctr); err != nil {
+			t.Fatalf("allocate compute instance %d: %v", want, err)
+		}
+		if ctr.CustomInfo[MigProfileCustomInfo] != "1c.3g.20gb" || ctr.Usedmem != 6826 || ctr.Usedcores != 14 {
+			t.Fatalf("unexpected reservation: mem=%d cores=%d info=%+v", ctr.Usedmem, ctr.Usedcores, ctr.CustomInfo)
+		}
+		if got := ctr.CustomInfo[MigComputePlacementCustomInfo]; got != (device.MigPlacement{Start: want, Size: 1}) {
+			t.Fatalf("compute placement=%v, want start %d", got, want)
+		}
+		placements = append(placements, ctr.CustomInfo[MigPlacementCustomInfo].(device.MigPlacement))
+	}
+	if placements[0] != placements[1] || placements[0] != placements[2] {
+		t.Fatalf("compute instances spread over GPU instances %v", placements)
+	}
+
+	// The shared GPU instance is full, so the next unit opens the other one.
+	ctr := &device.ContainerDevice{UUID: "GPU-a", Usedmem: 6000}
+	if err := dev.AddResourceUsage(nil, usage, ctr); err != nil {
+		t.Fatalf("allocate fourth compute instance: %v", err)
+	}
+	if got := ctr.CustomInfo[MigPlacementCustomInfo]; got == placements[0] {
+		t.Fatalf("fourth compute instance packed into a full GPU instance %v", got)
+	}
+	if got := ctr.CustomInfo[MigComputePlacementCustomInfo]; got != (device.MigPlacement{Start: 0, Size: 1}) {
+		t.Fatalf("compute placement=%v, want the new GPU instance's first slice", got)
+	}
+
+	// Both 3g GPU instances are taken, leaving no room for a 2g instance.
+	usage.MigProfiles = a100ComputeMigProfiles()
+	if dev.CustomFilterRule(nil, device.ContainerDeviceRequest{Memreq: 10000}, nil, usage) {
+		t.Fatal("2g request should not fit next to two 3g GPU instances")
+	}
+	if !dev.CustomFilterRule(nil, device.ContainerDeviceRequest{Memreq: 6000}, nil, usage) {
+		t.Fatal("compute instance request should fit the second GPU instance")
+	}
+}
+
+func TestSelectMigCandidatePrefersGPUInstances(t *testing.T) {
+	profiles := a100ComputeMigProfiles()
+
+	// The 1c.3g.20gb unit is the smallest profile holding 6000 MiB, but it
+	// shares memory with its neighbours, so the 2g.10gb instance is used.
+	profile, allocation, ok := selectMigCandidate(profiles, nil, 6000)
+	if !ok || profile.Name != "2g.10gb" || allocation.ComputePlacement.Size != 0 {
+		t.Fatalf("selectMigCandidate()=(%s, %+v, %v), want a 2g.10gb GPU instance", profile.Name, allocation, ok)
+	}
+
+	// With no GPU instance left that holds the request, the request falls
+	// back to a free unit of a subdivided GPU instance.
+	allocations := []device.MigAllocation{
+		{Profile: "1c.3g.20gb", Placement: device.MigPlacement{Start: 0, Size: 4}, ComputePlacement: device.MigPlacement{Start: 0, Size: 1}},
+		{Profile: "2g.10gb", Placement: device.MigPlacement{Start: 4, Size: 2}},
+	}
+	profile, allocation, ok = selectMigCandidate(profiles, allocations, 6000)
+	if !ok || profile.Name != "1c.3g.20gb" {
+		t.Fatalf("selectMigCandidate()=(%s, %v), want the 1c.3g.20gb fallback", profile.Name, ok)
+	}
+	if allocation.Placement != (device.MigPlacement{Start: 0, Size: 4}) || allocation.ComputePlacement != (device.MigPlacement{Start: 1, Size: 1}) {
+		t.Fatalf("allocation=%+v, want the shared GPU instance's second slice", allocation)
+	}
+}
+
+func TestEncodeDecodeMigComputeAllocations(t *testing.T) {
+	pd := device.PodSingleDevice{{{
+		UUID: "GPU-a", Type: NvidiaGPUDevice, Usedmem: 6826, Usedcores: 14,
+		CustomInfo: map[string]any{
+			MigProfileCustomInfo:          "1c.3g.20gb",
+			MigPlacementCustomInfo:        device.MigPlacement{Start: 4, Size: 4},
+			MigComputePlacementCustomInfo: device.MigPlacement{Start: 2, Size: 1},
+		},
+	}}}
+	raw, ok := EncodeMigAllocations(pd)
+	if !ok {
+		t.Fatal("expected MIG allocation annotation")
+	}
+	allocations, err := DecodeMigAllocations(raw)
+	if err != nil {
+		t.Fatalf("decode allocation: %v", err)
+	}
+	if len(allocations) != 1 || allocations[0].ComputePlacement == nil || *allocations[0].ComputePlacement != (device.MigPlacement{Start: 2, Size: 1}) {
+		t.Fatalf("compute placement lost: %+v", allocations)
+	}
+
+	raw = `[{"containerIndex":0,"deviceIndex":0,"gpuUUID":"GPU-a","profile":"1c.3g.20gb","placement":{"start":4,"size":4},"computePlacement":{"start":0,"size":0}}]`
+	if _, err := DecodeMigAllocations(raw); err == nil {
+		t.Fatal("empty compute placement should fail")
+	}
+}
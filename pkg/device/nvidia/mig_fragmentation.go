@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+// migSliceCount returns the number of memory slices the GPU's placements span.
+func migSliceCount(profiles []device.MigProfile) uint32 {
+	var total uint32
+	for _, profile := range profiles {
+		for _, placement := range profile.Placements {
+			total = max(total, placement.Start+placement.Size)
+		}
+	}
+	return total
+}
+
+// largestPlaceableMigSize returns the size of the largest profile that still
+// has a free placement next to occupied, i.e. the largest contiguous range a
+// new instance can get on the GPU.
+func largestPlaceableMigSize(profiles []device.MigProfile, occupied []device.MigPlacement) uint32 {
+	var largest uint32
+	for _, profile := range profiles {
+		if len(profile.Placements) == 0 || profile.Placements[0].Size <= largest {
+			continue
+		}
+		if canPlaceMigProfiles(profiles, occupied, []string{profile.Name}) {
+			largest = profile.Placements[0].Size
+		}
+	}
+	return largest
+}
+
+// MigFragmentation returns how much of a MIG GPU's free capacity placement
+// takes away: 0 when the largest profile that fits in the free slices can be
+// placed, approaching 1 as the free slices scatter between running instances.
+func MigFragmentation(profiles []device.MigProfile, allocations []device.MigAllocation) float64 {
+	occupied := occupiedMigPlacements(allocations)
+	var used uint32
+	for _, placement := range occupied {
+		used += placement.Size
+	}
+	total := migSliceCount(profiles)
+	if used >= total {
+		return 0
+	}
+	free := total - used
+	// The largest profile the free slices could hold if they were contiguous.
+	var fitting uint32
+	for _, profile := range profiles {
+		if len(profile.Placements) > 0 && profile.Placements[0].Size <= free {
+			fitting = max(fitting, profile.Placements[0].Size)
+		}
+	}
+	if fitting == 0 {
+		return 0
+	}
+	return 1 - float64(largestPlaceableMigSize(profiles, occupied))/float64(fitting)
+}
+
+// migRemainingRange returns the largest profile size left on dev once a
+// request for memreq is placed on it, or -1 when the request does not fit.
+func migRemainingRange(dev *device.DeviceUsage, memreq int32) int64 {
+	_, allocation, ok := selectMigCandidate(dev.MigProfiles, dev.MigAllocationsInUse, memreq)
+	if !ok {
+		return -1
+	}
+	occupied := occupiedMigPlacements(append(slices.Clone(dev.MigAllocationsInUse), allocation))
+	return int64(largestPlaceableMigSize(dev.MigProfiles, occupied))
+}
+
+// preferUnfragmentedMigDevices reorders the devices a request would use in MIG
+// mode so that Fit, which walks devices from the end, first tries the GPU left
+// with the largest contiguous free range. Other devices keep their positions,
+// and ties keep the scheduling policy's order. NUMA-bound requests are left
+// alone since Fit relies on NUMA groups staying contiguous.
+func preferUnfragmentedMigDevices(devices []*device.DeviceUsage, annos map[string]string, request device.ContainerDeviceRequest) []*device.DeviceUsage {
+	if assertNuma(annos) {
+		return devices
+	}
+	var positions []int
+	for i, dev := range devices {
+		if mode, ok := selectDeviceMode(annos, *dev); ok && mode == MigMode && len(dev.MigProfiles) > 0 {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) < 2 {
+		return devices
+	}
+	ranges := make(map[string]int64, len(positions))
+	migDevices := make([]*device.DeviceUsage, 0, len(positions))
+	for _, i := range positions {
+		dev := devices[i]
+		memreq := request.Memreq
+		if request.MemPercentagereq != 101 && request.Memreq == 0 {
+			memreq = dev.Totalmem * request.MemPercentagereq / 100
+		}
+		ranges[dev.ID] = migRemainingRange(dev, memreq)
+		migDevices = append(migDevices, dev)
+	}
+	sort.SliceStable(migDevices, func(i, j int) bool {
+		return ranges[migDevices[i].ID] < ranges[migDevices[j].ID]
+	})
+	ordered := slices.Clone(devices)
+	for n, i := range positions {
+		ordered[i] = migDevices[n]
+	}
+	return ordered
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+func migAllocationAt(profile string, start, size uint32) device.MigAllocation {
+	return device.MigAllocation{Profile: profile, Placement: device.MigPlacement{Start: start, Size: size}}
+}
+
+func TestMigFragmentation(t *testing.T) {
+	tests := []struct {
+		name        string
+		allocations []device.MigAllocation
+		want        float64
+	}{
+		{name: "empty GPU"},
+		{
+			name:        "free slices still hold a 3g instance",
+			allocations: []device.MigAllocation{migAllocationAt("1g.5gb", 6, 1)},
+		},
+		{
+			name:        "a 1g instance in the middle blocks both 3g placements",
+			allocations: []device.MigAllocation{migAllocationAt("1g.5gb", 3, 1), migAllocationAt("1g.5gb", 6, 1)},
+			want:        0.5,
+		},
+		{
+			name:        "full GPU",
+			allocations: []device.MigAllocation{migAllocationAt("3g.20gb", 0, 4), migAllocationAt("3g.20gb", 4, 4)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := MigFragmentation(a100MigProfiles(), test.allocations); got != test.want {
+				t.Fatalf("fragmentation=%v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFitPrefersLeastFragmentedMigDevice(t *testing.T) {
+	nv := InitNvidiaDevice(NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+	})
+	newDevices := func() []*device.DeviceUsage {
+		return []*device.DeviceUsage{
+			{ID: "GPU-open", Index: 0, Used: 1, Count: 7, Totalmem: 40960, Usedmem: 5120, Totalcore: 100, Usedcores: 14, Type: NvidiaGPUDevice, Health: true, Mode: MigMode,
+				MigProfiles: a100MigProfiles(), MigAllocationsInUse: []device.MigAllocation{migAllocationAt("1g.5gb", 6, 1)}},
+			{ID: "GPU-split", Index: 1, Used: 1, Count: 7, Totalmem: 40960, Usedmem: 5120, Totalcore: 100, Usedcores: 14, Type: NvidiaGPUDevice, Health: true, Mode: MigMode,
+				MigProfiles: a100MigProfiles(), MigAllocationsInUse: []device.MigAllocation{migAllocationAt("1g.5gb", 3, 1)}},
+		}
+	}
+	request := device.ContainerDeviceRequest{Nums: 1, Memreq: 5000, Type: NvidiaGPUDevice}
+
+	// Fit walks devices from the end, so without scoring GPU-split would be
+	// picked and lose its last 3g placement.
+	fit, result, reason := nv.Fit(newDevices(), request, &corev1.Pod{}, &device.NodeInfo{}, &device.PodDevices{})
+	if !fit {
+		t.Fatalf("fit failed: %s", reason)
+	}
+	if got := result[NvidiaGPUDevice][0].UUID; got != "GPU-open" {
+		t.Fatalf("picked %s, want the GPU left with a 3g placement", got)
+	}
+
+	// NUMA-bound requests keep the policy order.
+	numaPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{NumaBind: "true"}}}
+	fit, result, reason = nv.Fit(newDevices(), request, numaPod, &device.NodeInfo{}, &device.PodDevices{})
+	if !fit {
+		t.Fatalf("fit failed: %s", reason)
+	}
+	if got := result[NvidiaGPUDevice][0].UUID; got != "GPU-split" {
+		t.Fatalf("picked %s, want the policy's first choice", got)
+	}
+}
@@ -18,6 +18,7 @@ package nvidia
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
@@ -31,11 +32,36 @@ func ValidateMigProfileAllowlist(in []device.AllowedMigProfiles) error {
 			return fmt.Errorf("MIG profile allowlist must define models and profiles")
 		}
 		for _, profile := range cfg.Profiles {
-			parts := strings.SplitN(profile, ".", 2)
-			if len(parts) != 2 || len(parts[0]) < 2 || !strings.HasSuffix(parts[0], "g") {
+			if strings.Contains(profile, "c.") {
+				if _, _, ok := ParseMigComputeProfile(profile); !ok {
+					return fmt.Errorf("invalid MIG compute instance profile %q", profile)
+				}
+				continue
+			}
+			if !validGPUInstanceProfile(profile) {
 				return fmt.Errorf("invalid MIG profile %q", profile)
 			}
 		}
 	}
 	return nil
 }
+
+func validGPUInstanceProfile(profile string) bool {
+	parts := strings.SplitN(profile, ".", 2)
+	return len(parts) == 2 && len(parts[0]) >= 2 && strings.HasSuffix(parts[0], "g")
+}
+
+// ParseMigComputeProfile splits a compute instance profile such as 1c.3g.20gb
+// into its compute slice count and GPU instance profile (3g.20gb). It reports
+// false for GPU instance profiles and malformed names.
+func ParseMigComputeProfile(profile string) (uint32, string, bool) {
+	prefix, giProfile, ok := strings.Cut(profile, ".")
+	if !ok || !strings.HasSuffix(prefix, "c") || !validGPUInstanceProfile(giProfile) {
+		return 0, "", false
+	}
+	slices, err := strconv.ParseUint(strings.TrimSuffix(prefix, "c"), 10, 32)
+	if err != nil || slices == 0 {
+		return 0, "", false
+	}
+	return uint32(slices), giProfile, true
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package nvidia
 
 import (
+	"slices"
 	"sort"
 	"strings"
 
@@ -47,14 +48,62 @@ func findMigProfile(profiles []device.MigProfile, name string) (device.MigProfil
 	return device.MigProfile{}, false
 }
 
+// occupiedMigPlacements returns the GPU instance placements in use. Compute
+// instance allocations sharing a GPU instance are listed once.
 func occupiedMigPlacements(allocations []device.MigAllocation) []device.MigPlacement {
 	out := make([]device.MigPlacement, 0, len(allocations))
 	for _, allocation := range allocations {
-		out = append(out, allocation.Placement)
+		if !slices.Contains(out, allocation.Placement) {
+			out = append(out, allocation.Placement)
+		}
 	}
 	return out
 }
 
+// selectMigComputePlacement places a compute instance profile. It packs the
+// unit into a GPU instance already subdivided for the same GPU instance
+// profile when one has free compute slices, and opens a new GPU instance
+// otherwise.
+func selectMigComputePlacement(profiles []device.MigProfile, allocations []device.MigAllocation, profile device.MigProfile) (device.MigAllocation, bool) {
+	_, giProfile, _ := ParseMigComputeProfile(profile.Name)
+	shared := map[device.MigPlacement][]device.MigPlacement{}
+	var order []device.MigPlacement
+	for _, allocation := range allocations {
+		if allocation.ComputePlacement.Size == 0 {
+			continue
+		}
+		if _, other, ok := ParseMigComputeProfile(allocation.Profile); !ok || other != giProfile {
+			continue
+		}
+		if _, ok := shared[allocation.Placement]; !ok {
+			order = append(order, allocation.Placement)
+		}
+		shared[allocation.Placement] = append(shared[allocation.Placement], allocation.ComputePlacement)
+	}
+	for _, placement := range order {
+		if compute, ok := freeMigComputePlacement(profile, shared[placement]); ok {
+			return device.MigAllocation{Profile: profile.Name, Placement: placement, ComputePlacement: compute}, true
+		}
+	}
+	placement, ok := selectMigPlacement(profiles, occupiedMigPlacements(allocations), profile.Name)
+	if !ok {
+		return device.MigAllocation{}, false
+	}
+	return device.MigAllocation{Profile: profile.Name, Placement: placement, ComputePlacement: device.MigPlacement{Start: 0, Size: profile.ComputeSlices}}, true
+}
+
+// freeMigComputePlacement returns the lowest aligned compute placement of
+// profile that none of used overlaps within its GPU instance.
+func freeMigComputePlacement(profile device.MigProfile, used []device.MigPlacement) (device.MigPlacement, bool) {
+	for start := uint32(0); start+profile.ComputeSlices <= profile.SliceCount; start += profile.ComputeSlices {
+		candidate := device.MigPlacement{Start: start, Size: profile.ComputeSlices}
+		if !slices.ContainsFunc(used, func(existing device.MigPlacement) bool { return migPlacementsOverlap(candidate, existing) }) {
+			return candidate, true
+		}
+	}
+	return device.MigPlacement{}, false
+}
+
 func selectMigPlacement(profiles []device.MigProfile, occupied []device.MigPlacement, profileName string) (device.MigPlacement, bool) {
 	profile, ok := findMigProfile(profiles, profileName)
 	if !ok {
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+// DeviceModesAnnotation records, for pods placed on GPUs that can switch
+// isolation modes, the mode the scheduler picked for each GPU as a JSON
+// object keyed by GPU UUID. The device plugin switches the GPUs to those
+// modes in Allocate.
+const DeviceModesAnnotation = "hami.io/vgpu-device-modes"
+
+// DeviceModeCustomInfo is the ContainerDevice.CustomInfo key carrying the mode
+// picked for the device from Fit to PatchAnnotations.
+const DeviceModeCustomInfo = "deviceMode"
+
+// KnownModes are the isolation modes a GPU can run pods in.
+var KnownModes = []string{HamiCoreMode, MigMode, MpsMode, ExclusiveMode}
+
+// ValidateModes checks that every entry of modes is a known isolation mode.
+func ValidateModes(modes []string) error {
+	for _, mode := range modes {
+		if !slices.Contains(KnownModes, mode) {
+			return fmt.Errorf("unknown operating mode %q, expected one of %s", mode, strings.Join(KnownModes, ", "))
+		}
+	}
+	return nil
+}
+
+// requestedModes returns the modes listed in the pod's AllocateMode
+// annotation, in order of preference. None means any mode.
+func requestedModes(annos map[string]string) []string {
+	var modes []string
+	for mode := range strings.SplitSeq(annos[AllocateMode], ",") {
+		if mode = strings.TrimSpace(mode); mode != "" {
+			modes = append(modes, mode)
+		}
+	}
+	return modes
+}
+
+// selectDeviceMode returns the mode d would run the pod in and whether there
+// is one the pod accepts. A GPU in use keeps its mode. An idle GPU stays in
+// its mode if the pod accepts it and otherwise switches to the first mode the
+// pod prefers among those the GPU supports.
+func selectDeviceMode(annos map[string]string, d device.DeviceUsage) (string, bool) {
+	requested := requestedModes(annos)
+	if len(requested) == 0 || d.Mode == "" || slices.Contains(requested, d.Mode) {
+		return d.Mode, true
+	}
+	if d.Used > 0 {
+		return "", false
+	}
+	for _, mode := range requested {
+		if slices.Contains(d.Modes, mode) {
+			return mode, true
+		}
+	}
+	return "", false
+}
+
+// EncodeDeviceModes returns the DeviceModesAnnotation value for the modes Fit
+// picked for pd, and false when it picked none.
+func EncodeDeviceModes(pd device.PodSingleDevice) (string, bool) {
+	modes := make(map[string]string)
+	for _, ctr := range pd {
+		for _, dev := range ctr {
+			if mode, ok := dev.CustomInfo[DeviceModeCustomInfo].(string); ok && mode != "" {
+				modes[dev.UUID] = mode
+			}
+		}
+	}
+	if len(modes) == 0 {
+		return "", false
+	}
+	raw, err := json.Marshal(modes)
+	if err != nil {
+		return "", false
+	}
+	return string(raw), true
+}
+
+// DecodeDeviceModes parses a DeviceModesAnnotation value into a map from GPU
+// UUID to mode.
+func DecodeDeviceModes(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var modes map[string]string
+	if err := json.Unmarshal([]byte(raw), &modes); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", DeviceModesAnnotation, err)
+	}
+	for uuid, mode := range modes {
+		if err := ValidateModes([]string{mode}); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation for GPU %s: %w", DeviceModesAnnotation, uuid, err)
+		}
+	}
+	return modes, nil
+}
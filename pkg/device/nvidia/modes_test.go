@@ -0,0 +1,158 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+func Test_selectDeviceMode(t *testing.T) {
+	switchable := []string{HamiCoreMode, MpsMode, MigMode}
+	tests := []struct {
+		name     string
+		annos    map[string]string
+		dev      device.DeviceUsage
+		wantMode string
+		wantOK   bool
+	}{
+		{
+			name:     "no preference keeps the mode",
+			dev:      device.DeviceUsage{Mode: HamiCoreMode, Modes: switchable},
+			wantMode: HamiCoreMode,
+			wantOK:   true,
+		},
+		{
+			name:     "accepted mode is kept",
+			annos:    map[string]string{AllocateMode: "mps, hami-core"},
+			dev:      device.DeviceUsage{Mode: HamiCoreMode, Modes: switchable},
+			wantMode: HamiCoreMode,
+			wantOK:   true,
+		},
+		{
+			name:     "idle device switches to the first preference it supports",
+			annos:    map[string]string{AllocateMode: "exclusive,mig,mps"},
+			dev:      device.DeviceUsage{Mode: HamiCoreMode, Modes: switchable},
+			wantMode: MigMode,
+			wantOK:   true,
+		},
+		{
+			name:  "busy device does not switch",
+			annos: map[string]string{AllocateMode: "mig"},
+			dev:   device.DeviceUsage{Mode: HamiCoreMode, Modes: switchable, Used: 1},
+		},
+		{
+			name:  "device without switchable modes",
+			annos: map[string]string{AllocateMode: "mig"},
+			dev:   device.DeviceUsage{Mode: HamiCoreMode},
+		},
+		{
+			name:     "unregistered mode accepts anything",
+			annos:    map[string]string{AllocateMode: "mig"},
+			dev:      device.DeviceUsage{},
+			wantMode: "",
+			wantOK:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mode, ok := selectDeviceMode(test.annos, test.dev)
+			assert.Equal(t, ok, test.wantOK)
+			assert.Equal(t, mode, test.wantMode)
+		})
+	}
+}
+
+func TestDeviceModesRoundTrip(t *testing.T) {
+	_, ok := EncodeDeviceModes(device.PodSingleDevice{{{UUID: "GPU-a"}}})
+	assert.Assert(t, !ok, "devices without a picked mode are not recorded")
+
+	raw, ok := EncodeDeviceModes(device.PodSingleDevice{
+		{{UUID: "GPU-a", CustomInfo: map[string]any{DeviceModeCustomInfo: MpsMode}}},
+		{{UUID: "GPU-b", CustomInfo: map[string]any{DeviceModeCustomInfo: ExclusiveMode}}},
+	})
+	assert.Assert(t, ok)
+	modes, err := DecodeDeviceModes(raw)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, modes, map[string]string{"GPU-a": MpsMode, "GPU-b": ExclusiveMode})
+
+	_, err = DecodeDeviceModes(`{"GPU-a":"turbo"}`)
+	assert.ErrorContains(t, err, `unknown operating mode "turbo"`)
+}
+
+func TestFit_SwitchesIdleDeviceMode(t *testing.T) {
+	nv := InitNvidiaDevice(NvidiaConfig{
+		ResourceCountName:            "nvidia.com/gpu",
+		ResourceMemoryName:           "nvidia.com/gpumem",
+		ResourceCoreName:             "nvidia.com/gpucores",
+		ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+	})
+	modes := []string{HamiCoreMode, MpsMode, MigMode, ExclusiveMode}
+	newDevices := func() []*device.DeviceUsage {
+		return []*device.DeviceUsage{
+			{ID: "idle", Index: 0, Count: 10, Totalmem: 40000, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, Mode: HamiCoreMode, Modes: modes, MigProfiles: a100MigProfiles()},
+			{ID: "busy", Index: 1, Used: 1, Usedmem: 1000, Usedcores: 10, Count: 10, Totalmem: 40000, Totalcore: 100, Type: NvidiaGPUDevice, Health: true, Mode: HamiCoreMode, Modes: modes, MigProfiles: a100MigProfiles()},
+		}
+	}
+	podWithMode := func(mode string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AllocateMode: mode}}}
+	}
+
+	// Without a preference the busy device is shared in its current mode.
+	fit, result, _ := nv.Fit(newDevices(), device.ContainerDeviceRequest{Nums: 1, Memreq: 1000, Coresreq: 10, Type: NvidiaGPUDevice}, &corev1.Pod{}, &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, fit)
+	assert.Equal(t, result[NvidiaGPUDevice][0].UUID, "busy")
+	assert.Equal(t, result[NvidiaGPUDevice][0].CustomInfo[DeviceModeCustomInfo], HamiCoreMode)
+
+	// An MPS pod cannot join the hami-core tenants, so the idle device switches.
+	fit, result, _ = nv.Fit(newDevices(), device.ContainerDeviceRequest{Nums: 1, Memreq: 1000, Coresreq: 10, Type: NvidiaGPUDevice}, podWithMode(MpsMode), &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, fit)
+	assert.Equal(t, result[NvidiaGPUDevice][0].UUID, "idle")
+	assert.Equal(t, result[NvidiaGPUDevice][0].CustomInfo[DeviceModeCustomInfo], MpsMode)
+	annos := nv.PatchAnnotations(&corev1.Pod{}, &map[string]string{}, device.PodDevices{NvidiaGPUDevice: device.PodSingleDevice{result[NvidiaGPUDevice]}})
+	assert.Equal(t, annos[DeviceModesAnnotation], `{"idle":"mps"}`)
+
+	// An exclusive pod is accounted the whole device.
+	fit, result, _ = nv.Fit(newDevices(), device.ContainerDeviceRequest{Nums: 1, Memreq: 1000, Coresreq: 10, Type: NvidiaGPUDevice}, podWithMode(ExclusiveMode), &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, fit)
+	assert.Equal(t, result[NvidiaGPUDevice][0].UUID, "idle")
+	assert.Equal(t, result[NvidiaGPUDevice][0].Usedmem, int32(40000))
+	assert.Equal(t, result[NvidiaGPUDevice][0].Usedcores, int32(100))
+
+	// A MIG pod gets a profile on the idle device switched to MIG mode.
+	devices := newDevices()
+	fit, result, _ = nv.Fit(devices, device.ContainerDeviceRequest{Nums: 1, Memreq: 10000, Type: NvidiaGPUDevice}, podWithMode(MigMode), &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, fit)
+	assert.Equal(t, result[NvidiaGPUDevice][0].UUID, "idle")
+	assert.Equal(t, devices[0].Mode, HamiCoreMode, "Fit must not switch the device itself")
+	assert.NilError(t, nv.AddResourceUsage(podWithMode(MigMode), devices[0], &result[NvidiaGPUDevice][0]))
+	assert.Equal(t, devices[0].Mode, MigMode)
+	assert.Equal(t, result[NvidiaGPUDevice][0].CustomInfo[MigProfileCustomInfo], "2g.10gb")
+
+	// Once used in MIG mode the device only takes MIG pods.
+	fit, _, _ = nv.Fit(devices[:1], device.ContainerDeviceRequest{Nums: 1, Memreq: 1000, Coresreq: 10, Type: NvidiaGPUDevice}, podWithMode(HamiCoreMode), &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, !fit)
+
+	// No idle device is left for an exclusive pod.
+	fit, _, _ = nv.Fit(newDevices()[1:], device.ContainerDeviceRequest{Nums: 1, Type: NvidiaGPUDevice}, podWithMode(ExclusiveMode), &device.NodeInfo{}, &device.PodDevices{})
+	assert.Assert(t, !fit)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+const (
+	// ResizeAnnotation requests new vGPU limits for running containers, as a
+	// JSON object keyed by container name, e.g.
+	// {"train":{"memory":16384,"cores":50}}. Memory is per device in MiB and
+	// cores is a percentage; an omitted field keeps its current value.
+	ResizeAnnotation = "nvidia.com/vgpu-resize"
+	// ResizeStatusAnnotation is written by the scheduler with the outcome of
+	// the last ResizeAnnotation it handled.
+	ResizeStatusAnnotation = "hami.io/vgpu-resize-status"
+
+	ResizeApplied  = "Applied"
+	ResizeRejected = "Rejected"
+	// ResizePending marks a request that lowers a container's memory while
+	// vGPUmonitor checks it against the memory the container uses.
+	ResizePending = "Pending"
+	// ResizeVerified is set by vGPUmonitor on a pending request that leaves
+	// every container enough memory for what it already uses.
+	ResizeVerified = "Verified"
+)
+
+// ResizeTarget holds the new limits for one container.
+type ResizeTarget struct {
+	Memory *int32 `json:"memory,omitempty"`
+	Cores  *int32 `json:"cores,omitempty"`
+}
+
+// ResizeStatus is the value of ResizeStatusAnnotation. Request is the
+// ResizeAnnotation value it answers, so a request is handled exactly once.
+type ResizeStatus struct {
+	Request string `json:"request"`
+	Phase   string `json:"phase"`
+	Message string `json:"message,omitempty"`
+}
+
+// ParseResizeRequest decodes and validates a ResizeAnnotation value.
+func ParseResizeRequest(value string) (map[string]ResizeTarget, error) {
+	var req map[string]ResizeTarget
+	dec := json.NewDecoder(bytes.NewReader([]byte(value)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", ResizeAnnotation, err)
+	}
+	if len(req) == 0 {
+		return nil, fmt.Errorf("invalid %s annotation: no containers", ResizeAnnotation)
+	}
+	for name, target := range req {
+		if target.Memory == nil && target.Cores == nil {
+			return nil, fmt.Errorf("invalid %s annotation: container %s sets neither memory nor cores", ResizeAnnotation, name)
+		}
+		if target.Memory != nil && *target.Memory <= 0 {
+			return nil, fmt.Errorf("invalid %s annotation: container %s memory must be positive, got %d", ResizeAnnotation, name, *target.Memory)
+		}
+		if target.Cores != nil && (*target.Cores < 0 || *target.Cores > 100) {
+			return nil, fmt.Errorf("invalid %s annotation: container %s cores must be between 0 and 100, got %d", ResizeAnnotation, name, *target.Cores)
+		}
+	}
+	return req, nil
+}
+
+// DecodeResizeStatus returns the pod's ResizeStatusAnnotation, if any.
+func DecodeResizeStatus(annos map[string]string) (ResizeStatus, bool) {
+	var status ResizeStatus
+	value, ok := annos[ResizeStatusAnnotation]
+	if !ok {
+		return status, false
+	}
+	if err := json.Unmarshal([]byte(value), &status); err != nil {
+		return status, false
+	}
+	return status, true
+}
+
+// EncodeResizeStatus renders status as a ResizeStatusAnnotation value.
+func EncodeResizeStatus(status ResizeStatus) string {
+	data, _ := json.Marshal(status)
+	return string(data)
+}
+
+// ResizeContainerDevices returns a copy of the pod's NVIDIA allocation with
+// the requested containers' per-device limits replaced. Allocation entries
+// are indexed like the pod's init containers followed by its containers.
+func ResizeContainerDevices(pod *corev1.Pod, allocated device.PodSingleDevice, req map[string]ResizeTarget) (device.PodSingleDevice, error) {
+	resized := make(device.PodSingleDevice, len(allocated))
+	for i, ctrDevs := range allocated {
+		resized[i] = append(device.ContainerDevices(nil), ctrDevs...)
+	}
+	for name, target := range req {
+		idx := -1
+		for i, ctr := range pod.Spec.Containers {
+			if ctr.Name == name {
+				idx = len(pod.Spec.InitContainers) + i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("container %s not found", name)
+		}
+		if idx >= len(resized) || len(resized[idx]) == 0 {
+			return nil, fmt.Errorf("container %s has no NVIDIA devices allocated", name)
+		}
+		for i := range resized[idx] {
+			if target.Memory != nil {
+				resized[idx][i].Usedmem = *target.Memory
+			}
+			if target.Cores != nil {
+				resized[idx][i].Usedcores = *target.Cores
+			}
+		}
+	}
+	return resized, nil
+}
+
+// ShrunkContainers returns the requested memory (MiB) of the containers
+// whose memory limit req lowers, keyed by container name.
+func ShrunkContainers(pod *corev1.Pod, allocated device.PodSingleDevice, req map[string]ResizeTarget) map[string]int32 {
+	shrunk := map[string]int32{}
+	for name, target := range req {
+		if target.Memory == nil {
+			continue
+		}
+		if mem, _, ok := ContainerLimits(pod, allocated, name); ok && *target.Memory < mem {
+			shrunk[name] = *target.Memory
+		}
+	}
+	return shrunk
+}
+
+// ContainerLimits returns the memory (MiB) and core limits recorded for
+// container name in the pod's NVIDIA allocation. Resized containers always
+// carry the same limits on every device.
+func ContainerLimits(pod *corev1.Pod, allocated device.PodSingleDevice, name string) (memory int32, cores int32, ok bool) {
+	for i, ctr := range pod.Spec.Containers {
+		if ctr.Name != name {
+			continue
+		}
+		idx := len(pod.Spec.InitContainers) + i
+		if idx >= len(allocated) || len(allocated[idx]) == 0 {
+			return 0, 0, false
+		}
+		return allocated[idx][0].Usedmem, allocated[idx][0].Usedcores, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+)
+
+func TestParseResizeRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{name: "memory and cores", value: `{"app":{"memory":4096,"cores":30}}`},
+		{name: "cores only", value: `{"app":{"cores":0}}`},
+		{name: "not json", value: `app=4096`, wantErr: "invalid nvidia.com/vgpu-resize annotation"},
+		{name: "unknown field", value: `{"app":{"mem":4096}}`, wantErr: `unknown field "mem"`},
+		{name: "empty", value: `{}`, wantErr: "no containers"},
+		{name: "nothing to change", value: `{"app":{}}`, wantErr: "sets neither memory nor cores"},
+		{name: "zero memory", value: `{"app":{"memory":0}}`, wantErr: "memory must be positive"},
+		{name: "cores over 100", value: `{"app":{"cores":101}}`, wantErr: "cores must be between 0 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseResizeRequest(tt.value)
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResizeContainerDevices(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init"}},
+		Containers:     []corev1.Container{{Name: "sidecar"}, {Name: "app"}},
+	}}
+	allocated := device.PodSingleDevice{
+		{{UUID: "GPU0", Usedmem: 2000, Usedcores: 10}},
+		{},
+		{{UUID: "GPU0", Usedmem: 1000, Usedcores: 20}, {UUID: "GPU1", Usedmem: 1000, Usedcores: 20}},
+	}
+	req, err := ParseResizeRequest(`{"app":{"memory":3000}}`)
+	assert.NilError(t, err)
+
+	resized, err := ResizeContainerDevices(pod, allocated, req)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, resized[2], device.ContainerDevices{
+		{UUID: "GPU0", Usedmem: 3000, Usedcores: 20},
+		{UUID: "GPU1", Usedmem: 3000, Usedcores: 20},
+	})
+	assert.Equal(t, allocated[2][0].Usedmem, int32(1000), "the input allocation must not be modified")
+	assert.DeepEqual(t, resized[0], allocated[0])
+
+	mem, cores, ok := ContainerLimits(pod, resized, "app")
+	assert.Assert(t, ok)
+	assert.Equal(t, mem, int32(3000))
+	assert.Equal(t, cores, int32(20))
+	_, _, ok = ContainerLimits(pod, resized, "sidecar")
+	assert.Assert(t, !ok)
+
+	req, err = ParseResizeRequest(`{"app":{"memory":500},"sidecar":{"memory":100}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ShrunkContainers(pod, allocated, req), map[string]int32{"app": 500})
+	assert.Equal(t, len(ShrunkContainers(pod, resized, map[string]ResizeTarget{"app": {Cores: req["app"].Memory}})), 0)
+
+	req, err = ParseResizeRequest(`{"sidecar":{"cores":5}}`)
+	assert.NilError(t, err)
+	_, err = ResizeContainerDevices(pod, allocated, req)
+	assert.ErrorContains(t, err, "container sidecar has no NVIDIA devices allocated")
+	req, err = ParseResizeRequest(`{"init":{"cores":5}}`)
+	assert.NilError(t, err)
+	_, err = ResizeContainerDevices(pod, allocated, req)
+	assert.ErrorContains(t, err, "container init not found")
+}
+
+func TestResizeStatusRoundTrip(t *testing.T) {
+	_, ok := DecodeResizeStatus(map[string]string{})
+	assert.Assert(t, !ok)
+	status := ResizeStatus{Request: `{"app":{"cores":5}}`, Phase: ResizeRejected, Message: "no room"}
+	got, ok := DecodeResizeStatus(map[string]string{ResizeStatusAnnotation: EncodeResizeStatus(status)})
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, got, status)
+}
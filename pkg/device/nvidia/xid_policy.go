@@ -0,0 +1,81 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// XIDAction is what the device plugin does when a GPU reports an XID.
+type XIDAction string
+
+const (
+	// XIDIgnore only counts the XID.
+	XIDIgnore XIDAction = "ignore"
+	// XIDWarn records a warning event on the node.
+	XIDWarn XIDAction = "warn"
+	// XIDUnhealthy marks the device unhealthy.
+	XIDUnhealthy XIDAction = "unhealthy"
+	// XIDDrain marks the device unhealthy and cordons the node, so no new
+	// pods land on it until an operator uncordons it.
+	XIDDrain XIDAction = "drain"
+)
+
+// XIDRule maps XIDs to the action the device plugin takes on them.
+type XIDRule struct {
+	// XIDs lists single XIDs such as "79" and inclusive ranges such as "61-64".
+	XIDs   []string  `yaml:"xids" json:"xids"`
+	Action XIDAction `yaml:"action" json:"action"`
+}
+
+// ParseXIDRange parses a single XID or an inclusive range of XIDs.
+func ParseXIDRange(s string) (uint64, uint64, error) {
+	from, to, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	first, err := strconv.ParseUint(strings.TrimSpace(from), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid XID %q", s)
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	last, err := strconv.ParseUint(strings.TrimSpace(to), 10, 64)
+	if err != nil || last < first {
+		return 0, 0, fmt.Errorf("invalid XID range %q", s)
+	}
+	return first, last, nil
+}
+
+// ValidateXIDRule checks that rule names a known action and at least one
+// valid XID or range.
+func ValidateXIDRule(rule XIDRule) error {
+	switch rule.Action {
+	case XIDIgnore, XIDWarn, XIDUnhealthy, XIDDrain:
+	default:
+		return fmt.Errorf("action must be one of ignore, warn, unhealthy, drain, got %q", rule.Action)
+	}
+	if len(rule.XIDs) == 0 {
+		return fmt.Errorf("XID rule must list XIDs")
+	}
+	for _, xids := range rule.XIDs {
+		if _, _, err := ParseXIDRange(xids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseXIDRange(t *testing.T) {
+	tests := []struct {
+		in          string
+		first, last uint64
+		err         string
+	}{
+		{in: "79", first: 79, last: 79},
+		{in: " 61 - 64 ", first: 61, last: 64},
+		{in: "64-61", err: `invalid XID range "64-61"`},
+		{in: "61-", err: `invalid XID range "61-"`},
+		{in: "all", err: `invalid XID "all"`},
+	}
+	for _, test := range tests {
+		first, last, err := ParseXIDRange(test.in)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, first, test.first)
+		assert.Equal(t, last, test.last)
+	}
+}
+
+func TestValidateXIDRule(t *testing.T) {
+	assert.NilError(t, ValidateXIDRule(XIDRule{XIDs: []string{"48", "61-64"}, Action: XIDUnhealthy}))
+	assert.Error(t, ValidateXIDRule(XIDRule{XIDs: []string{"48"}}), `action must be one of ignore, warn, unhealthy, drain, got ""`)
+	assert.Error(t, ValidateXIDRule(XIDRule{Action: XIDWarn}), "XID rule must list XIDs")
+	assert.Error(t, ValidateXIDRule(XIDRule{XIDs: []string{"4x"}, Action: XIDWarn}), `invalid XID "4x"`)
+}
@@ -59,6 +59,8 @@ type UsageInfo interface {
 	DeviceMemoryOffset(idx int) uint64
 	DeviceMemoryTotal(idx int) uint64
 	DeviceSmUtil(idx int) uint64
+	DeviceSmLimit(idx int) uint64
+	HostPIDs() []int32
 	SetDeviceSmLimit(l uint64)
 	IsValidUUID(idx int) bool
 	DeviceUUID(idx int) string
@@ -75,16 +77,24 @@ type UsageInfo interface {
 
 type ContainerUsage struct {
 	PodUID        string
+	PodNamespace  string
+	PodName       string
 	ContainerName string
 	data          []byte
 	Info          UsageInfo
+
+	// BaseCoreLimit is the core limit allocated to the container and
+	// CoreBurstRatio its nv.CoreBurstRatioAnnotation, or 0 when elastic cores
+	// are off. Both are refreshed from the pod on every Update.
+	BaseCoreLimit  uint64
+	CoreBurstRatio float64
 }
 
 type ContainerLister struct {
 	containerPath string
 	containers    map[string]*ContainerUsage
 	mutex         sync.Mutex
-	clientset     *kubernetes.Clientset
+	clientset     kubernetes.Interface
 	nodeName      string
 
 	// Fields for the informer-based pod cache mechanism
@@ -157,10 +167,15 @@ func (l *ContainerLister) ListContainers() map[string]*ContainerUsage {
 	return l.containers
 }
 
-func (l *ContainerLister) Clientset() *kubernetes.Clientset {
+func (l *ContainerLister) Clientset() kubernetes.Interface {
 	return l.clientset
 }
 
+// ListPods returns the pods of the node from the informer cache.
+func (l *ContainerLister) ListPods() ([]*corev1.Pod, error) {
+	return l.podLister.List(labels.Everything())
+}
+
 func (l *ContainerLister) Update() error {
 
 	l.mutex.Lock()
@@ -176,9 +191,9 @@ func (l *ContainerLister) Update() error {
 		return fmt.Errorf("failed to list pods: %v", err)
 	}
 
-	podUIDs := make(map[string]bool, len(pods))
+	podsByUID := make(map[string]*corev1.Pod, len(pods))
 	for _, pod := range pods {
-		podUIDs[string(pod.UID)] = true
+		podsByUID[string(pod.UID)] = pod
 	}
 
 	for _, entry := range entries {
@@ -192,7 +207,8 @@ func (l *ContainerLister) Update() error {
 		}
 		dirName := filepath.Join(l.containerPath, entry.Name())
 		podUID := parts[0]
-		if !podUIDs[podUID] {
+		pod, ok := podsByUID[podUID]
+		if !ok {
 			dirInfo, err := os.Stat(dirName)
 			if err == nil && dirInfo.ModTime().Add(resyncInterval).After(time.Now()) {
 				continue
@@ -205,7 +221,8 @@ func (l *ContainerLister) Update() error {
 			_ = os.RemoveAll(dirName)
 			continue
 		}
-		if _, ok := l.containers[entry.Name()]; ok {
+		if c, ok := l.containers[entry.Name()]; ok {
+			refreshPodLimits(c, pod)
 			continue
 		}
 		usage, err := loadCache(dirName)
@@ -218,6 +235,7 @@ func (l *ContainerLister) Update() error {
 		}
 		usage.PodUID = podUID
 		usage.ContainerName = parts[1]
+		refreshPodLimits(usage, pod)
 		l.containers[entry.Name()] = usage
 		klog.Infof("Adding ctr dirname %s in monitorpath", dirName)
 	}
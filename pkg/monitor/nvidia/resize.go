@@ -0,0 +1,199 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	nv "github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
+)
+
+// SyncResizedLimits writes the limits of resized containers into their shared
+// regions. Only pods whose last resize the scheduler applied are touched, so
+// the limits hami-core read from the environment at start are otherwise left
+// alone. Pending resizes that lower memory are answered with whether the
+// containers fit into their new limits.
+func (l *ContainerLister) SyncResizedLimits() error {
+	l.mutex.Lock()
+	pods, err := l.podLister.List(labels.Everything())
+	if err != nil {
+		l.mutex.Unlock()
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+	podsByUID := make(map[string]*corev1.Pod, len(pods))
+	containersByPod := make(map[string]map[string]*ContainerUsage, len(pods))
+	for _, pod := range pods {
+		podsByUID[string(pod.UID)] = pod
+	}
+	for _, c := range l.containers {
+		pod, ok := podsByUID[c.PodUID]
+		if !ok {
+			continue
+		}
+		applyResizedLimits(pod, c)
+		if containersByPod[c.PodUID] == nil {
+			containersByPod[c.PodUID] = map[string]*ContainerUsage{}
+		}
+		containersByPod[c.PodUID][c.ContainerName] = c
+	}
+	var verdicts []resizeVerdict
+	for _, pod := range pods {
+		if status, ok := verifyResize(pod, containersByPod[string(pod.UID)]); ok {
+			verdicts = append(verdicts, resizeVerdict{pod: pod, status: status})
+		}
+	}
+	l.mutex.Unlock()
+
+	// The API calls are made without holding the containers.
+	for _, v := range verdicts {
+		if err := l.patchResizeStatus(v.pod, v.status); err != nil {
+			klog.ErrorS(err, "Failed to record vGPU resize check", "pod", klog.KObj(v.pod))
+		}
+	}
+	return nil
+}
+
+type resizeVerdict struct {
+	pod    *corev1.Pod
+	status nv.ResizeStatus
+}
+
+// verifyResize checks a pending resize of pod against the device memory its
+// containers use, and returns the status to record. ok is false if the pod has
+// no pending resize. Containers without a shared region have not allocated
+// device memory yet and always fit.
+func verifyResize(pod *corev1.Pod, containers map[string]*ContainerUsage) (status nv.ResizeStatus, ok bool) {
+	request := pod.Annotations[nv.ResizeAnnotation]
+	current, found := nv.DecodeResizeStatus(pod.Annotations)
+	if !found || current.Phase != nv.ResizePending || current.Request != request {
+		return status, false
+	}
+	status = nv.ResizeStatus{Request: request, Phase: nv.ResizeVerified}
+	req, err := nv.ParseResizeRequest(request)
+	if err != nil {
+		return status, false
+	}
+	pd, err := device.DecodePodDevices(map[string]string{nv.NvidiaGPUDevice: nv.AllocatedDevicesAnnos}, pod.Annotations)
+	if err != nil {
+		klog.ErrorS(err, "Failed to decode pod devices of pending resize", "pod", klog.KObj(pod))
+		return status, false
+	}
+	shrunk := nv.ShrunkContainers(pod, pd[nv.NvidiaGPUDevice], req)
+	names := slices.Sorted(maps.Keys(shrunk))
+	for _, name := range names {
+		c, ok := containers[name]
+		if !ok {
+			continue
+		}
+		limit := uint64(shrunk[name]) * 1024 * 1024
+		for i := range c.Info.DeviceNum() {
+			if used := c.Info.DeviceMemoryTotal(i); used > limit {
+				status.Phase = nv.ResizeRejected
+				status.Message = fmt.Sprintf("container %s uses %d MiB on device %d, more than the requested %d MiB", name, used/(1024*1024), i, shrunk[name])
+				return status, true
+			}
+		}
+	}
+	return status, true
+}
+
+func (l *ContainerLister) patchResizeStatus(pod *corev1.Pod, status nv.ResizeStatus) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]string{nv.ResizeStatusAnnotation: nv.EncodeResizeStatus(status)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = l.clientset.CoreV1().Pods(pod.Namespace).Patch(context.Background(), pod.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	if err == nil {
+		klog.InfoS("Checked pending vGPU resize", "pod", klog.KObj(pod), "phase", status.Phase, "message", status.Message)
+	}
+	return err
+}
+
+// applyResizedLimits updates c's shared region to the limits recorded in the
+// pod's allocation and reports whether anything changed.
+func applyResizedLimits(pod *corev1.Pod, c *ContainerUsage) bool {
+	status, ok := nv.DecodeResizeStatus(pod.Annotations)
+	if !ok || status.Phase != nv.ResizeApplied {
+		return false
+	}
+	pd, err := device.DecodePodDevices(map[string]string{nv.NvidiaGPUDevice: nv.AllocatedDevicesAnnos}, pod.Annotations)
+	if err != nil {
+		klog.ErrorS(err, "Failed to decode resized pod devices", "pod", klog.KObj(pod))
+		return false
+	}
+	mem, cores, ok := nv.ContainerLimits(pod, pd[nv.NvidiaGPUDevice], c.ContainerName)
+	if !ok || c.Info.DeviceNum() == 0 {
+		return false
+	}
+	memLimit := uint64(mem) * 1024 * 1024
+	changed := false
+	if c.Info.DeviceMemoryLimit(0) != memLimit {
+		c.Info.SetDeviceMemoryLimit(memLimit)
+		changed = true
+	}
+	// Elastic containers have their SM limit managed by the burst loop,
+	// which starts from the same BaseCoreLimit.
+	if c.CoreBurstRatio == 0 && c.Info.DeviceSmLimit(0) != uint64(cores) {
+		c.Info.SetDeviceSmLimit(uint64(cores))
+		changed = true
+	}
+	if changed {
+		_, span := tracing.StartPodSpan(context.Background(), pod, "vGPUmonitor.ApplyResizedLimits")
+		span.SetAttributes(attribute.String("k8s.container.name", c.ContainerName))
+		span.End()
+		klog.InfoS("Applied resized vGPU limits", "pod", klog.KObj(pod), "container", c.ContainerName, "memoryMiB", mem, "cores", cores)
+	}
+	return changed
+}
+
+// refreshPodLimits records the pod-level settings the feedback loop needs on c.
+func refreshPodLimits(c *ContainerUsage, pod *corev1.Pod) {
+	c.PodNamespace, c.PodName = pod.Namespace, pod.Name
+	c.BaseCoreLimit, c.CoreBurstRatio = 0, 0
+	pd, err := device.DecodePodDevices(map[string]string{nv.NvidiaGPUDevice: nv.AllocatedDevicesAnnos}, pod.Annotations)
+	if err != nil {
+		return
+	}
+	if _, cores, ok := nv.ContainerLimits(pod, pd[nv.NvidiaGPUDevice], c.ContainerName); ok {
+		c.BaseCoreLimit = uint64(cores)
+	}
+	if value, ok := pod.Annotations[nv.CoreBurstRatioAnnotation]; ok {
+		ratio, err := nv.ParseCoreBurstRatio(value)
+		if err != nil {
+			klog.V(4).InfoS("Ignoring core burst ratio", "pod", klog.KObj(pod), "err", err)
+			return
+		}
+		c.CoreBurstRatio = ratio
+	}
+}
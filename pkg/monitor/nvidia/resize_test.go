@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	nv "github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+func resizedPod(phase string, usedmem, usedcores int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "p", Namespace: "default", UID: "uid1",
+			Annotations: map[string]string{
+				nv.AllocatedDevicesAnnos: device.EncodePodSingleDevice(device.PodSingleDevice{
+					{{UUID: "GPU-0", Type: "NVIDIA", Usedmem: usedmem, Usedcores: usedcores}},
+				}),
+				nv.ResizeStatusAnnotation: nv.EncodeResizeStatus(nv.ResizeStatus{Request: "{}", Phase: phase}),
+			},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "ctr"}}},
+	}
+}
+
+func Test_SyncResizedLimits(t *testing.T) {
+	cacheDir := t.TempDir()
+	writeCacheFile(t, cacheDir, "x.cache", makeV0CacheBytes(2, []uint64{1024 << 20, 1024 << 20}))
+	usage, err := loadCache(cacheDir)
+	assert.NilError(t, err)
+	usage.PodUID = "uid1"
+	usage.ContainerName = "ctr"
+
+	l := &ContainerLister{
+		containers: map[string]*ContainerUsage{"uid1_ctr": usage},
+		podLister:  newTestPodLister(resizedPod(nv.ResizeRejected, 4096, 30)),
+	}
+	assert.NilError(t, l.SyncResizedLimits())
+	assert.Equal(t, usage.Info.DeviceMemoryLimit(0), uint64(1024<<20), "rejected resize must not touch the limits")
+
+	l.podLister = newTestPodLister(resizedPod(nv.ResizeApplied, 4096, 30))
+	assert.NilError(t, l.SyncResizedLimits())
+	for idx := range 2 {
+		assert.Equal(t, usage.Info.DeviceMemoryLimit(idx), uint64(4096<<20))
+		assert.Equal(t, usage.Info.DeviceSmLimit(idx), uint64(30))
+	}
+	assert.Assert(t, !applyResizedLimits(resizedPod(nv.ResizeApplied, 4096, 30), usage), "unchanged limits are not rewritten")
+}
+
+func Test_refreshPodLimits(t *testing.T) {
+	pod := resizedPod(nv.ResizeApplied, 4096, 30)
+	pod.Annotations[nv.CoreBurstRatioAnnotation] = "0.5"
+	c := &ContainerUsage{ContainerName: "ctr"}
+	refreshPodLimits(c, pod)
+	assert.Equal(t, c.BaseCoreLimit, uint64(30))
+	assert.Equal(t, c.CoreBurstRatio, 0.5)
+
+	pod.Annotations[nv.CoreBurstRatioAnnotation] = "2"
+	refreshPodLimits(c, pod)
+	assert.Equal(t, c.CoreBurstRatio, float64(0), "an invalid ratio disables elastic cores")
+	assert.Equal(t, c.BaseCoreLimit, uint64(30))
+}
+
+// memoryUsageStub reports fixed device memory usage.
+type memoryUsageStub struct {
+	UsageInfo
+	used []uint64
+}
+
+func (s *memoryUsageStub) DeviceNum() int                   { return len(s.used) }
+func (s *memoryUsageStub) DeviceMemoryTotal(idx int) uint64 { return s.used[idx] }
+
+func Test_SyncResizedLimitsChecksShrinks(t *testing.T) {
+	pending := func(request string) *corev1.Pod {
+		pod := resizedPod(nv.ResizePending, 4096, 30)
+		pod.Annotations[nv.ResizeAnnotation] = request
+		pod.Annotations[nv.ResizeStatusAnnotation] = nv.EncodeResizeStatus(nv.ResizeStatus{Request: request, Phase: nv.ResizePending})
+		return pod
+	}
+	usage := &ContainerUsage{PodUID: "uid1", ContainerName: "ctr", Info: &memoryUsageStub{used: []uint64{1500 << 20, 3000 << 20}}}
+	check := func(pod *corev1.Pod) nv.ResizeStatus {
+		t.Helper()
+		client := fake.NewClientset(pod)
+		l := &ContainerLister{
+			containers: map[string]*ContainerUsage{"uid1_ctr": usage},
+			podLister:  newTestPodLister(pod),
+			clientset:  client,
+		}
+		assert.NilError(t, l.SyncResizedLimits())
+		got, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		assert.NilError(t, err)
+		status, ok := nv.DecodeResizeStatus(got.Annotations)
+		assert.Assert(t, ok)
+		return status
+	}
+
+	status := check(pending(`{"ctr":{"memory":2048}}`))
+	assert.Equal(t, status.Phase, nv.ResizeRejected)
+	assert.Equal(t, status.Message, "container ctr uses 3000 MiB on device 1, more than the requested 2048 MiB")
+
+	status = check(pending(`{"ctr":{"memory":3072}}`))
+	assert.Equal(t, status.Phase, nv.ResizeVerified)
+	assert.Equal(t, status.Request, `{"ctr":{"memory":3072}}`)
+
+	_, ok := verifyResize(resizedPod(nv.ResizeApplied, 4096, 30), nil)
+	assert.Assert(t, !ok, "only pending resizes are checked")
+}
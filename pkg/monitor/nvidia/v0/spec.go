@@ -158,6 +158,22 @@ func (s Spec) DeviceSmUtil(idx int) uint64 {
 	return v
 }
 
+// HostPIDs returns the host PIDs of the processes registered in the region.
+func (s Spec) HostPIDs() []int32 {
+	var pids []int32
+	for _, p := range s.activeProcs() {
+		if p.status == 0 || p.hostpid <= 0 {
+			continue
+		}
+		pids = append(pids, p.hostpid)
+	}
+	return pids
+}
+
+func (s Spec) DeviceSmLimit(idx int) uint64 {
+	return atomic.LoadUint64(&s.sr.smLimit[idx])
+}
+
 func (s Spec) SetDeviceSmLimit(l uint64) {
 	n := min(s.sr.num, maxDevices)
 	for idx := range n {
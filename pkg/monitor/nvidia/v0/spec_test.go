@@ -826,3 +826,14 @@ func TestV0SharedRegionTLayoutSize(t *testing.T) {
 		t.Errorf("MinSize() = %d, want %d; a field was added or removed from v0.sharedRegionT — update the size discriminator in cudevshr.go accordingly", got, want)
 	}
 }
+
+func TestSpec_HostPIDs(t *testing.T) {
+	sr := &sharedRegionT{num: 1, procnum: 3}
+	sr.procs[0] = shrregProcSlotT{status: 1, hostpid: 100}
+	sr.procs[1] = shrregProcSlotT{status: 0, hostpid: 200}
+	sr.procs[2] = shrregProcSlotT{status: 1}
+	sr.procs[3] = shrregProcSlotT{status: 1, hostpid: 400}
+	if got := (Spec{sr: sr}).HostPIDs(); !reflect.DeepEqual(got, []int32{100}) {
+		t.Errorf("HostPIDs() = %v, want [100]", got)
+	}
+}
@@ -1423,3 +1423,12 @@ func TestSpec_CorruptProcnumIsClamped(t *testing.T) {
 		})
 	}
 }
+
+func Test_HostPIDs(t *testing.T) {
+	sr := &sharedRegionT{num: 1, procnum: 3}
+	sr.procs[0] = shrregProcSlotT{status: 1, hostpid: 100}
+	sr.procs[1] = shrregProcSlotT{status: 0, hostpid: 200}
+	sr.procs[2] = shrregProcSlotT{status: 1}
+	sr.procs[3] = shrregProcSlotT{status: 1, hostpid: 400}
+	assert.DeepEqual(t, Spec{sr: sr}.HostPIDs(), []int32{100})
+}
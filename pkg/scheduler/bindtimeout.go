@@ -0,0 +1,120 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+const bindTimeoutCheckInterval = 30 * time.Second
+
+// WatchBindTimeouts gives up on bound pods whose devices the device plugin
+// does not allocate within config.BindPhaseTimeout, e.g. because kubelet
+// restarted or the plugin crashed, until the scheduler stops.
+func (s *Scheduler) WatchBindTimeouts() {
+	klog.InfoS("Watching for pods stuck in the allocating bind phase", "timeout", config.BindPhaseTimeout, "deletePods", config.BindTimeoutDeletePod)
+	ticker := time.NewTicker(bindTimeoutCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		}
+		if atomic.LoadUint32(&s.started) == 0 || !s.leaderManager.IsLeader() {
+			continue
+		}
+		s.expireStuckBindings(time.Now())
+	}
+}
+
+// expireStuckBindings handles every pending pod that has been allocating
+// since longer than config.BindPhaseTimeout before now.
+func (s *Scheduler) expireStuckBindings(now time.Time) {
+	pods, err := s.podLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pods for bind timeouts")
+		return
+	}
+	for _, pod := range pods {
+		since, ok := allocatingSince(pod)
+		if !ok || now.Sub(since) <= config.BindPhaseTimeout {
+			continue
+		}
+		s.expireBinding(pod, now.Sub(since))
+	}
+}
+
+// allocatingSince returns when pod was bound if it is still waiting for the
+// device plugin to allocate its devices. Pods whose Bind call failed are not
+// on a node and are left to the next scheduling attempt.
+func allocatingSince(pod *corev1.Pod) (time.Time, bool) {
+	if pod.Annotations[util.DeviceBindPhase] != util.DeviceBindAllocating || pod.Spec.NodeName == "" ||
+		pod.Status.Phase != corev1.PodPending || util.IsPodTerminating(pod) {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(pod.Annotations[util.BindTimeAnnotations], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
+// expireBinding marks pod failed, so the device plugin no longer picks it
+// for Allocate, releases the node locks it holds and drops its devices from
+// the scheduler's usage. With config.BindTimeoutDeletePod the pod is also
+// deleted if a controller will recreate it.
+func (s *Scheduler) expireBinding(pod *corev1.Pod, waited time.Duration) {
+	klog.InfoS("Device allocation timed out", "pod", klog.KObj(pod), "waited", waited)
+	if err := util.PatchPodAnnotations(pod, map[string]string{util.DeviceBindPhase: util.DeviceBindFailed}); err != nil {
+		klog.ErrorS(err, "Failed to mark timed out pod failed", "pod", klog.KObj(pod))
+		return
+	}
+	if node, err := s.nodeLister.Get(pod.Annotations[util.AssignedNodeAnnotations]); err == nil {
+		s.releaseAllDevices(node, pod)
+	} else {
+		klog.ErrorS(err, "Failed to get node of timed out pod", "pod", klog.KObj(pod))
+	}
+	s.cleanupStalePodAllocation(pod)
+	s.recordScheduleBindingResultEvent(pod, EventReasonBindTimeout, []string{}, fmt.Errorf("devices were not allocated within %s of binding", config.BindPhaseTimeout))
+
+	action := "failed"
+	if config.BindTimeoutDeletePod {
+		if metav1.GetControllerOf(pod) == nil {
+			klog.InfoS("Keeping timed out pod without a controller", "pod", klog.KObj(pod))
+		} else if err := s.kubeClient.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &pod.UID},
+		}); err != nil {
+			klog.ErrorS(err, "Failed to delete timed out pod", "pod", klog.KObj(pod))
+		} else {
+			action = "deleted"
+		}
+	}
+	BindTimeoutsTotal.WithLabelValues(action).Inc()
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+)
+
+func newBindingPod(name, phase string, bound time.Time, controlled bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:       k8stypes.UID("uid-" + name),
+			Name:      name,
+			Namespace: "default",
+			Annotations: map[string]string{
+				util.AssignedNodeAnnotations: "node1",
+				util.BindTimeAnnotations:     strconv.FormatInt(bound.Unix(), 10),
+				util.DeviceBindPhase:         phase,
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node1",
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu": *resource.NewQuantity(1, resource.BinarySI),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	if controlled {
+		isController := true
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", UID: "uid-rs", Controller: &isController,
+		}}
+	}
+	return pod
+}
+
+func Test_allocatingSince(t *testing.T) {
+	bound := time.Unix(1700000000, 0)
+	pod := newBindingPod("p", util.DeviceBindAllocating, bound, false)
+	since, ok := allocatingSince(pod)
+	assert.True(t, ok)
+	assert.Equal(t, bound, since)
+
+	for name, mutate := range map[string]func(*corev1.Pod){
+		"allocated":   func(p *corev1.Pod) { p.Annotations[util.DeviceBindPhase] = util.DeviceBindSuccess },
+		"running":     func(p *corev1.Pod) { p.Status.Phase = corev1.PodRunning },
+		"terminating": func(p *corev1.Pod) { p.DeletionTimestamp = &metav1.Time{Time: bound} },
+		"bind failed": func(p *corev1.Pod) { p.Spec.NodeName = "" },
+		"no bind time": func(p *corev1.Pod) {
+			delete(p.Annotations, util.BindTimeAnnotations)
+		},
+	} {
+		p := pod.DeepCopy()
+		mutate(p)
+		_, ok := allocatingSince(p)
+		assert.False(t, ok, name)
+	}
+}
+
+func Test_expireStuckBindings(t *testing.T) {
+	require.NoError(t, config.InitDevicesWithConfig(&config.Config{
+		NvidiaConfig: nvidia.NvidiaConfig{ResourceCountName: "hami.io/gpu", DefaultGPUNum: 1},
+	}))
+	previousTimeout, previousDelete := config.BindPhaseTimeout, config.BindTimeoutDeletePod
+	config.BindPhaseTimeout, config.BindTimeoutDeletePod = 5*time.Minute, true
+	t.Cleanup(func() { config.BindPhaseTimeout, config.BindTimeoutDeletePod = previousTimeout, previousDelete })
+
+	now := time.Now()
+	stuck := newBindingPod("stuck", util.DeviceBindAllocating, now.Add(-10*time.Minute), true)
+	bare := newBindingPod("bare", util.DeviceBindAllocating, now.Add(-10*time.Minute), false)
+	fresh := newBindingPod("fresh", util.DeviceBindAllocating, now.Add(-time.Minute), true)
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        "node1",
+		Annotations: map[string]string{nodelock.NodeLockKey: nodelock.GenerateNodeLockKeyByPod(stuck)},
+	}}
+
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewClientset(stuck, bare, fresh, node)
+	t.Cleanup(func() { client.KubeClient = previousKubeClient })
+
+	s := NewScheduler()
+	s.kubeClient = client.KubeClient
+	recorder := record.NewFakeRecorder(10)
+	s.eventRecorder = recorder
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(client.KubeClient, 0)
+	s.podLister = informerFactory.Core().V1().Pods().Lister()
+	s.nodeLister = informerFactory.Core().V1().Nodes().Lister()
+	for _, pod := range []*corev1.Pod{stuck, bare, fresh} {
+		require.NoError(t, informerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod))
+	}
+	require.NoError(t, informerFactory.Core().V1().Nodes().Informer().GetIndexer().Add(node))
+	devices := device.PodDevices{nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{UUID: "GPU0", Usedmem: 1000, Usedcores: 10}}}}
+	s.podManager.AddPod(stuck, "node1", devices)
+	s.podManager.AddPod(fresh, "node1", devices)
+	t.Cleanup(func() {
+		s.podManager.DelPod(stuck)
+		s.podManager.DelPod(fresh)
+	})
+	deletedBefore := testutil.ToFloat64(BindTimeoutsTotal.WithLabelValues("deleted"))
+	failedBefore := testutil.ToFloat64(BindTimeoutsTotal.WithLabelValues("failed"))
+
+	s.expireStuckBindings(now)
+
+	ctx := context.Background()
+	_, err := client.KubeClient.CoreV1().Pods("default").Get(ctx, "stuck", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "the controller recreates a deleted pod")
+	_, ok := s.podManager.GetPod(stuck)
+	assert.False(t, ok)
+	gotNode, err := client.KubeClient.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, gotNode.Annotations, nodelock.NodeLockKey)
+
+	gotBare, err := client.KubeClient.CoreV1().Pods("default").Get(ctx, "bare", metav1.GetOptions{})
+	require.NoError(t, err, "pods without a controller are kept")
+	assert.Equal(t, util.DeviceBindFailed, gotBare.Annotations[util.DeviceBindPhase])
+
+	gotFresh, err := client.KubeClient.CoreV1().Pods("default").Get(ctx, "fresh", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, util.DeviceBindAllocating, gotFresh.Annotations[util.DeviceBindPhase])
+	_, ok = s.podManager.GetPod(fresh)
+	assert.True(t, ok)
+
+	assert.Equal(t, deletedBefore+1, testutil.ToFloat64(BindTimeoutsTotal.WithLabelValues("deleted")))
+	assert.Equal(t, failedBefore+1, testutil.ToFloat64(BindTimeoutsTotal.WithLabelValues("failed")))
+	require.Len(t, recorder.Events, 2)
+	assert.Contains(t, <-recorder.Events, EventReasonBindTimeout)
+
+	// Once marked failed, informer updates do not bring the devices back.
+	s.onUpdatePod(bare, gotBare)
+	_, ok = s.podManager.GetPod(gotBare)
+	assert.False(t, ok)
+}
+
+func Test_FilterResetsFailedBindPhase(t *testing.T) {
+	require.NoError(t, config.InitDevicesWithConfig(&config.Config{
+		NvidiaConfig: nvidia.NvidiaConfig{
+			ResourceCountName: "hami.io/gpu", ResourceMemoryName: "hami.io/gpumem",
+			ResourceCoreName: "hami.io/gpucores", DefaultGPUNum: 1,
+		},
+	}))
+	s := NewScheduler()
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {{ID: "GPU0", Count: 10, Devmem: 8192, Devcore: 100, Type: "NVIDIA-A100", Mode: nvidia.HamiCoreMode, Health: true}},
+		},
+	})
+	// The pod timed out on an earlier attempt whose Bind call failed.
+	pod := newBindingPod("retried", util.DeviceBindFailed, time.Now().Add(-time.Hour), false)
+	pod.Spec.NodeName = ""
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewClientset(pod)
+	t.Cleanup(func() {
+		client.KubeClient = previousKubeClient
+		s.onDelPod(pod)
+	})
+
+	res, err := s.Filter(extenderv1.ExtenderArgs{Pod: pod, NodeNames: &[]string{"node1"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"node1"}, *res.NodeNames)
+	patched, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, patched.Annotations[util.DeviceBindPhase])
+
+	s.onUpdatePod(pod, patched)
+	_, ok := s.podManager.GetPod(patched)
+	assert.True(t, ok, "the allocation Filter recorded is kept")
+}
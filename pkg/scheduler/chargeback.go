@@ -0,0 +1,398 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// unknownDeviceType is the card type of devices on nodes the scheduler no
+// longer knows.
+const unknownDeviceType = "unknown"
+
+// PriceTable prices weighted GPU-hours per card type. A device's weight is
+// MemoryWeight times the share of the card's memory allocated to the pod plus
+// CoreWeight times its share of the cores.
+type PriceTable struct {
+	MemoryWeight float64 `yaml:"memoryWeight"`
+	CoreWeight   float64 `yaml:"coreWeight"`
+	Currency     string  `yaml:"currency"`
+	// Prices maps a device type, as registered by the device plugin, to the
+	// price of one weighted GPU-hour. The "*" entry prices all other types.
+	Prices map[string]float64 `yaml:"prices"`
+}
+
+// DefaultPriceTable weighs memory and cores equally and prices nothing.
+func DefaultPriceTable() *PriceTable {
+	return &PriceTable{MemoryWeight: 0.5, CoreWeight: 0.5}
+}
+
+// LoadPriceTable reads a PriceTable from a YAML file. Unset weights default
+// to DefaultPriceTable's.
+func LoadPriceTable(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %v", err)
+	}
+	var table PriceTable
+	if err := yaml.UnmarshalStrict(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse price table %s: %v", path, err)
+	}
+	if table.MemoryWeight == 0 && table.CoreWeight == 0 {
+		table.MemoryWeight, table.CoreWeight = 0.5, 0.5
+	}
+	if table.MemoryWeight < 0 || table.CoreWeight < 0 {
+		return nil, fmt.Errorf("price table weights must not be negative")
+	}
+	for t, price := range table.Prices {
+		if price < 0 {
+			return nil, fmt.Errorf("price of %q must not be negative", t)
+		}
+	}
+	return &table, nil
+}
+
+func (t *PriceTable) price(deviceType string) float64 {
+	if price, ok := t.Prices[deviceType]; ok {
+		return price
+	}
+	return t.Prices["*"]
+}
+
+// ChargebackOptions selects what a chargeback report covers.
+type ChargebackOptions struct {
+	From, To time.Time
+	// GroupBy is "namespace" or "label:<key>" to group pods by the value of
+	// a pod label.
+	GroupBy string
+	Prices  *PriceTable
+}
+
+// ChargebackRow is the allocation of one group on one card type.
+type ChargebackRow struct {
+	Group      string `json:"group"`
+	Vendor     string `json:"vendor"`
+	DeviceType string `json:"deviceType"`
+	// GPUHours counts every allocated device as a whole card.
+	GPUHours float64 `json:"gpuHours"`
+	// MemoryGPUHours and CoreGPUHours weigh the hours by the share of the
+	// card's memory and cores allocated.
+	MemoryGPUHours   float64 `json:"memoryGpuHours"`
+	CoreGPUHours     float64 `json:"coreGpuHours"`
+	WeightedGPUHours float64 `json:"weightedGpuHours"`
+	Cost             float64 `json:"cost"`
+}
+
+// ChargebackReport is the GPU allocation of pods over a time range.
+type ChargebackReport struct {
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	GroupBy  string          `json:"groupBy"`
+	Currency string          `json:"currency,omitempty"`
+	Rows     []ChargebackRow `json:"rows"`
+}
+
+// ChargebackReport sums the devices allocated to pods over opts' time range.
+// A pod is charged from its bind time until it finishes or is deleted. Pods
+// still in the scheduler's cache are read from it; pods that already ended
+// are read from the allocations recorded when they did, which are kept for
+// chargebackRetention. Those records are not persisted, so pods that ended
+// before this scheduler started are missing from the report.
+func (s *Scheduler) ChargebackReport(opts ChargebackOptions, now time.Time) (*ChargebackReport, error) {
+	labelKey, byLabel := strings.CutPrefix(opts.GroupBy, "label:")
+	if opts.GroupBy != "namespace" && (!byLabel || labelKey == "") {
+		return nil, fmt.Errorf("groupBy must be namespace or label:<key>, got %q", opts.GroupBy)
+	}
+	if !opts.From.Before(opts.To) {
+		return nil, fmt.Errorf("from %s is not before to %s", opts.From.Format(time.RFC3339), opts.To.Format(time.RFC3339))
+	}
+	prices := opts.Prices
+	if prices == nil {
+		prices = DefaultPriceTable()
+	}
+	pods, err := s.podLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	records := s.chargeback.snapshot()
+	device.RLockConfig()
+	for _, pod := range pods {
+		if _, ended := records[pod.UID]; ended {
+			continue
+		}
+		if record, ok := s.chargebackRecord(pod, now); ok {
+			records[pod.UID] = record
+		}
+	}
+	device.RUnlockConfig()
+
+	type rowKey struct{ group, vendor, deviceType string }
+	rows := map[rowKey]*ChargebackRow{}
+	for _, record := range records {
+		start, end := later(record.start, opts.From), earlier(record.end, opts.To)
+		if !start.Before(end) {
+			continue
+		}
+		hours := end.Sub(start).Hours()
+		group := record.namespace
+		if byLabel {
+			group = record.labels[labelKey]
+		}
+		for _, dev := range record.devices {
+			key := rowKey{group, dev.vendor, dev.deviceType}
+			row, ok := rows[key]
+			if !ok {
+				row = &ChargebackRow{Group: group, Vendor: dev.vendor, DeviceType: dev.deviceType}
+				rows[key] = row
+			}
+			weighted := hours * (prices.MemoryWeight*dev.memShare + prices.CoreWeight*dev.coreShare)
+			row.GPUHours += hours
+			row.MemoryGPUHours += hours * dev.memShare
+			row.CoreGPUHours += hours * dev.coreShare
+			row.WeightedGPUHours += weighted
+			row.Cost += weighted * prices.price(dev.deviceType)
+		}
+	}
+
+	report := &ChargebackReport{From: opts.From, To: opts.To, GroupBy: opts.GroupBy, Currency: prices.Currency, Rows: []ChargebackRow{}}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	slices.SortFunc(report.Rows, func(a, b ChargebackRow) int {
+		return strings.Compare(a.Group+"/"+a.Vendor+"/"+a.DeviceType, b.Group+"/"+b.Vendor+"/"+b.DeviceType)
+	})
+	return report, nil
+}
+
+// chargebackRetention is how long the allocation of a pod that ended stays
+// available to chargeback reports.
+const chargebackRetention = 31 * 24 * time.Hour
+
+// chargedDevice is one device allocated to a pod, priced by card type.
+type chargedDevice struct {
+	vendor, deviceType  string
+	memShare, coreShare float64
+}
+
+// allocationRecord is what a chargeback report needs of a pod's allocation.
+type allocationRecord struct {
+	namespace  string
+	labels     map[string]string
+	start, end time.Time
+	devices    []chargedDevice
+}
+
+// chargebackLedger keeps the allocations of pods that ended, since they leave
+// the informer cache once deleted. It lives in memory only: every replica
+// keeps its own and starts it empty.
+type chargebackLedger struct {
+	mu      sync.Mutex
+	records map[k8stypes.UID]allocationRecord
+}
+
+// add records the allocation of a pod that ended, unless it already was, and
+// drops records that ended before the retention period.
+func (l *chargebackLedger) add(uid k8stypes.UID, record allocationRecord, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.records == nil {
+		l.records = make(map[k8stypes.UID]allocationRecord)
+	}
+	if _, ok := l.records[uid]; !ok {
+		l.records[uid] = record
+	}
+	for id, r := range l.records {
+		if now.Sub(r.end) > chargebackRetention {
+			delete(l.records, id)
+		}
+	}
+}
+
+func (l *chargebackLedger) has(uid k8stypes.UID) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.records[uid]
+	return ok
+}
+
+func (l *chargebackLedger) snapshot() map[k8stypes.UID]allocationRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records := make(map[k8stypes.UID]allocationRecord, len(l.records))
+	maps.Copy(records, l.records)
+	return records
+}
+
+// recordChargeback stores the allocation of a pod that finished or was
+// deleted. now is when that was observed and ends the allocation when the
+// pod carries no time of its own.
+func (s *Scheduler) recordChargeback(pod *corev1.Pod, now time.Time) {
+	if s.chargeback.has(pod.UID) {
+		return
+	}
+	if record, ok := s.chargebackRecord(pod, now); ok {
+		s.chargeback.add(pod.UID, record, now)
+	}
+}
+
+// chargebackRecord reads the device annotation keys of the loaded config;
+// callers hold device.RLockConfig.
+func (s *Scheduler) chargebackRecord(pod *corev1.Pod, now time.Time) (allocationRecord, bool) {
+	nodeID, ok := pod.Annotations[util.AssignedNodeAnnotations]
+	if !ok {
+		return allocationRecord{}, false
+	}
+	start, end, ok := allocationInterval(pod, now)
+	if !ok {
+		return allocationRecord{}, false
+	}
+	raw, err := device.DecodePodDevices(device.SupportDevices, pod.Annotations)
+	if err != nil || len(raw) == 0 {
+		return allocationRecord{}, false
+	}
+	var nodeDevices map[string][]device.DeviceInfo
+	if node, err := s.GetNode(nodeID); err == nil {
+		nodeDevices = node.Devices
+	}
+	record := allocationRecord{namespace: pod.Namespace, labels: maps.Clone(pod.Labels), start: start, end: end}
+	for vendor, single := range device.CollapseInitContainerUsage(pod, raw) {
+		for _, ctrDevices := range single {
+			for _, dev := range ctrDevices {
+				deviceType, memShare, coreShare := deviceShares(nodeDevices[vendor], dev)
+				record.devices = append(record.devices, chargedDevice{vendor: vendor, deviceType: deviceType, memShare: memShare, coreShare: coreShare})
+			}
+		}
+	}
+	return record, true
+}
+
+// WriteCSV writes the report's rows as CSV with a header line.
+func (r *ChargebackReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"group", "vendor", "deviceType", "gpuHours", "memoryGpuHours", "coreGpuHours", "weightedGpuHours", "cost"})
+	for _, row := range r.Rows {
+		_ = cw.Write([]string{
+			row.Group, row.Vendor, row.DeviceType,
+			formatHours(row.GPUHours), formatHours(row.MemoryGPUHours), formatHours(row.CoreGPUHours), formatHours(row.WeightedGPUHours),
+			strconv.FormatFloat(row.Cost, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatHours(h float64) string {
+	return strconv.FormatFloat(h, 'f', 4, 64)
+}
+
+// allocationInterval returns when pod got its devices and when it released
+// them, or now if it still holds them.
+func allocationInterval(pod *corev1.Pod, now time.Time) (start, end time.Time, ok bool) {
+	for _, anno := range []string{util.BindTimeAnnotations, util.AssignedTimeAnnotations} {
+		if v, found := pod.Annotations[anno]; found {
+			if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+				start, ok = time.Unix(sec, 0), true
+				break
+			}
+		}
+	}
+	if !ok {
+		return start, end, false
+	}
+	end = now
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		if finished := finishedAt(pod); !finished.IsZero() {
+			end = finished
+		}
+	}
+	if pod.DeletionTimestamp != nil {
+		end = earlier(end, pod.DeletionTimestamp.Time)
+	}
+	return start, end, true
+}
+
+// finishedAt returns when a pod in a terminal phase stopped: the last time one
+// of its containers terminated or, for pods failed or evicted without
+// container states, when its conditions last changed.
+func finishedAt(pod *corev1.Pod) time.Time {
+	var finished time.Time
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if t := status.State.Terminated; t != nil && t.FinishedAt.Time.After(finished) {
+			finished = t.FinishedAt.Time
+		}
+	}
+	if !finished.IsZero() {
+		return finished
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.LastTransitionTime.Time.After(finished) {
+			finished = cond.LastTransitionTime.Time
+		}
+	}
+	return finished
+}
+
+// deviceShares looks dev up among the node's devices and returns its card type
+// and the shares of the card's memory and cores allocated. Devices the
+// scheduler no longer knows are charged as whole cards.
+func deviceShares(nodeDevices []device.DeviceInfo, dev device.ContainerDevice) (deviceType string, memShare, coreShare float64) {
+	for _, d := range nodeDevices {
+		if d.ID != dev.UUID && !strings.HasPrefix(dev.UUID, d.ID+"[") {
+			continue
+		}
+		if d.Devmem > 0 {
+			memShare = min(float64(dev.Usedmem)/float64(d.Devmem), 1)
+		}
+		if d.Devcore > 0 {
+			coreShare = min(float64(dev.Usedcores)/float64(d.Devcore), 1)
+		}
+		return d.Type, memShare, coreShare
+	}
+	return unknownDeviceType, 1, 1
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
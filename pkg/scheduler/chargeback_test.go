@@ -0,0 +1,219 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+func newChargebackTestPod(name, namespace, team string, bound time.Time, devices device.PodSingleDevice) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:       k8stypes.UID("uid-" + name),
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"team": team},
+			Annotations: map[string]string{
+				util.AssignedNodeAnnotations: "node1",
+				util.BindTimeAnnotations:     strconv.FormatInt(bound.Unix(), 10),
+			},
+		},
+		Spec:   corev1.PodSpec{NodeName: "node1", Containers: []corev1.Container{{Name: "app"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	maps.Copy(pod.Annotations, device.EncodePodDevices(device.SupportDevices, device.PodDevices{nvidia.NvidiaGPUDevice: devices}))
+	return pod
+}
+
+func newChargebackTestScheduler(t *testing.T, pods ...*corev1.Pod) *Scheduler {
+	t.Helper()
+	s := NewScheduler()
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {
+				{ID: "GPU0", Type: "NVIDIA-A100", Devmem: 40000, Devcore: 100},
+				{ID: "GPU1", Type: "NVIDIA-T4", Devmem: 16000, Devcore: 100},
+			},
+		},
+	})
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(fake.NewClientset(), 0)
+	s.podLister = informerFactory.Core().V1().Pods().Lister()
+	for _, pod := range pods {
+		require.NoError(t, informerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod))
+	}
+	return s
+}
+
+func TestChargebackReport(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(10 * time.Hour)
+
+	// Runs the whole range on half an A100.
+	running := newChargebackTestPod("running", "ml", "vision", from.Add(-time.Hour), device.PodSingleDevice{
+		{{UUID: "GPU0", Usedmem: 20000, Usedcores: 50}},
+	})
+	// Bound two hours in, finished four hours later, on a whole T4.
+	finished := newChargebackTestPod("finished", "ml", "speech", from.Add(2*time.Hour), device.PodSingleDevice{
+		{{UUID: "GPU1", Usedmem: 16000, Usedcores: 100}},
+	})
+	finished.Status.Phase = corev1.PodSucceeded
+	finished.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(from.Add(6 * time.Hour))}},
+	}}
+	// Deleted an hour in, with a quarter of the A100's memory and no cores.
+	deleting := newChargebackTestPod("deleting", "web", "vision", from.Add(-time.Hour), device.PodSingleDevice{
+		{{UUID: "GPU0", Usedmem: 10000, Usedcores: 0}},
+	})
+	deleting.DeletionTimestamp = &metav1.Time{Time: from.Add(time.Hour)}
+	// Bound after the range.
+	late := newChargebackTestPod("late", "web", "vision", to.Add(time.Hour), device.PodSingleDevice{
+		{{UUID: "GPU0", Usedmem: 10000, Usedcores: 10}},
+	})
+	unscheduled := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "web"}}
+
+	s := newChargebackTestScheduler(t, running, finished, deleting, late, unscheduled)
+	prices := &PriceTable{MemoryWeight: 0.5, CoreWeight: 0.5, Currency: "USD", Prices: map[string]float64{"NVIDIA-A100": 2, "*": 1}}
+
+	report, err := s.ChargebackReport(ChargebackOptions{From: from, To: to, GroupBy: "namespace", Prices: prices}, to.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "USD", report.Currency)
+	assert.Equal(t, []ChargebackRow{
+		{Group: "ml", Vendor: nvidia.NvidiaGPUDevice, DeviceType: "NVIDIA-A100", GPUHours: 10, MemoryGPUHours: 5, CoreGPUHours: 5, WeightedGPUHours: 5, Cost: 10},
+		{Group: "ml", Vendor: nvidia.NvidiaGPUDevice, DeviceType: "NVIDIA-T4", GPUHours: 4, MemoryGPUHours: 4, CoreGPUHours: 4, WeightedGPUHours: 4, Cost: 4},
+		{Group: "web", Vendor: nvidia.NvidiaGPUDevice, DeviceType: "NVIDIA-A100", GPUHours: 1, MemoryGPUHours: 0.25, CoreGPUHours: 0, WeightedGPUHours: 0.125, Cost: 0.25},
+	}, report.Rows)
+
+	report, err = s.ChargebackReport(ChargebackOptions{From: from, To: to, GroupBy: "label:team"}, to)
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 2)
+	assert.Equal(t, "speech", report.Rows[0].Group)
+	assert.Equal(t, "vision", report.Rows[1].Group)
+	assert.InDelta(t, 5.125, report.Rows[1].WeightedGPUHours, 1e-9)
+	assert.Zero(t, report.Rows[1].Cost, "no price table prices nothing")
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf))
+	assert.Equal(t, "group,vendor,deviceType,gpuHours,memoryGpuHours,coreGpuHours,weightedGpuHours,cost\n"+
+		"speech,NVIDIA,NVIDIA-T4,4.0000,4.0000,4.0000,4.0000,0.00\n"+
+		"vision,NVIDIA,NVIDIA-A100,11.0000,5.2500,5.0000,5.1250,0.00\n", buf.String())
+}
+
+func TestChargebackReportEndedPods(t *testing.T) {
+	// Recent enough that the ended allocations are within retention.
+	from := time.Now().Truncate(time.Hour).Add(-12 * time.Hour)
+	to := from.Add(10 * time.Hour)
+	wholeT4 := device.PodSingleDevice{{{UUID: "GPU1", Usedmem: 16000, Usedcores: 100}}}
+
+	// Evicted three hours in, without container states.
+	evicted := newChargebackTestPod("evicted", "evicted", "vision", from.Add(time.Hour), wholeT4)
+	evicted.Status = corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted", Conditions: []corev1.PodCondition{
+		{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(from.Add(3 * time.Hour))},
+	}}
+	// Deleted while running and gone from the cache.
+	deleted := newChargebackTestPod("deleted", "deleted", "vision", from, wholeT4)
+	deleted.DeletionTimestamp = &metav1.Time{Time: from.Add(5 * time.Hour)}
+	// Finished two hours in, then deleted.
+	done := newChargebackTestPod("done", "done", "vision", from, wholeT4)
+	running := done.DeepCopy()
+	done.Status.Phase = corev1.PodSucceeded
+	done.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(from.Add(2 * time.Hour))}},
+	}}
+
+	s := newChargebackTestScheduler(t, evicted)
+	s.onDelPod(deleted)
+	s.onUpdatePod(running, done)
+	deletedLater := done.DeepCopy()
+	deletedLater.DeletionTimestamp = &metav1.Time{Time: from.Add(8 * time.Hour)}
+	s.onDelPod(deletedLater)
+
+	report, err := s.ChargebackReport(ChargebackOptions{From: from, To: to, GroupBy: "namespace"}, to)
+	require.NoError(t, err)
+	hours := map[string]float64{}
+	for _, row := range report.Rows {
+		hours[row.Group] = row.GPUHours
+	}
+	assert.Equal(t, map[string]float64{"evicted": 2, "deleted": 5, "done": 2}, hours)
+}
+
+func TestChargebackReportUnknownDevice(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	pod := newChargebackTestPod("gone", "ml", "vision", now.Add(-2*time.Hour), device.PodSingleDevice{
+		{{UUID: "GPU9", Usedmem: 1000, Usedcores: 10}},
+	})
+	s := newChargebackTestScheduler(t, pod)
+
+	report, err := s.ChargebackReport(ChargebackOptions{From: now.Add(-24 * time.Hour), To: now, GroupBy: "namespace"}, now)
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 1)
+	assert.Equal(t, unknownDeviceType, report.Rows[0].DeviceType)
+	assert.Equal(t, 2.0, report.Rows[0].WeightedGPUHours, "devices the scheduler no longer knows are charged as whole cards")
+}
+
+func TestChargebackReportInvalidOptions(t *testing.T) {
+	s := newChargebackTestScheduler(t)
+	now := time.Now()
+	_, err := s.ChargebackReport(ChargebackOptions{From: now.Add(-time.Hour), To: now, GroupBy: "node"}, now)
+	assert.ErrorContains(t, err, "groupBy must be namespace or label:<key>")
+	_, err = s.ChargebackReport(ChargebackOptions{From: now.Add(-time.Hour), To: now, GroupBy: "label:"}, now)
+	assert.Error(t, err)
+	_, err = s.ChargebackReport(ChargebackOptions{From: now, To: now, GroupBy: "namespace"}, now)
+	assert.ErrorContains(t, err, "is not before")
+}
+
+func TestLoadPriceTable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prices.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("currency: EUR\nprices:\n  NVIDIA-A100: 2.5\n  \"*\": 1\n"), 0o644))
+	table, err := LoadPriceTable(path)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", table.Currency)
+	assert.Equal(t, 0.5, table.MemoryWeight, "unset weights default to an even split")
+	assert.Equal(t, 2.5, table.price("NVIDIA-A100"))
+	assert.Equal(t, 1.0, table.price("NVIDIA-T4"))
+
+	require.NoError(t, os.WriteFile(path, []byte("memoryWeight: 1\nprices:\n  NVIDIA-A100: -1\n"), 0o644))
+	_, err = LoadPriceTable(path)
+	assert.ErrorContains(t, err, "must not be negative")
+
+	require.NoError(t, os.WriteFile(path, []byte("memoryWieght: 1\n"), 0o644))
+	_, err = LoadPriceTable(path)
+	assert.Error(t, err, "unknown fields are rejected")
+
+	_, err = LoadPriceTable(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
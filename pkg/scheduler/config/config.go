@@ -19,6 +19,7 @@ package config
 import (
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"reflect"
 	"time"
@@ -64,13 +65,37 @@ var (
 	// another PodGroup member. Zero disables retry (fail-fast).
 	NodeLockRetryTimeout time.Duration
 
+	// BindPhaseTimeout is how long a bound pod may stay in the allocating
+	// bind phase before the scheduler marks it failed and releases its
+	// devices. Zero disables the check.
+	BindPhaseTimeout time.Duration
+
+	// BindTimeoutDeletePod also deletes pods that timed out in the allocating
+	// phase, so that their controller recreates them.
+	BindTimeoutDeletePod bool
+
 	// If set to false, When Pod.Spec.SchedulerName equals to the const DefaultSchedulerName in k8s.io/api/core/v1 package, webhook will not overwrite it, default value is true.
 	ForceOverwriteDefaultScheduler bool
 
+	// NamespaceProfileConfigMap is the <namespace>/<name> of a ConfigMap holding
+	// per-namespace GPU request profiles keyed by namespace name. Empty disables it.
+	NamespaceProfileConfigMap string
+
+	// ChargebackPriceTable is the YAML file pricing the GPU-hours of the
+	// /chargeback report per device type. Empty reports GPU-hours only.
+	ChargebackPriceTable string
+
 	HostName                     string
 	LeaderElect                  bool
 	LeaderElectResourceName      string
 	LeaderElectResourceNamespace string
+
+	// appliedConfig is the device config whose backends are registered, used
+	// to restore the vendor settings when a reload fails half way.
+	appliedConfig *Config
+	// build constructs the device backends; tests replace it to make a
+	// reload fail after some vendors were initialized.
+	build = buildDevices
 )
 
 type Config struct {
@@ -97,15 +122,64 @@ var (
 )
 
 func InitDevicesWithConfig(config *Config) error {
-	if err := validateConfig(config); err != nil {
+	if isEmptyConfig(config) {
+		err := fmt.Errorf("all configurations are empty")
 		klog.Errorf("Invalid configuration: %v", err)
 		return err
 	}
+	// Configs that started the scheduler before the semantic checks existed
+	// must keep doing so; only validate-config and reloads reject them.
+	for _, issue := range semanticIssues(config) {
+		klog.Warningf("Device config: %s", issue)
+	}
 
 	klog.Info("Initializing devices with configuration")
 
-	device.DevicesMap = make(map[string]device.Devices)
-	device.DevicesToHandle = []string{}
+	devicesMap, devicesToHandle, err := buildDevices(config)
+	device.SetDevices(devicesMap, devicesToHandle)
+	if err != nil {
+		return err
+	}
+	appliedConfig = config
+	klog.Info("All devices initialized successfully")
+	return nil
+}
+
+// swapDevices builds the backends for cfg and registers them while holding
+// the device config lock, so no request observes a partly applied config.
+// The vendors' Init functions write package settings (nvidia.MemoryFactor,
+// resource names, the annotation key maps) as they go; the maps are cloned
+// beforehand and, if the build fails, the settings are put back by building
+// the previously applied config into the discarded clones.
+func swapDevices(cfg *Config) ([]string, error) {
+	device.LockConfig()
+	defer device.UnlockConfig()
+
+	inRequest, support, handshake := device.InRequestDevices, device.SupportDevices, util.HandshakeAnnos
+	device.InRequestDevices = maps.Clone(inRequest)
+	device.SupportDevices = maps.Clone(support)
+	util.HandshakeAnnos = maps.Clone(handshake)
+
+	devicesMap, devicesToHandle, err := build(cfg)
+	if err != nil {
+		if appliedConfig != nil {
+			if _, _, rerr := build(appliedConfig); rerr != nil {
+				klog.ErrorS(rerr, "Failed to restore the previous device settings")
+			}
+		}
+		device.InRequestDevices, device.SupportDevices, util.HandshakeAnnos = inRequest, support, handshake
+		return nil, err
+	}
+	device.SetDevices(devicesMap, devicesToHandle)
+	appliedConfig = cfg
+	return devicesToHandle, nil
+}
+
+// buildDevices constructs every device backend for config without touching
+// the registered set, so a reload can discard the result on failure.
+func buildDevices(config *Config) (map[string]device.Devices, []string, error) {
+	devicesMap := make(map[string]device.Devices)
+	devicesToHandle := []string{}
 	var initErrors []error
 
 	// Helper function to initialize devices and handle errors
@@ -117,8 +191,8 @@ func InitDevicesWithConfig(config *Config) error {
 			initErrors = append(initErrors, fmt.Errorf("%s: %v", commonWord, err))
 			return
 		}
-		device.DevicesMap[dev.CommonWord()] = dev
-		device.DevicesToHandle = append(device.DevicesToHandle, commonWord)
+		devicesMap[dev.CommonWord()] = dev
+		devicesToHandle = append(devicesToHandle, commonWord)
 		klog.Infof("%s device initialized successfully", commonWord)
 	}
 
@@ -237,49 +311,52 @@ func InitDevicesWithConfig(config *Config) error {
 	// Initialize Ascend devices
 	for _, dev := range ascend.InitDevices(config.VNPUs) {
 		commonWord := dev.CommonWord()
-		device.DevicesMap[commonWord] = dev
-		device.DevicesToHandle = append(device.DevicesToHandle, commonWord)
+		devicesMap[commonWord] = dev
+		devicesToHandle = append(devicesToHandle, commonWord)
 		klog.Infof("Ascend device %s initialized", commonWord)
 	}
 
 	// Initialize Iluvatar devices
 	for _, dev := range iluvatar.InitIluvatarDevice(config.IluvatarConfig) {
 		commonWord := dev.CommonWord()
-		device.DevicesMap[commonWord] = dev
-		device.DevicesToHandle = append(device.DevicesToHandle, commonWord)
+		devicesMap[commonWord] = dev
+		devicesToHandle = append(devicesToHandle, commonWord)
 		klog.Infof("Iluvatar device %s initialized", commonWord)
 	}
 
 	if len(initErrors) > 0 {
-		return fmt.Errorf("errors occurred during initialization: %v", initErrors)
+		return devicesMap, devicesToHandle, fmt.Errorf("errors occurred during initialization: %v", initErrors)
 	}
-
-	klog.Info("All devices initialized successfully")
-	return nil
+	return devicesMap, devicesToHandle, nil
 }
 
-// validateConfig validates the configuration object to ensure it is complete.
+// validateConfig validates the configuration object to ensure it is complete
+// and that every vendor section is semantically valid.
 func validateConfig(config *Config) error {
-	if !reflect.DeepEqual(config.NvidiaConfig, nvidia.NvidiaConfig{}) ||
-		!reflect.DeepEqual(config.CambriconConfig, cambricon.CambriconConfig{}) ||
-		!reflect.DeepEqual(config.HygonConfig, hygon.HygonConfig{}) ||
-		len(config.IluvatarConfig) > 0 ||
-		!reflect.DeepEqual(config.MthreadsConfig, mthreads.MthreadsConfig{}) ||
-		!reflect.DeepEqual(config.MetaxConfig, metax.MetaxConfig{}) ||
-		!reflect.DeepEqual(config.KunlunConfig, kunlun.KunlunConfig{}) ||
-		!reflect.DeepEqual(config.AWSNeuronConfig, awsneuron.AWSNeuronConfig{}) ||
-		!reflect.DeepEqual(config.EnflameConfig, enflame.EnflameConfig{}) ||
-		!reflect.DeepEqual(config.AMDGPUConfig, amd.AMDConfig{}) ||
-		!reflect.DeepEqual(config.VastaiConfig, vastai.VastaiConfig{}) ||
-		!reflect.DeepEqual(config.BirenConfig, biren.BirenConfig{}) ||
-		len(config.VNPUs.Configs) > 0 {
-		return nil
+	if isEmptyConfig(config) {
+		return fmt.Errorf("all configurations are empty")
 	}
-	return fmt.Errorf("all configurations are empty")
+	return issuesError(semanticIssues(config))
+}
+
+func isEmptyConfig(config *Config) bool {
+	return reflect.DeepEqual(config.NvidiaConfig, nvidia.NvidiaConfig{}) &&
+		reflect.DeepEqual(config.CambriconConfig, cambricon.CambriconConfig{}) &&
+		reflect.DeepEqual(config.HygonConfig, hygon.HygonConfig{}) &&
+		len(config.IluvatarConfig) == 0 &&
+		reflect.DeepEqual(config.MthreadsConfig, mthreads.MthreadsConfig{}) &&
+		reflect.DeepEqual(config.MetaxConfig, metax.MetaxConfig{}) &&
+		reflect.DeepEqual(config.KunlunConfig, kunlun.KunlunConfig{}) &&
+		reflect.DeepEqual(config.AWSNeuronConfig, awsneuron.AWSNeuronConfig{}) &&
+		reflect.DeepEqual(config.EnflameConfig, enflame.EnflameConfig{}) &&
+		reflect.DeepEqual(config.AMDGPUConfig, amd.AMDConfig{}) &&
+		reflect.DeepEqual(config.VastaiConfig, vastai.VastaiConfig{}) &&
+		reflect.DeepEqual(config.BirenConfig, biren.BirenConfig{}) &&
+		len(config.VNPUs.Configs) == 0
 }
 
 func InitDevices() {
-	if len(device.DevicesMap) > 0 {
+	if len(device.GetDevices()) > 0 {
 		klog.Info("Devices are already initialized, skipping initialization")
 		return
 	}
@@ -477,6 +554,7 @@ func GlobalFlagSet() *flag.FlagSet {
 	kunlun.ParseConfig(fs)
 	fs.BoolVar(&DebugMode, "debug", false, "Enable debug mode")
 	fs.StringVar(&configFile, "device-config-file", "", "Path to the device config file")
+	fs.BoolVar(&WatchDeviceConfig, "watch-device-config", false, "Reload the device config file when it changes, without restarting the scheduler")
 	klog.InitFlags(fs)
 	return fs
 }
@@ -492,6 +570,7 @@ func LoadConfig(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &yamlData); err != nil {
 		return nil, err
 	}
+	warnUnknownFields(data)
 	klog.Info("Successfully read and parsed config file")
 	return &yamlData, nil
 }
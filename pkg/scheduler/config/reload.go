@@ -0,0 +1,175 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+)
+
+// reloadDebounce coalesces the burst of events a ConfigMap volume update
+// produces (the ..data symlink swap touches several entries).
+const reloadDebounce = time.Second
+
+var (
+	// WatchDeviceConfig enables hot reload of the device config file.
+	WatchDeviceConfig bool
+
+	// ConfigReloadsTotal counts device config reload attempts by result.
+	ConfigReloadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_scheduler_device_config_reloads_total",
+		Help: "Number of device config reloads, partitioned by result (success or failure).",
+	}, []string{"result"})
+	// ConfigLastReloadSuccessful is 1 when the latest reload was applied and
+	// 0 when it was rejected and the previous config is still in effect.
+	ConfigLastReloadSuccessful = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hami_scheduler_device_config_last_reload_successful",
+		Help: "Whether the last device config reload was applied (1) or rejected (0).",
+	})
+)
+
+func init() {
+	ConfigLastReloadSuccessful.Set(1)
+}
+
+// Reloader re-reads the device config file and swaps the device backends
+// when its content changes. A config that fails to parse, validate or
+// initialize is rejected and the running backends are left untouched.
+type Reloader struct {
+	path string
+	// OnReload is called after every reload attempt that saw changed content,
+	// with the error that rejected it or nil on success.
+	OnReload func(err error)
+
+	mu       sync.Mutex
+	lastHash [sha256.Size]byte
+}
+
+// NewReloader returns a Reloader for path. The current content is treated as
+// already applied, since InitDevices loaded it at startup.
+func NewReloader(path string) *Reloader {
+	r := &Reloader{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		r.lastHash = sha256.Sum256(data)
+	}
+	return r
+}
+
+// NewDeviceConfigReloader returns a Reloader for the --device-config-file.
+func NewDeviceConfigReloader() *Reloader {
+	return NewReloader(configFile)
+}
+
+// Reload applies the config file if it changed since the last successful or
+// rejected attempt. It reports whether a new config was applied.
+func (r *Reloader) Reload() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, r.finish(fmt.Errorf("failed to read device config %s: %w", r.path, err))
+	}
+	hash := sha256.Sum256(data)
+	if bytes.Equal(hash[:], r.lastHash[:]) {
+		return false, nil
+	}
+	// Remember the content even if it is rejected, so an invalid file is
+	// reported once rather than on every unrelated event.
+	r.lastHash = hash
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return false, r.finish(fmt.Errorf("failed to parse device config %s: %w", r.path, err))
+	}
+	warnUnknownFields(data)
+	if err := validateConfig(&cfg); err != nil {
+		return false, r.finish(fmt.Errorf("invalid device config %s: %w", r.path, err))
+	}
+	devicesToHandle, err := swapDevices(&cfg)
+	if err != nil {
+		return false, r.finish(fmt.Errorf("failed to initialize devices from %s: %w", r.path, err))
+	}
+	klog.InfoS("Reloaded device config", "path", r.path, "devices", devicesToHandle)
+	return true, r.finish(nil)
+}
+
+func (r *Reloader) finish(err error) error {
+	if err != nil {
+		klog.ErrorS(err, "Rejected device config reload, keeping the previous config")
+		ConfigReloadsTotal.WithLabelValues("failure").Inc()
+		ConfigLastReloadSuccessful.Set(0)
+	} else {
+		ConfigReloadsTotal.WithLabelValues("success").Inc()
+		ConfigLastReloadSuccessful.Set(1)
+	}
+	if r.OnReload != nil {
+		r.OnReload(err)
+	}
+	return err
+}
+
+// Watch reloads the config whenever its directory changes, until stopCh is
+// closed. The directory is watched rather than the file so the atomic
+// symlink swap used by ConfigMap volumes is observed.
+func (r *Reloader) Watch(stopCh <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+		watcher.Close()
+		return err
+	}
+	go func() {
+		defer watcher.Close()
+		timer := time.NewTimer(reloadDebounce)
+		timer.Stop()
+		for {
+			select {
+			case <-stopCh:
+				timer.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				klog.V(5).InfoS("Device config directory changed", "event", event)
+				timer.Reset(reloadDebounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				klog.ErrorS(err, "Device config watcher error")
+			case <-timer.C:
+				_, _ = r.Reload()
+			}
+		}
+	}()
+	klog.InfoS("Watching device config for changes", "path", r.path)
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/v3/assert"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/cambricon"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+func writeConfig(t *testing.T, path, data string) {
+	t.Helper()
+	assert.NilError(t, os.WriteFile(path, []byte(data), 0o644))
+}
+
+func nvidiaCountName() string {
+	return device.GetDevices()[nvidia.NvidiaGPUDevice].GetResourceNames().ResourceCountName
+}
+
+func Test_Reloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "device-config.yaml")
+	writeConfig(t, path, loadTestConfig())
+	cfg, err := LoadConfig(path)
+	assert.NilError(t, err)
+	assert.NilError(t, InitDevicesWithConfig(cfg))
+
+	var results []error
+	r := NewReloader(path)
+	r.OnReload = func(err error) { results = append(results, err) }
+
+	applied, err := r.Reload()
+	assert.NilError(t, err)
+	assert.Assert(t, !applied, "unchanged content must not reload")
+	assert.Equal(t, len(results), 0)
+
+	writeConfig(t, path, strings.Replace(loadTestConfig(), "resourceCountName: nvidia.com/gpu", "resourceCountName: nvidia.com/vgpu", 1))
+	applied, err = r.Reload()
+	assert.NilError(t, err)
+	assert.Assert(t, applied)
+	assert.Equal(t, nvidiaCountName(), "nvidia.com/vgpu")
+	assert.Equal(t, testutil.ToFloat64(ConfigLastReloadSuccessful), float64(1))
+
+	failuresBefore := testutil.ToFloat64(ConfigReloadsTotal.WithLabelValues("failure"))
+	writeConfig(t, path, "nvidia: [")
+	applied, err = r.Reload()
+	assert.ErrorContains(t, err, "failed to parse device config")
+	assert.Assert(t, !applied)
+	assert.Equal(t, nvidiaCountName(), "nvidia.com/vgpu", "previous config must stay in effect")
+	assert.Equal(t, testutil.ToFloat64(ConfigLastReloadSuccessful), float64(0))
+	assert.Equal(t, testutil.ToFloat64(ConfigReloadsTotal.WithLabelValues("failure")), failuresBefore+1)
+
+	writeConfig(t, path, `
+nvidia:
+  resourceCountName: nvidia.com/gpu
+  migProfileAllowlist:
+  - models: ["A100"]
+`)
+	_, err = r.Reload()
+	assert.ErrorContains(t, err, "invalid device config")
+	assert.Equal(t, nvidiaCountName(), "nvidia.com/vgpu")
+
+	assert.Equal(t, len(results), 3)
+	assert.NilError(t, results[0])
+}
+
+func Test_ReloaderRestoresSettingsOnFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "device-config.yaml")
+	writeConfig(t, path, loadTestConfig())
+	cfg, err := LoadConfig(path)
+	assert.NilError(t, err)
+	assert.NilError(t, InitDevicesWithConfig(cfg))
+	inRequest := device.InRequestDevices
+	memoryFactor := nvidia.MemoryFactor
+
+	// Fail after every vendor already wrote its settings.
+	build = func(c *Config) (map[string]device.Devices, []string, error) {
+		if c == appliedConfig {
+			return buildDevices(c)
+		}
+		_, _, _ = buildDevices(c)
+		return nil, nil, errors.New("injected failure")
+	}
+	defer func() { build = buildDevices }()
+
+	r := NewReloader(path)
+	data := strings.Replace(loadTestConfig(), "resourceCountName: cambricon.com/vmlu", "resourceCountName: cambricon.com/other", 1)
+	writeConfig(t, path, strings.Replace(data, "defaultGPUNum: 1", "defaultGPUNum: 1\n  memoryFactor: 4", 1))
+	_, err = r.Reload()
+	assert.ErrorContains(t, err, "injected failure")
+
+	assert.Equal(t, cambricon.MLUResourceCount, "cambricon.com/vmlu")
+	assert.Equal(t, nvidia.MemoryFactor, memoryFactor)
+	assert.Equal(t, nvidiaCountName(), "nvidia.com/gpu")
+	assert.Assert(t, device.InRequestDevices[nvidia.NvidiaGPUDevice] != "")
+	// The annotation key maps in use must be the ones from before the reload.
+	inRequest["probe"] = "x"
+	defer delete(inRequest, "probe")
+	assert.Equal(t, device.InRequestDevices["probe"], "x")
+}
+
+func Test_ReloaderWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "device-config.yaml")
+	writeConfig(t, path, loadTestConfig())
+	cfg, err := LoadConfig(path)
+	assert.NilError(t, err)
+	assert.NilError(t, InitDevicesWithConfig(cfg))
+
+	reloaded := make(chan error, 1)
+	r := NewReloader(path)
+	r.OnReload = func(err error) { reloaded <- err }
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	assert.NilError(t, r.Watch(stopCh))
+
+	writeConfig(t, path, strings.Replace(loadTestConfig(), "resourceCountName: nvidia.com/gpu", "resourceCountName: nvidia.com/watched", 1))
+	select {
+	case err := <-reloaded:
+		assert.NilError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("config change was not picked up")
+	}
+	assert.Equal(t, nvidiaCountName(), "nvidia.com/watched")
+}
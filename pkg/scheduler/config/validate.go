@@ -0,0 +1,325 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/ascend"
+	"github.com/Project-HAMi/HAMi/pkg/device/hygon"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+)
+
+// Issue is a single problem found in a device config, located by the YAML
+// path of the offending field, e.g. "vnpus.configs[0].templates[1].memory".
+type Issue struct {
+	Path    string
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+// ValidateConfigData parses data as a device config and returns every
+// problem found: YAML errors, fields that do not exist in the schema, and
+// per-vendor semantic errors. An empty result means the config is valid.
+func ValidateConfigData(data []byte) []Issue {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return []Issue{{Message: err.Error()}}
+	}
+	issues := unknownFieldIssues(raw, reflect.TypeFor[Config](), "")
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return append(issues, Issue{Message: err.Error()})
+	}
+	if isEmptyConfig(&cfg) {
+		issues = append(issues, Issue{Message: "all configurations are empty"})
+	}
+	return append(issues, semanticIssues(&cfg)...)
+}
+
+// unknownFieldIssues walks the generic YAML tree alongside the Go type it is
+// decoded into and reports keys that yaml.Unmarshal would silently drop.
+func unknownFieldIssues(node any, t reflect.Type, path string) []Issue {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var issues []Issue
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := node.(map[any]any)
+		if !ok {
+			return nil
+		}
+		fields := yamlFields(t)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, fmt.Sprint(k))
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fieldPath := joinPath(path, key)
+			ft, ok := fields[key]
+			if !ok {
+				issues = append(issues, Issue{Path: fieldPath, Message: "unknown field"})
+				continue
+			}
+			issues = append(issues, unknownFieldIssues(m[key], ft, fieldPath)...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := node.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			issues = append(issues, unknownFieldIssues(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		m, ok := node.(map[any]any)
+		if !ok {
+			return nil
+		}
+		keys := make([]any, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			issues = append(issues, unknownFieldIssues(m[k], t.Elem(), joinPath(path, fmt.Sprint(k)))...)
+		}
+	}
+	return issues
+}
+
+// yamlFields maps the YAML keys of struct t to their field types, following
+// yaml.v2's naming rules and flattening ",inline" fields.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			inner := f.Type
+			for inner.Kind() == reflect.Pointer {
+				inner = inner.Elem()
+			}
+			for k, v := range yamlFields(inner) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// semanticIssues checks values that parse but cannot work: malformed
+// resource names, out-of-range factors and defaults, MIG allowlists, and
+// Ascend VNPU templates that do not fit their chip.
+func semanticIssues(cfg *Config) []Issue {
+	issues := resourceNameIssues(reflect.ValueOf(*cfg), reflect.TypeFor[Config](), "")
+
+	nv := cfg.NvidiaConfig
+	if nv.MemoryFactor < 0 {
+		issues = append(issues, Issue{Path: "nvidia.memoryFactor", Message: fmt.Sprintf("must not be negative, got %d", nv.MemoryFactor)})
+	}
+	if nv.DefaultMemory < 0 {
+		issues = append(issues, Issue{Path: "nvidia.defaultMemory", Message: fmt.Sprintf("must not be negative, got %d", nv.DefaultMemory)})
+	}
+	if nv.DefaultCores < 0 || nv.DefaultCores > 100 {
+		issues = append(issues, Issue{Path: "nvidia.defaultCores", Message: fmt.Sprintf("must be between 0 and 100, got %d", nv.DefaultCores)})
+	}
+	if nv.DefaultGPUNum < 0 {
+		issues = append(issues, Issue{Path: "nvidia.defaultGPUNum", Message: fmt.Sprintf("must not be negative, got %d", nv.DefaultGPUNum)})
+	}
+	switch nv.GPUCorePolicy {
+	case "", nvidia.DefaultCorePolicy, nvidia.ForceCorePolicy, nvidia.DisableCorePolicy:
+	default:
+		issues = append(issues, Issue{Path: "nvidia.gpuCorePolicy", Message: fmt.Sprintf("must be one of default, force, disable, got %q", nv.GPUCorePolicy)})
+	}
+	if nv.DeviceMemoryScaling != nil && *nv.DeviceMemoryScaling <= 0 {
+		issues = append(issues, Issue{Path: "nvidia.deviceMemoryScaling", Message: fmt.Sprintf("must be positive, got %v", *nv.DeviceMemoryScaling)})
+	}
+	if nv.DeviceCoreScaling != nil && *nv.DeviceCoreScaling <= 0 {
+		issues = append(issues, Issue{Path: "nvidia.deviceCoreScaling", Message: fmt.Sprintf("must be positive, got %v", *nv.DeviceCoreScaling)})
+	}
+	for i, allow := range nv.MigProfileAllowlist {
+		if err := nvidia.ValidateMigProfileAllowlist([]device.AllowedMigProfiles{allow}); err != nil {
+			issues = append(issues, Issue{Path: fmt.Sprintf("nvidia.migProfileAllowlist[%d]", i), Message: err.Error()})
+		}
+	}
+	for i, rule := range nv.XIDPolicy {
+		if err := nvidia.ValidateXIDRule(rule); err != nil {
+			issues = append(issues, Issue{Path: fmt.Sprintf("nvidia.xidPolicy[%d]", i), Message: err.Error()})
+		}
+	}
+	issues = append(issues, hygonIssues(cfg.HygonConfig)...)
+	issues = append(issues, vnpuIssues(cfg.VNPUs)...)
+	return issues
+}
+
+func hygonIssues(cfg hygon.HygonConfig) []Issue {
+	if cfg.MemoryFactor < 0 {
+		return []Issue{{Path: "hygon.memoryFactor", Message: fmt.Sprintf("must not be negative, got %d", cfg.MemoryFactor)}}
+	}
+	return nil
+}
+
+func vnpuIssues(vnpus ascend.VNPUs) []Issue {
+	var issues []Issue
+	commonWords := make(map[string]int)
+	for i, c := range vnpus.Configs {
+		path := fmt.Sprintf("vnpus.configs[%d]", i)
+		if c.CommonWord == "" {
+			issues = append(issues, Issue{Path: path + ".commonWord", Message: "must not be empty"})
+		} else if prev, dup := commonWords[c.CommonWord]; dup {
+			issues = append(issues, Issue{Path: path + ".commonWord", Message: fmt.Sprintf("duplicates vnpus.configs[%d]", prev)})
+		} else {
+			commonWords[c.CommonWord] = i
+		}
+		if c.ResourceName == "" {
+			issues = append(issues, Issue{Path: path + ".resourceName", Message: "must not be empty"})
+		}
+		if c.MemoryFactor < 0 {
+			issues = append(issues, Issue{Path: path + ".memoryFactor", Message: fmt.Sprintf("must not be negative, got %d", c.MemoryFactor)})
+		}
+		if c.MemoryAllocatable <= 0 {
+			issues = append(issues, Issue{Path: path + ".memoryAllocatable", Message: fmt.Sprintf("must be positive, got %d", c.MemoryAllocatable)})
+		}
+		if c.MemoryCapacity > 0 && c.MemoryAllocatable > c.MemoryCapacity {
+			issues = append(issues, Issue{Path: path + ".memoryAllocatable", Message: fmt.Sprintf("exceeds memoryCapacity %d", c.MemoryCapacity)})
+		}
+		if c.AICore < 0 {
+			issues = append(issues, Issue{Path: path + ".aiCore", Message: fmt.Sprintf("must not be negative, got %d", c.AICore)})
+		}
+		templateNames := make(map[string]int)
+		for j, tmpl := range c.Templates {
+			tpath := fmt.Sprintf("%s.templates[%d]", path, j)
+			if tmpl.Name == "" {
+				issues = append(issues, Issue{Path: tpath + ".name", Message: "must not be empty"})
+			} else if prev, dup := templateNames[tmpl.Name]; dup {
+				issues = append(issues, Issue{Path: tpath + ".name", Message: fmt.Sprintf("duplicates %s.templates[%d]", path, prev)})
+			} else {
+				templateNames[tmpl.Name] = j
+			}
+			if tmpl.Memory <= 0 {
+				issues = append(issues, Issue{Path: tpath + ".memory", Message: fmt.Sprintf("must be positive, got %d", tmpl.Memory)})
+			} else if c.MemoryAllocatable > 0 && tmpl.Memory > c.MemoryAllocatable {
+				issues = append(issues, Issue{Path: tpath + ".memory", Message: fmt.Sprintf("exceeds memoryAllocatable %d", c.MemoryAllocatable)})
+			}
+			if tmpl.AICore < 0 || (c.AICore > 0 && tmpl.AICore > c.AICore) {
+				issues = append(issues, Issue{Path: tpath + ".aiCore", Message: fmt.Sprintf("must be between 0 and the chip's aiCore %d, got %d", c.AICore, tmpl.AICore)})
+			}
+			if tmpl.AICPU < 0 || (c.AICPU > 0 && tmpl.AICPU > c.AICPU) {
+				issues = append(issues, Issue{Path: tpath + ".aiCPU", Message: fmt.Sprintf("must be between 0 and the chip's aiCPU %d, got %d", c.AICPU, tmpl.AICPU)})
+			}
+		}
+	}
+	return issues
+}
+
+// resourceNameIssues checks every string field whose YAML key starts with
+// "resource" (resourceCountName, resourceNameGCU, ...) is a valid extended
+// resource name. Every vendor names these fields that way.
+func resourceNameIssues(v reflect.Value, t reflect.Type, path string) []Issue {
+	var issues []Issue
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			fieldPath := path
+			if !strings.Contains(opts, "inline") {
+				if name == "" || name == "-" {
+					continue
+				}
+				fieldPath = joinPath(path, name)
+			}
+			fv := v.Field(i)
+			if f.Type.Kind() == reflect.String && strings.HasPrefix(name, "resource") {
+				if s := fv.String(); s != "" {
+					for _, msg := range validation.IsQualifiedName(s) {
+						issues = append(issues, Issue{Path: fieldPath, Message: fmt.Sprintf("invalid resource name %q: %s", s, msg)})
+					}
+				}
+				continue
+			}
+			issues = append(issues, resourceNameIssues(fv, f.Type, fieldPath)...)
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			issues = append(issues, resourceNameIssues(v.Index(i), t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return issues
+}
+
+// issuesError joins issues into one error, or returns nil for none.
+func issuesError(issues []Issue) error {
+	errs := make([]error, len(issues))
+	for i, issue := range issues {
+		errs[i] = errors.New(issue.String())
+	}
+	return errors.Join(errs...)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// warnUnknownFields logs keys the schema does not know about. Startup and
+// reload only warn so that configs carrying stale keys keep working; use
+// the validate-config subcommand to treat them as errors.
+func warnUnknownFields(data []byte) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return
+	}
+	for _, issue := range unknownFieldIssues(raw, reflect.TypeFor[Config](), "") {
+		klog.Warningf("Device config: %s", issue)
+	}
+}
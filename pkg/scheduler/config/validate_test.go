@@ -0,0 +1,196 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func issueStrings(issues []Issue) []string {
+	out := make([]string, len(issues))
+	for i, issue := range issues {
+		out[i] = issue.String()
+	}
+	return out
+}
+
+func Test_ValidateConfigData(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "test config is valid",
+			data: loadTestConfig(),
+			want: []string{},
+		},
+		{
+			name: "yaml syntax error",
+			data: "nvidia: [",
+			want: []string{"yaml: line 1: did not find expected node content"},
+		},
+		{
+			name: "empty config",
+			data: "{}",
+			want: []string{"all configurations are empty"},
+		},
+		{
+			name: "unknown fields at every level",
+			data: `
+nvidia:
+  resourceCountName: nvidia.com/gpu
+  resourceCountNmae: nvidia.com/gpu
+  deviceSplitCnt: 10
+vnpu: {}
+iluvatars:
+- commonWord: MR-V100
+  chip: MR-V100
+`,
+			want: []string{
+				"iluvatars[0].chip: unknown field",
+				"nvidia.deviceSplitCnt: unknown field",
+				"nvidia.resourceCountNmae: unknown field",
+				"vnpu: unknown field",
+			},
+		},
+		{
+			name: "inline node defaults are known",
+			data: `
+nvidia:
+  resourceCountName: nvidia.com/gpu
+  deviceSplitCount: 10
+  deviceMemoryScaling: 1
+  enableNumaTopology: true
+`,
+			want: []string{},
+		},
+		{
+			name: "nvidia semantic errors",
+			data: `
+nvidia:
+  resourceCountName: nvidia.com/gpu
+  memoryFactor: -1
+  defaultCores: 101
+  gpuCorePolicy: strict
+  deviceCoreScaling: 0
+  migProfileAllowlist:
+  - models: ["A30"]
+    profiles: ["1g.6gb"]
+  - models: ["A100"]
+    profiles: ["6gb"]
+  xidPolicy:
+  - xids: ["48", "61-64"]
+    action: unhealthy
+  - xids: ["64-61"]
+    action: warn
+  - xids: ["79"]
+    action: reboot
+`,
+			want: []string{
+				"nvidia.memoryFactor: must not be negative, got -1",
+				"nvidia.defaultCores: must be between 0 and 100, got 101",
+				`nvidia.gpuCorePolicy: must be one of default, force, disable, got "strict"`,
+				"nvidia.deviceCoreScaling: must be positive, got 0",
+				`nvidia.migProfileAllowlist[1]: invalid MIG profile "6gb"`,
+				`nvidia.xidPolicy[1]: invalid XID range "64-61"`,
+				`nvidia.xidPolicy[2]: action must be one of ignore, warn, unhealthy, drain, got "reboot"`,
+			},
+		},
+		{
+			name: "vnpu template consistency",
+			data: `
+vnpus:
+  configs:
+  - commonWord: Ascend910B
+    resourceName: huawei.com/Ascend910B
+    memoryAllocatable: 65536
+    memoryCapacity: 32768
+    aiCore: 20
+    templates:
+    - name: vir05
+      memory: 8192
+      aiCore: 5
+    - name: vir05
+      memory: 70000
+      aiCore: 24
+  - commonWord: Ascend910B
+    resourceName: huawei.com/Ascend910B2
+    memoryAllocatable: 1024
+`,
+			want: []string{
+				"vnpus.configs[0].memoryAllocatable: exceeds memoryCapacity 32768",
+				"vnpus.configs[0].templates[1].name: duplicates vnpus.configs[0].templates[0]",
+				"vnpus.configs[0].templates[1].memory: exceeds memoryAllocatable 65536",
+				"vnpus.configs[0].templates[1].aiCore: must be between 0 and the chip's aiCore 20, got 24",
+				"vnpus.configs[1].commonWord: duplicates vnpus.configs[0]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.DeepEqual(t, issueStrings(ValidateConfigData([]byte(tt.data))), tt.want)
+		})
+	}
+}
+
+func Test_ValidateConfigDataResourceNames(t *testing.T) {
+	issues := ValidateConfigData([]byte(`
+nvidia:
+  resourceCountName: nvidia.com/gpu/x
+  resourceMemoryName: nvidia.com/gpumem
+`))
+	assert.Equal(t, len(issues), 1)
+	assert.Equal(t, issues[0].Path, "nvidia.resourceCountName")
+	assert.Assert(t, strings.HasPrefix(issues[0].Message, `invalid resource name "nvidia.com/gpu/x"`), issues[0].Message)
+}
+
+func Test_validateConfigSemantic(t *testing.T) {
+	cfg := &Config{}
+	cfg.NvidiaConfig.ResourceCountName = "nvidia.com/gpu"
+	cfg.NvidiaConfig.DefaultCores = 200
+	assert.ErrorContains(t, validateConfig(cfg), "nvidia.defaultCores: must be between 0 and 100")
+}
+
+func Test_unknownFieldIssuesSortsMapKeys(t *testing.T) {
+	type entry struct {
+		Name string `yaml:"name"`
+	}
+	var raw any
+	assert.NilError(t, yaml.Unmarshal([]byte(`
+zeta: {bad: 1}
+alpha: {bad: 1}
+mid: {bad: 1}
+`), &raw))
+	want := []string{"alpha.bad: unknown field", "mid.bad: unknown field", "zeta.bad: unknown field"}
+	for range 10 {
+		assert.DeepEqual(t, issueStrings(unknownFieldIssues(raw, reflect.TypeFor[map[string]entry](), "")), want)
+	}
+}
+
+func Test_InitDevicesWithConfigWarnsOnSemanticIssues(t *testing.T) {
+	cfg := &Config{}
+	cfg.NvidiaConfig.ResourceCountName = "nvidia.com/gpu"
+	cfg.NvidiaConfig.DefaultCores = 200
+	assert.NilError(t, InitDevicesWithConfig(cfg))
+	assert.ErrorContains(t, InitDevicesWithConfig(&Config{}), "all configurations are empty")
+}
@@ -18,6 +18,7 @@ package scheduler
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
 
@@ -40,6 +41,20 @@ const (
 	EventReasonBindingFailed = "BindingFailed"
 	// EventReasonBindingSucceed indicates that  binding succeed.
 	EventReasonBindingSucceed = "BindingSucceed"
+
+	// EventReasonConfigReloaded indicates that a new device config was applied.
+	EventReasonConfigReloaded = "DeviceConfigReloaded"
+	// EventReasonConfigReloadFailed indicates that a device config was rejected.
+	EventReasonConfigReloadFailed = "DeviceConfigReloadFailed"
+
+	// EventReasonResized indicates that a vGPU resize request was applied.
+	EventReasonResized = "VGPUResized"
+	// EventReasonResizeFailed indicates that a vGPU resize request was rejected.
+	EventReasonResizeFailed = "VGPUResizeFailed"
+
+	// EventReasonBindTimeout indicates that a bound pod's devices were never
+	// allocated by the device plugin and its binding was given up.
+	EventReasonBindTimeout = "BindTimeout"
 )
 
 func (s *Scheduler) addAllEventHandlers() {
@@ -53,6 +68,21 @@ func (s *Scheduler) addAllEventHandlers() {
 	s.eventRecorder = eventBroadcaster.NewRecorder(schema, corev1.EventSource{Component: config.SchedulerName})
 }
 
+// recordConfigReloadEvent reports a device config reload on the scheduler's
+// own Pod, identified by the POD_NAME and POD_NAMESPACE downward API env.
+func (s *Scheduler) recordConfigReloadEvent(reloadErr error) {
+	name, namespace := os.Getenv("POD_NAME"), os.Getenv("POD_NAMESPACE")
+	if s.eventRecorder == nil || name == "" || namespace == "" {
+		return
+	}
+	ref := &corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Name: name, Namespace: namespace}
+	if reloadErr == nil {
+		s.eventRecorder.Event(ref, corev1.EventTypeNormal, EventReasonConfigReloaded, "Applied the updated device config")
+	} else {
+		s.eventRecorder.Event(ref, corev1.EventTypeWarning, EventReasonConfigReloadFailed, reloadErr.Error())
+	}
+}
+
 func (s *Scheduler) recordScheduleBindingResultEvent(pod *corev1.Pod, eventReason string, nodeResult []string, schedulerErr error) {
 	if pod == nil {
 		return
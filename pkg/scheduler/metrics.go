@@ -0,0 +1,99 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+// Results of a Filter or Bind call, used as the result label.
+const (
+	resultScheduled     = "scheduled"
+	resultUnschedulable = "unschedulable"
+	resultBound         = "bound"
+	resultError         = "error"
+)
+
+var latencyBuckets = prometheus.ExponentialBuckets(0.001, 2, 16)
+
+var (
+	// FilterDuration is the latency of Filter calls for pods requesting
+	// HAMi devices, partitioned by result.
+	FilterDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hami_scheduler_filter_duration_seconds",
+		Help:    "Latency of filter requests for pods requesting HAMi devices, partitioned by result (scheduled, unschedulable or error).",
+		Buckets: latencyBuckets,
+	}, []string{"result"})
+	// FilterEvaluatedNodes is the number of nodes a Filter call scored.
+	FilterEvaluatedNodes = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hami_scheduler_filter_evaluated_nodes",
+		Help:    "Number of nodes whose devices were evaluated by a filter request.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	})
+	// ScoreDuration is the time Filter spends fitting the pod onto the
+	// devices of every candidate node.
+	ScoreDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hami_scheduler_score_duration_seconds",
+		Help:    "Time spent fitting a pod onto the devices of the candidate nodes.",
+		Buckets: latencyBuckets,
+	})
+	// FilterNodeFailuresTotal counts the nodes Filter rejected, partitioned
+	// by the reasons the device backends reported for them. A node rejected
+	// for several reasons is counted under each.
+	FilterNodeFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_scheduler_filter_node_failures_total",
+		Help: "Number of nodes rejected by filter requests, partitioned by reason (e.g. CardTypeMismatch, CardInsufficientMemory, NumaNotFit).",
+	}, []string{"reason"})
+	// BindDuration is the latency of Bind calls, partitioned by result.
+	BindDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hami_scheduler_bind_duration_seconds",
+		Help:    "Latency of bind requests, partitioned by result (bound or error).",
+		Buckets: latencyBuckets,
+	}, []string{"result"})
+	// NodeLockWaitDuration is the time Bind waits for the node locks of the
+	// pod's devices, partitioned by whether they were acquired.
+	NodeLockWaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hami_scheduler_node_lock_wait_seconds",
+		Help:    "Time spent acquiring node locks while binding, partitioned by result (acquired or failed).",
+		Buckets: latencyBuckets,
+	}, []string{"result"})
+	// NodeLockContentionsTotal counts the binds that failed because other
+	// pods were allocating on the node.
+	NodeLockContentionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hami_scheduler_node_lock_contentions_total",
+		Help: "Number of bind requests rejected because the node was locked by pods still allocating devices.",
+	})
+	// BindTimeoutsTotal counts the pods given up on after staying in the
+	// allocating bind phase for longer than config.BindPhaseTimeout.
+	BindTimeoutsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_scheduler_bind_timeouts_total",
+		Help: "Number of pods whose device allocation timed out after binding, partitioned by action (failed or deleted).",
+	}, []string{"action"})
+)
+
+// filterResult classifies the outcome of a Filter call for FilterDuration.
+func filterResult(res *extenderv1.ExtenderFilterResult, err error) string {
+	switch {
+	case err != nil || res == nil || res.Error != "":
+		return resultError
+	case res.NodeNames == nil || len(*res.NodeNames) == 0:
+		return resultUnschedulable
+	default:
+		return resultScheduled
+	}
+}
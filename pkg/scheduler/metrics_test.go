@@ -0,0 +1,143 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/common"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func histogramCount(t *testing.T, h prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	require.NoError(t, h.(prometheus.Metric).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func Test_filterResult(t *testing.T) {
+	assert.Equal(t, resultError, filterResult(nil, assert.AnError))
+	assert.Equal(t, resultError, filterResult(&extenderv1.ExtenderFilterResult{Error: "boom"}, nil))
+	assert.Equal(t, resultUnschedulable, filterResult(&extenderv1.ExtenderFilterResult{FailedNodes: map[string]string{"node1": "x"}}, nil))
+	assert.Equal(t, resultUnschedulable, filterResult(&extenderv1.ExtenderFilterResult{NodeNames: &[]string{}}, nil))
+	assert.Equal(t, resultScheduled, filterResult(&extenderv1.ExtenderFilterResult{NodeNames: &[]string{"node1"}}, nil))
+}
+
+func TestFilterMetrics(t *testing.T) {
+	require.NoError(t, config.InitDevicesWithConfig(&config.Config{
+		NvidiaConfig: nvidia.NvidiaConfig{
+			ResourceCountName: "hami.io/gpu", ResourceMemoryName: "hami.io/gpumem",
+			ResourceCoreName: "hami.io/gpucores", DefaultGPUNum: 1,
+		},
+	}))
+	s := NewScheduler()
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {{ID: "GPU0", Count: 10, Devmem: 8192, Devcore: 100, Type: "NVIDIA-A100", Mode: nvidia.HamiCoreMode, Health: true}},
+		},
+	})
+	newPod := func(name string, mem int64) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{UID: k8stypes.UID("uid-" + name), Name: name, Namespace: "metrics"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "c",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					"hami.io/gpu":    *resource.NewQuantity(1, resource.BinarySI),
+					"hami.io/gpumem": *resource.NewQuantity(mem, resource.BinarySI),
+				}},
+			}}},
+		}
+	}
+	unfit, fit := newPod("unfit", 16384), newPod("fit", 4096)
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewClientset(fit)
+	t.Cleanup(func() {
+		client.KubeClient = previousKubeClient
+		s.onDelPod(fit)
+	})
+
+	insufficientMemory := FilterNodeFailuresTotal.WithLabelValues(common.CardInsufficientMemory)
+	failuresBefore := testutil.ToFloat64(insufficientMemory)
+	unschedulableBefore := histogramCount(t, FilterDuration.WithLabelValues(resultUnschedulable))
+	scheduledBefore := histogramCount(t, FilterDuration.WithLabelValues(resultScheduled))
+	evaluatedBefore := histogramCount(t, FilterEvaluatedNodes)
+	scoredBefore := histogramCount(t, ScoreDuration)
+
+	res, err := s.Filter(extenderv1.ExtenderArgs{Pod: unfit, NodeNames: &[]string{"node1"}})
+	require.NoError(t, err)
+	require.Contains(t, res.FailedNodes, "node1")
+	assert.Equal(t, failuresBefore+1, testutil.ToFloat64(insufficientMemory))
+	assert.Equal(t, unschedulableBefore+1, histogramCount(t, FilterDuration.WithLabelValues(resultUnschedulable)))
+
+	res, err = s.Filter(extenderv1.ExtenderArgs{Pod: fit, NodeNames: &[]string{"node1"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"node1"}, *res.NodeNames)
+	assert.Equal(t, scheduledBefore+1, histogramCount(t, FilterDuration.WithLabelValues(resultScheduled)))
+	assert.Equal(t, evaluatedBefore+2, histogramCount(t, FilterEvaluatedNodes))
+	assert.Equal(t, scoredBefore+2, histogramCount(t, ScoreDuration))
+	assert.Equal(t, failuresBefore+1, testutil.ToFloat64(insufficientMemory), "a fitting node is no failure")
+
+	// Pods without HAMi resources take the fast path and are not timed.
+	cpuPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cpu", Namespace: "metrics"}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "c"}}}}
+	_, err = s.Filter(extenderv1.ExtenderArgs{Pod: cpuPod, NodeNames: &[]string{"node1"}})
+	require.NoError(t, err)
+	assert.Equal(t, scheduledBefore+1, histogramCount(t, FilterDuration.WithLabelValues(resultScheduled)))
+}
+
+func TestBindMetrics(t *testing.T) {
+	s := NewScheduler()
+	s.kubeClient = fake.NewClientset()
+	s.podLister = informers.NewSharedInformerFactoryWithOptions(s.kubeClient, 0).Core().V1().Pods().Lister()
+
+	errorsBefore := histogramCount(t, BindDuration.WithLabelValues(resultError))
+	_, err := s.Bind(extenderv1.ExtenderBindingArgs{PodName: "missing", PodNamespace: "metrics", PodUID: "uid-missing", Node: "node1"})
+	require.Error(t, err)
+	assert.Equal(t, errorsBefore+1, histogramCount(t, BindDuration.WithLabelValues(resultError)))
+}
+
+func TestBindCountsNodeLockContention(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "contended", Namespace: "default", UID: "uid-contended"}}
+	previousKubeClient := client.KubeClient
+	t.Cleanup(func() { client.KubeClient = previousKubeClient })
+	s, args, cleanup := setupBindLockRetryTest(t, 0, pod, &bindLockMockDevice{lockErr: errContention})
+	defer cleanup()
+
+	before := testutil.ToFloat64(NodeLockContentionsTotal)
+	res, err := s.Bind(args)
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Error)
+	assert.Equal(t, before+1, testutil.ToFloat64(NodeLockContentionsTotal))
+}
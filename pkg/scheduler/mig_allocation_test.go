@@ -113,6 +113,35 @@ func TestGetNodesUsageRestoresUnconsumedMigReservations(t *testing.T) {
 	}
 }
 
+func TestGetNodesUsageAppliesPodDeviceModes(t *testing.T) {
+	nodes := newNodeManager()
+	nodes.addNode("node1", &device.NodeInfo{
+		ID: "node1", Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{nvidia.NvidiaGPUDevice: {
+			{ID: "GPU-a", Count: 10, Devmem: 40960, Devcore: 100, Mode: nvidia.HamiCoreMode, Modes: []string{nvidia.HamiCoreMode, nvidia.MpsMode}, Health: true},
+			{ID: "GPU-b", Count: 10, Devmem: 40960, Devcore: 100, Mode: nvidia.HamiCoreMode, Modes: []string{nvidia.HamiCoreMode, nvidia.MpsMode}, Health: true},
+		}},
+	})
+	pods := device.NewPodManager()
+	pods.AddPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		UID: "pod-1", Name: "pod-1", Namespace: "default",
+		Annotations: map[string]string{nvidia.DeviceModesAnnotation: `{"GPU-a":"mps"}`},
+	}}, "node1", device.PodDevices{nvidia.NvidiaGPUDevice: {{{UUID: "GPU-a", Usedmem: 1000, Usedcores: 10}}}})
+	s := Scheduler{nodeManager: nodes, podManager: pods}
+	nodeNames := []string{"node1"}
+	usage, _, _, err := s.getNodesUsage(&nodeNames, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices := (*usage)["node1"].Devices.DeviceLists
+	if devices[0].Device.Mode != nvidia.MpsMode || devices[1].Device.Mode != nvidia.HamiCoreMode {
+		t.Fatalf("device modes: %q, %q", devices[0].Device.Mode, devices[1].Device.Mode)
+	}
+	if len(devices[0].Device.Modes) != 2 {
+		t.Fatalf("switchable modes not carried over: %v", devices[0].Device.Modes)
+	}
+}
+
 func twoNvidiaDeviceRequest(mode string) (*corev1.Pod, device.ContainerDeviceRequest, device.ContainerDeviceRequests) {
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -263,3 +292,16 @@ func TestFitInDevicesRetainsPhysicalCountCheckForNonMigGPU(t *testing.T) {
 		t.Fatalf("fitInDevices() = %v, reason = %q, devices = %+v; want physical-device-count rejection", fit, reason, allocated)
 	}
 }
+
+func TestMigAllocationUsageKeepsComputePlacement(t *testing.T) {
+	usage := migAllocationUsage(nvidia.MigAllocation{
+		Profile: "1c.3g.20gb", Placement: device.MigPlacement{Start: 4, Size: 4},
+		ComputePlacement: &device.MigPlacement{Start: 1, Size: 1},
+	})
+	if usage.ComputePlacement != (device.MigPlacement{Start: 1, Size: 1}) {
+		t.Fatalf("compute placement = %+v, want {1 1}", usage.ComputePlacement)
+	}
+	if usage = migAllocationUsage(nvidia.MigAllocation{Profile: "3g.20gb", Placement: device.MigPlacement{Start: 4, Size: 4}}); usage.ComputePlacement.Size != 0 {
+		t.Fatalf("GPU instance allocation got compute placement %+v", usage.ComputePlacement)
+	}
+}
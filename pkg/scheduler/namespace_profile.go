@@ -0,0 +1,313 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+// NamespaceProfileAnnotation is a namespace annotation carrying a JSON
+// NamespaceProfile. It takes precedence over the namespace's entry in the
+// --namespace-profile-configmap ConfigMap.
+const NamespaceProfileAnnotation = "hami.io/gpu-profile"
+
+// NamespaceProfile holds namespace-scoped defaults and limits for HAMi
+// resources, in the spirit of a LimitRange. Memory is in the same unit as the
+// vendor's memory resource, before the memory factor is applied. Zero values
+// mean "not set" and fall back to the global device config.
+type NamespaceProfile struct {
+	// DefaultMemory is the per-vGPU memory assigned to containers that
+	// request a device count but no memory.
+	DefaultMemory int64 `json:"defaultMemory,omitempty"`
+	// DefaultCores is the per-vGPU core percentage assigned to containers
+	// that request a device count but no cores.
+	DefaultCores int64 `json:"defaultCores,omitempty"`
+	// MaxMemoryPerContainer caps the total device memory of one container,
+	// i.e. memory per vGPU times the number of vGPUs.
+	MaxMemoryPerContainer int64 `json:"maxMemoryPerContainer,omitempty"`
+	// AllowedTypes restricts the NVIDIA card types pods may select. Pods
+	// without nvidia.com/use-gputype get it set to this list.
+	AllowedTypes []string `json:"allowedTypes,omitempty"`
+}
+
+func (p *NamespaceProfile) validate() error {
+	if p.DefaultMemory < 0 {
+		return fmt.Errorf("defaultMemory must not be negative, got %d", p.DefaultMemory)
+	}
+	if p.DefaultCores < 0 || p.DefaultCores > 100 {
+		return fmt.Errorf("defaultCores must be between 0 and 100, got %d", p.DefaultCores)
+	}
+	if p.MaxMemoryPerContainer < 0 {
+		return fmt.Errorf("maxMemoryPerContainer must not be negative, got %d", p.MaxMemoryPerContainer)
+	}
+	if p.MaxMemoryPerContainer > 0 && p.DefaultMemory > p.MaxMemoryPerContainer {
+		return fmt.Errorf("defaultMemory %d exceeds maxMemoryPerContainer %d", p.DefaultMemory, p.MaxMemoryPerContainer)
+	}
+	for _, t := range p.AllowedTypes {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("allowedTypes must not contain empty entries")
+		}
+	}
+	return nil
+}
+
+func parseNamespaceProfile(raw string) (*NamespaceProfile, error) {
+	profile := &NamespaceProfile{}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(profile); err != nil {
+		return nil, err
+	}
+	if err := profile.validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Listers getNamespaceProfile reads profiles from, so that admission does
+// not call the API server for every pod. They are set by
+// watchNamespaceProfiles; until then no namespace has a profile.
+var (
+	namespaceLister        listerscorev1.NamespaceLister
+	profileConfigMapLister listerscorev1.ConfigMapNamespaceLister
+)
+
+// watchNamespaceProfiles starts the informers behind getNamespaceProfile: one
+// on namespaces and, with --namespace-profile-configmap, one on that
+// ConfigMap alone.
+func watchNamespaceProfiles(kubeClient kubernetes.Interface, stopCh <-chan struct{}) {
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeClient, defaultResync)
+	namespaces := factory.Core().V1().Namespaces()
+	informersToSync := []cache.InformerSynced{namespaces.Informer().HasSynced}
+	var configMaps listerscorev1.ConfigMapNamespaceLister
+	if cmNamespace, cmName, ok := strings.Cut(config.NamespaceProfileConfigMap, "/"); ok {
+		cmFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, defaultResync,
+			informers.WithNamespace(cmNamespace),
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", cmName).String()
+			}))
+		informer := cmFactory.Core().V1().ConfigMaps()
+		informersToSync = append(informersToSync, informer.Informer().HasSynced)
+		configMaps = informer.Lister().ConfigMaps(cmNamespace)
+		cmFactory.Start(stopCh)
+	}
+	factory.Start(stopCh)
+	cache.WaitForCacheSync(stopCh, informersToSync...)
+	namespaceLister, profileConfigMapLister = namespaces.Lister(), configMaps
+}
+
+// getNamespaceProfile looks up the profile for ns, first on the namespace
+// annotation and then in the configured ConfigMap, keyed by namespace name.
+// A nil profile and nil error mean the namespace has no profile.
+func getNamespaceProfile(ns string) (*NamespaceProfile, error) {
+	if namespaceLister == nil {
+		return nil, nil
+	}
+	namespace, err := namespaceLister.Get(ns)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", ns, err)
+	}
+	if err == nil {
+		if raw, ok := namespace.Annotations[NamespaceProfileAnnotation]; ok {
+			profile, err := parseNamespaceProfile(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s annotation on namespace %s: %w", NamespaceProfileAnnotation, ns, err)
+			}
+			return profile, nil
+		}
+	}
+
+	if profileConfigMapLister == nil {
+		return nil, nil
+	}
+	_, cmName, _ := strings.Cut(config.NamespaceProfileConfigMap, "/")
+	cm, err := profileConfigMapLister.Get(cmName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get configmap %s: %w", config.NamespaceProfileConfigMap, err)
+	}
+	raw, ok := cm.Data[ns]
+	if !ok {
+		return nil, nil
+	}
+	profile, err := parseNamespaceProfile(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile for namespace %s in configmap %s: %w", ns, config.NamespaceProfileConfigMap, err)
+	}
+	return profile, nil
+}
+
+// requestsDevices reports whether any container of pod asks for a HAMi
+// device, by count, memory or cores.
+func requestsDevices(pod *corev1.Pod) bool {
+	for _, ctrs := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range ctrs {
+			for _, dev := range device.GetDevices() {
+				if requestsDevice(&ctrs[i], dev.GetResourceNames()) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func requestsDevice(ctr *corev1.Container, names device.ResourceNames) bool {
+	return hasResourceLimit(ctr, names.ResourceCountName) ||
+		hasResourceLimit(ctr, names.ResourceMemoryName) ||
+		hasResourceLimit(ctr, names.ResourceMemoryPercentageName) ||
+		hasResourceLimit(ctr, names.ResourceCoreName)
+}
+
+// applyNamespaceDefaults fills in the profile's default memory and cores on
+// every container that requests a HAMi device. It runs before the vendors'
+// MutateAdmission, so their own defaults, such as exclusive cores for a bare
+// device count, only apply where the profile sets none.
+func applyNamespaceDefaults(pod *corev1.Pod, profile *NamespaceProfile) {
+	if profile == nil {
+		return
+	}
+	for _, ctrs := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range ctrs {
+			ctr := &ctrs[i]
+			for _, dev := range device.GetDevices() {
+				names := dev.GetResourceNames()
+				if !requestsDevice(ctr, names) {
+					continue
+				}
+				// A memory percentage is the container's memory request.
+				if !hasResourceLimit(ctr, names.ResourceMemoryPercentageName) {
+					setDefaultLimit(ctr, names.ResourceMemoryName, profile.DefaultMemory)
+				}
+				setDefaultLimit(ctr, names.ResourceCoreName, profile.DefaultCores)
+			}
+		}
+	}
+}
+
+// enforceNamespaceProfile checks the profile's limits on every container that
+// requests a HAMi device. It runs after the vendors' MutateAdmission, so a
+// device count defaulted by DefaultGPUNum is already present, and before
+// fitResourceQuota so that nvidia.com/use-gputype is set when the quota is
+// checked.
+func enforceNamespaceProfile(pod *corev1.Pod, profile *NamespaceProfile) error {
+	if profile == nil {
+		return nil
+	}
+	requestsNvidia := false
+	for _, ctrs := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range ctrs {
+			ctr := &ctrs[i]
+			for deviceName, dev := range device.GetDevices() {
+				names := dev.GetResourceNames()
+				if !hasResourceLimit(ctr, names.ResourceCountName) {
+					continue
+				}
+				requestsNvidia = requestsNvidia || deviceName == nvidia.NvidiaGPUDevice
+				if profile.MaxMemoryPerContainer == 0 {
+					continue
+				}
+				req := dev.GenerateResourceRequests(ctr)
+				if req.Memreq == 0 && req.MemPercentagereq > 0 && req.MemPercentagereq <= 100 {
+					// The memory a percentage amounts to depends on the GPU
+					// the scheduler picks, so it cannot be bounded here.
+					return fmt.Errorf("container %s requests %d%% of each device's memory, which cannot be checked against the namespace limit of %d %s; request %s instead",
+						ctr.Name, req.MemPercentagereq, profile.MaxMemoryPerContainer, names.ResourceMemoryName, names.ResourceMemoryName)
+				}
+				mem := int64(req.Memreq) * int64(req.Nums) / int64(max(names.MemoryFactor, 1))
+				if mem > profile.MaxMemoryPerContainer {
+					return fmt.Errorf("container %s requests %d %s in total, exceeding the namespace limit of %d", ctr.Name, mem, names.ResourceMemoryName, profile.MaxMemoryPerContainer)
+				}
+			}
+		}
+	}
+	if requestsNvidia && len(profile.AllowedTypes) > 0 {
+		return restrictGPUTypes(pod, profile.AllowedTypes)
+	}
+	return nil
+}
+
+// restrictGPUTypes defaults nvidia.com/use-gputype to the allowed list, or
+// rejects the pod if it explicitly asks for a type outside of it. A requested
+// type is allowed when it contains an allowed one, matching the substring
+// comparison device.CheckType applies to card models, so A100-SXM4-80GB fits
+// a namespace allowed A100.
+func restrictGPUTypes(pod *corev1.Pod, allowed []string) error {
+	requested, ok := pod.Annotations[nvidia.GPUInUse]
+	if !ok || strings.TrimSpace(requested) == "" {
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+		pod.Annotations[nvidia.GPUInUse] = strings.Join(allowed, ",")
+		return nil
+	}
+	for t := range strings.SplitSeq(requested, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !slices.ContainsFunc(allowed, func(a string) bool {
+			a = strings.TrimSpace(a)
+			return a != "" && strings.Contains(strings.ToUpper(t), strings.ToUpper(a))
+		}) {
+			return fmt.Errorf("card type %q is not allowed in namespace %s, allowed types are %s", t, pod.Namespace, strings.Join(allowed, ","))
+		}
+	}
+	return nil
+}
+
+func hasResourceLimit(ctr *corev1.Container, name string) bool {
+	if name == "" {
+		return false
+	}
+	if _, ok := ctr.Resources.Limits[corev1.ResourceName(name)]; ok {
+		return true
+	}
+	_, ok := ctr.Resources.Requests[corev1.ResourceName(name)]
+	return ok
+}
+
+func setDefaultLimit(ctr *corev1.Container, name string, value int64) {
+	if value == 0 || name == "" || hasResourceLimit(ctr, name) {
+		return
+	}
+	if ctr.Resources.Limits == nil {
+		ctr.Resources.Limits = make(corev1.ResourceList)
+	}
+	klog.V(4).InfoS("Applying namespace default", "container", ctr.Name, "resource", name, "value", value)
+	ctr.Resources.Limits[corev1.ResourceName(name)] = *resource.NewQuantity(value, resource.DecimalSI)
+}
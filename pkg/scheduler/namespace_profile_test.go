@@ -0,0 +1,246 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+)
+
+func initNvidiaForProfileTest(t *testing.T) {
+	t.Helper()
+	old := device.DevicesMap
+	t.Cleanup(func() { device.DevicesMap = old })
+	device.DevicesMap = map[string]device.Devices{
+		nvidia.NvidiaGPUDevice: nvidia.InitNvidiaDevice(nvidia.NvidiaConfig{
+			ResourceCountName:            "nvidia.com/gpu",
+			ResourceMemoryName:           "nvidia.com/gpumem",
+			ResourceCoreName:             "nvidia.com/gpucores",
+			ResourceMemoryPercentageName: "nvidia.com/gpumem-percentage",
+			MemoryFactor:                 1,
+		}),
+	}
+}
+
+func gpuPod(limits corev1.ResourceList, annos map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "team-a", Annotations: annos},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "c",
+			Resources: corev1.ResourceRequirements{Limits: limits},
+		}}},
+	}
+}
+
+func TestParseNamespaceProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    *NamespaceProfile
+		wantErr string
+	}{
+		{
+			name: "full profile",
+			raw:  `{"defaultMemory":2000,"defaultCores":20,"maxMemoryPerContainer":8000,"allowedTypes":["A10"]}`,
+			want: &NamespaceProfile{DefaultMemory: 2000, DefaultCores: 20, MaxMemoryPerContainer: 8000, AllowedTypes: []string{"A10"}},
+		},
+		{name: "unknown field", raw: `{"defaultMem":2000}`, wantErr: "unknown field"},
+		{name: "cores out of range", raw: `{"defaultCores":120}`, wantErr: "defaultCores must be between 0 and 100"},
+		{name: "default above max", raw: `{"defaultMemory":9000,"maxMemoryPerContainer":8000}`, wantErr: "exceeds maxMemoryPerContainer"},
+		{name: "empty type", raw: `{"allowedTypes":[""]}`, wantErr: "empty entries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNamespaceProfile(tt.raw)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// applyNamespaceProfile applies profile to pod the way the webhook does,
+// leaving out the vendors' MutateAdmission in between.
+func applyNamespaceProfile(pod *corev1.Pod, profile *NamespaceProfile) error {
+	applyNamespaceDefaults(pod, profile)
+	return enforceNamespaceProfile(pod, profile)
+}
+
+func TestApplyNamespaceProfile(t *testing.T) {
+	initNvidiaForProfileTest(t)
+	profile := &NamespaceProfile{DefaultMemory: 2000, DefaultCores: 20, MaxMemoryPerContainer: 8000, AllowedTypes: []string{"A10", "L4"}}
+
+	t.Run("fills defaults and allowed types", func(t *testing.T) {
+		pod := gpuPod(corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}, nil)
+		assert.NoError(t, applyNamespaceProfile(pod, profile))
+		limits := pod.Spec.Containers[0].Resources.Limits
+		assert.Equal(t, int64(2000), limits.Name("nvidia.com/gpumem", resource.DecimalSI).Value())
+		assert.Equal(t, int64(20), limits.Name("nvidia.com/gpucores", resource.DecimalSI).Value())
+		assert.Equal(t, "A10,L4", pod.Annotations[nvidia.GPUInUse])
+	})
+
+	t.Run("keeps explicit values", func(t *testing.T) {
+		pod := gpuPod(corev1.ResourceList{
+			"nvidia.com/gpu":    resource.MustParse("1"),
+			"nvidia.com/gpumem": resource.MustParse("4000"),
+		}, map[string]string{nvidia.GPUInUse: "l4"})
+		assert.NoError(t, applyNamespaceProfile(pod, profile))
+		assert.Equal(t, int64(4000), pod.Spec.Containers[0].Resources.Limits.Name("nvidia.com/gpumem", resource.DecimalSI).Value())
+		assert.Equal(t, "l4", pod.Annotations[nvidia.GPUInUse])
+	})
+
+	t.Run("rejects memory above limit", func(t *testing.T) {
+		pod := gpuPod(corev1.ResourceList{
+			"nvidia.com/gpu":    resource.MustParse("2"),
+			"nvidia.com/gpumem": resource.MustParse("5000"),
+		}, nil)
+		assert.ErrorContains(t, applyNamespaceProfile(pod, profile), "exceeding the namespace limit of 8000")
+	})
+
+	t.Run("keeps memory percentage but rejects it under a memory limit", func(t *testing.T) {
+		pod := gpuPod(corev1.ResourceList{
+			"nvidia.com/gpu":               resource.MustParse("1"),
+			"nvidia.com/gpumem-percentage": resource.MustParse("50"),
+		}, nil)
+		assert.ErrorContains(t, applyNamespaceProfile(pod, profile), "requests 50% of each device's memory")
+		assert.NotContains(t, pod.Spec.Containers[0].Resources.Limits, corev1.ResourceName("nvidia.com/gpumem"))
+
+		pod = gpuPod(corev1.ResourceList{
+			"nvidia.com/gpu":               resource.MustParse("1"),
+			"nvidia.com/gpumem-percentage": resource.MustParse("50"),
+		}, nil)
+		assert.NoError(t, applyNamespaceProfile(pod, &NamespaceProfile{DefaultMemory: 2000}))
+	})
+
+	t.Run("rejects disallowed type", func(t *testing.T) {
+		pod := gpuPod(corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}, map[string]string{nvidia.GPUInUse: "H100"})
+		assert.ErrorContains(t, applyNamespaceProfile(pod, profile), `card type "H100" is not allowed`)
+	})
+
+	t.Run("accepts narrower type of an allowed one", func(t *testing.T) {
+		narrow := &NamespaceProfile{AllowedTypes: []string{"A100"}}
+		pod := gpuPod(corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}, map[string]string{nvidia.GPUInUse: "a100-sxm4-80gb"})
+		assert.NoError(t, applyNamespaceProfile(pod, narrow))
+
+		pod = gpuPod(corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}, map[string]string{nvidia.GPUInUse: "A10"})
+		assert.ErrorContains(t, applyNamespaceProfile(pod, narrow), `card type "A10" is not allowed`)
+	})
+
+	t.Run("ignores pods without devices", func(t *testing.T) {
+		pod := gpuPod(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}, nil)
+		assert.NoError(t, applyNamespaceProfile(pod, profile))
+		assert.Empty(t, pod.Annotations)
+		assert.Len(t, pod.Spec.Containers[0].Resources.Limits, 1)
+	})
+}
+
+// watchNamespaceProfilesForTest serves getNamespaceProfile from objs until
+// the test ends.
+func watchNamespaceProfilesForTest(t *testing.T, configMap string, objs ...runtime.Object) {
+	t.Helper()
+	oldCM, oldNamespaces, oldConfigMaps := config.NamespaceProfileConfigMap, namespaceLister, profileConfigMapLister
+	stopCh := make(chan struct{})
+	t.Cleanup(func() {
+		close(stopCh)
+		config.NamespaceProfileConfigMap, namespaceLister, profileConfigMapLister = oldCM, oldNamespaces, oldConfigMaps
+	})
+	config.NamespaceProfileConfigMap = configMap
+	watchNamespaceProfiles(fake.NewSimpleClientset(objs...), stopCh)
+}
+
+func TestGetNamespaceProfile(t *testing.T) {
+	watchNamespaceProfilesForTest(t, "hami-system/profiles",
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "annotated",
+			Annotations: map[string]string{NamespaceProfileAnnotation: `{"defaultMemory":1000}`},
+		}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "plain"}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "profiles", Namespace: "hami-system"},
+			Data: map[string]string{
+				"annotated": `{"defaultMemory":3000}`,
+				"plain":     `{"defaultMemory":3000}`,
+			},
+		},
+	)
+
+	profile, err := getNamespaceProfile("annotated")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), profile.DefaultMemory, "annotation takes precedence")
+
+	profile, err = getNamespaceProfile("plain")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3000), profile.DefaultMemory)
+
+	profile, err = getNamespaceProfile("other")
+	assert.NoError(t, err)
+	assert.Nil(t, profile)
+}
+
+func TestHandleAppliesNamespaceProfile(t *testing.T) {
+	initNvidiaForProfileTest(t)
+	watchNamespaceProfilesForTest(t, "", &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team-a",
+		Annotations: map[string]string{NamespaceProfileAnnotation: `{"defaultMemory":2000,"defaultCores":20,"maxMemoryPerContainer":8000}`},
+	}})
+	wh, err := NewWebHook()
+	assert.NoError(t, err)
+	handle := func(pod *corev1.Pod) admission.Response {
+		raw, err := json.Marshal(pod)
+		assert.NoError(t, err)
+		return wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID: "uid", Namespace: pod.Namespace, Name: pod.Name, Object: runtime.RawExtension{Raw: raw},
+		}})
+	}
+
+	// A bare device count gets the profile's cores rather than being made
+	// exclusive by the vendor default.
+	resp := handle(gpuPod(corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}, nil))
+	assert.True(t, resp.Allowed, resp.Result)
+	limits := make(map[string]any)
+	for _, patch := range resp.Patches {
+		if name, ok := strings.CutPrefix(patch.Path, "/spec/containers/0/resources/limits/"); ok {
+			limits[strings.ReplaceAll(name, "~1", "/")] = patch.Value
+		}
+	}
+	assert.Equal(t, map[string]any{"nvidia.com/gpumem": "2k", "nvidia.com/gpucores": "20"}, limits)
+
+	resp = handle(gpuPod(corev1.ResourceList{
+		"nvidia.com/gpu":               resource.MustParse("1"),
+		"nvidia.com/gpumem-percentage": resource.MustParse("50"),
+	}, nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "cannot be checked against the namespace limit of 8000")
+}
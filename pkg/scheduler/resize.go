@@ -0,0 +1,260 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+)
+
+// enqueueResize queues pod for handleResize if it carries a resize request
+// the scheduler has not answered yet. The work is done off the informer
+// goroutine since it patches the pod and walks the node usage.
+func (s *Scheduler) enqueueResize(pod *corev1.Pod) {
+	request, ok := pod.Annotations[nvidia.ResizeAnnotation]
+	if !ok {
+		return
+	}
+	if status, ok := nvidia.DecodeResizeStatus(pod.Annotations); ok && status.Request == request && status.Phase != nvidia.ResizeVerified {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(pod)
+	if err != nil {
+		klog.ErrorS(err, "Failed to queue vGPU resize", "pod", klog.KObj(pod))
+		return
+	}
+	s.resizeQueue.Add(key)
+}
+
+// runResizeWorker handles queued resizes until the scheduler stops.
+func (s *Scheduler) runResizeWorker() {
+	go func() {
+		<-s.stopCh
+		s.resizeQueue.ShutDown()
+	}()
+	for s.processNextResize() {
+	}
+}
+
+func (s *Scheduler) processNextResize() bool {
+	key, quit := s.resizeQueue.Get()
+	if quit {
+		return false
+	}
+	defer s.resizeQueue.Done(key)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		s.resizeQueue.Forget(key)
+		return true
+	}
+	pod, err := s.podLister.Pods(namespace).Get(name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to get pod for vGPU resize", "pod", key)
+			s.resizeQueue.AddRateLimited(key)
+		} else {
+			s.resizeQueue.Forget(key)
+		}
+		return true
+	}
+	device.RLockConfig()
+	err = s.handleResize(pod)
+	device.RUnlockConfig()
+	if err != nil {
+		klog.ErrorS(err, "Failed to record vGPU resize, retrying", "pod", key)
+		s.resizeQueue.AddRateLimited(key)
+		return true
+	}
+	s.resizeQueue.Forget(key)
+	return true
+}
+
+// handleResize applies a pending nvidia.ResizeAnnotation on a running pod.
+// The new limits are checked against the free memory and cores of the
+// devices the pod already holds and against the namespace quota. A request
+// that lowers a container's memory is first marked nvidia.ResizePending and
+// only applied once vGPUmonitor verified the container uses less than the new
+// limit. On success the allocation annotation is rewritten, which vGPUmonitor
+// picks up to update the container's shared region, and the pod's cached usage
+// is replaced. Either way the outcome is recorded in
+// nvidia.ResizeStatusAnnotation; the returned error is a failure to record it.
+func (s *Scheduler) handleResize(pod *corev1.Pod) error {
+	request, ok := pod.Annotations[nvidia.ResizeAnnotation]
+	if !ok {
+		return nil
+	}
+	status, hasStatus := nvidia.DecodeResizeStatus(pod.Annotations)
+	verified := hasStatus && status.Request == request && status.Phase == nvidia.ResizeVerified
+	if hasStatus && status.Request == request && !verified {
+		return nil
+	}
+	if !s.leaderManager.IsLeader() {
+		return nil
+	}
+	// Until the init containers finish, the cached usage still holds their
+	// peak and a resize would be accounted against the wrong footprint.
+	if len(pod.Spec.InitContainers) > 0 && !util.AllInitContainersSucceeded(pod) {
+		klog.V(4).InfoS("Deferring vGPU resize until init containers complete", "pod", klog.KObj(pod))
+		return nil
+	}
+
+	status = nvidia.ResizeStatus{Request: request, Phase: nvidia.ResizeApplied}
+	annos, newDevices, shrinks, err := s.resizePodDevices(pod, request)
+	switch {
+	case err != nil:
+		status.Phase = nvidia.ResizeRejected
+		status.Message = err.Error()
+		annos = map[string]string{}
+	case shrinks && !verified:
+		status.Phase = nvidia.ResizePending
+		annos = map[string]string{}
+	}
+	annos[nvidia.ResizeStatusAnnotation] = nvidia.EncodeResizeStatus(status)
+	if perr := util.PatchPodAnnotations(pod, annos); perr != nil {
+		return perr
+	}
+	switch status.Phase {
+	case nvidia.ResizeRejected:
+		klog.InfoS("Rejected vGPU resize", "pod", klog.KObj(pod), "reason", err)
+		s.recordScheduleFilterResultEvent(pod, EventReasonResizeFailed, "", err)
+		return nil
+	case nvidia.ResizePending:
+		klog.V(4).InfoS("Waiting for vGPUmonitor to check the memory used before shrinking", "pod", klog.KObj(pod))
+		return nil
+	}
+	if oldDevices, ok := s.podManager.UpdatePodDevice(pod, newDevices); ok {
+		s.quotaManager.ReplaceUsage(pod, oldDevices, newDevices)
+		klog.InfoS("Resized vGPU limits", "pod", klog.KObj(pod), "oldUsage", oldDevices, "newUsage", newDevices)
+	}
+	s.recordScheduleFilterResultEvent(pod, EventReasonResized, fmt.Sprintf("Resized vGPU limits: %s", request), nil)
+	return nil
+}
+
+// resizePodDevices validates request against the pod's node and namespace and
+// returns the annotations to patch, the pod's new effective usage and whether
+// the request lowers the memory of any container.
+func (s *Scheduler) resizePodDevices(pod *corev1.Pod, request string) (map[string]string, device.PodDevices, bool, error) {
+	req, err := nvidia.ParseResizeRequest(request)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	pi, ok := s.podManager.GetPod(pod)
+	if !ok {
+		return nil, nil, false, fmt.Errorf("pod has no device allocation")
+	}
+	rawDevices, err := device.DecodePodDevices(device.SupportDevices, pod.Annotations)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to decode pod devices: %w", err)
+	}
+	resized, err := nvidia.ResizeContainerDevices(pod, rawDevices[nvidia.NvidiaGPUDevice], req)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	shrinks := len(nvidia.ShrunkContainers(pod, rawDevices[nvidia.NvidiaGPUDevice], req)) > 0
+	newRaw := maps.Clone(rawDevices)
+	newRaw[nvidia.NvidiaGPUDevice] = resized
+	var newDevices device.PodDevices
+	if len(pod.Spec.InitContainers) > 0 {
+		newDevices = device.AppContainersOnlyDeviceUsage(pod, newRaw)
+	} else {
+		newDevices = device.CollapseInitContainerUsage(pod, newRaw)
+	}
+	if err := s.fitResize(pod, pi, newDevices); err != nil {
+		return nil, nil, false, err
+	}
+	annos := map[string]string{
+		device.SupportDevices[nvidia.NvidiaGPUDevice]: device.EncodePodSingleDevice(resized),
+	}
+	return annos, newDevices, shrinks, nil
+}
+
+type resizeDelta struct {
+	mem   int32
+	cores int32
+}
+
+// fitResize checks that the change from the pod's cached usage to newDevices
+// fits the free capacity of each device and the namespace quota. Shrinking
+// always fits.
+func (s *Scheduler) fitResize(pod *corev1.Pod, pi *device.PodInfo, newDevices device.PodDevices) error {
+	deltas := map[string]resizeDelta{}
+	for _, ctrDevs := range pi.Devices[nvidia.NvidiaGPUDevice] {
+		for _, dev := range ctrDevs {
+			d := deltas[dev.UUID]
+			d.mem -= dev.Usedmem
+			d.cores -= dev.Usedcores
+			deltas[dev.UUID] = d
+		}
+	}
+	for _, ctrDevs := range newDevices[nvidia.NvidiaGPUDevice] {
+		for _, dev := range ctrDevs {
+			d := deltas[dev.UUID]
+			d.mem += dev.Usedmem
+			d.cores += dev.Usedcores
+			deltas[dev.UUID] = d
+		}
+	}
+
+	_, overall, _, err := s.getNodesUsage(nil, pod)
+	if err != nil {
+		return fmt.Errorf("failed to get node usage: %w", err)
+	}
+	node, ok := (*overall)[pi.NodeID]
+	if !ok {
+		return fmt.Errorf("node %s is not registered", pi.NodeID)
+	}
+	var memTotal, coresTotal int64
+	for uuid, d := range deltas {
+		memTotal += int64(d.mem)
+		coresTotal += int64(d.cores)
+		var usage *device.DeviceUsage
+		for _, dl := range node.Devices.DeviceLists {
+			if dl.Device.ID == uuid {
+				usage = dl.Device
+				break
+			}
+		}
+		if usage == nil {
+			return fmt.Errorf("device %s is not registered on node %s", uuid, pi.NodeID)
+		}
+		if usage.Mode == nvidia.MigMode || usage.Mode == nvidia.MpsMode {
+			return fmt.Errorf("device %s runs in %s mode, only %s limits can be resized", uuid, usage.Mode, nvidia.HamiCoreMode)
+		}
+		if d.mem > 0 && usage.Usedmem+d.mem > usage.Totalmem {
+			return fmt.Errorf("device %s has %d MiB free, resize needs %d MiB more", uuid, usage.Totalmem-usage.Usedmem, d.mem)
+		}
+		if d.cores > 0 && usage.Usedcores+d.cores > usage.Totalcore {
+			return fmt.Errorf("device %s has %d cores free, resize needs %d more", uuid, usage.Totalcore-usage.Usedcores, d.cores)
+		}
+	}
+	if memTotal > 0 || coresTotal > 0 {
+		if !s.quotaManager.FitQuota(pod.Namespace, max(memTotal, 0), nvidia.MemoryFactor, max(coresTotal, 0), nvidia.NvidiaGPUDevice) {
+			return fmt.Errorf("resize exceeds the resource quota of namespace %s", pod.Namespace)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+)
+
+func newResizeTestPod(name string, usedmem int32) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:         k8stypes.UID("uid-" + name),
+			Name:        name,
+			Namespace:   "default",
+			Annotations: map[string]string{util.AssignedNodeAnnotations: "node1"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:   "node1",
+			Containers: []corev1.Container{{Name: "app", Image: "app"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	maps.Copy(pod.Annotations, device.EncodePodDevices(device.SupportDevices, device.PodDevices{
+		nvidia.NvidiaGPUDevice: device.PodSingleDevice{{{UUID: "GPU0", Usedmem: usedmem, Usedcores: 20}}},
+	}))
+	return pod
+}
+
+func resizeStatus(t *testing.T, pod *corev1.Pod) nvidia.ResizeStatus {
+	t.Helper()
+	got, err := client.KubeClient.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	status, ok := nvidia.DecodeResizeStatus(got.Annotations)
+	require.True(t, ok, "resize status not recorded")
+	return status
+}
+
+func Test_handleResize(t *testing.T) {
+	sConfig := &config.Config{
+		NvidiaConfig: nvidia.NvidiaConfig{
+			ResourceCountName:  "hami.io/gpu",
+			ResourceMemoryName: "hami.io/gpumem",
+			ResourceCoreName:   "hami.io/gpucores",
+			DefaultGPUNum:      1,
+		},
+	}
+	require.NoError(t, config.InitDevicesWithConfig(sConfig))
+
+	s := NewScheduler()
+	s.addNode("node1", &device.NodeInfo{
+		ID:   "node1",
+		Node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		Devices: map[string][]device.DeviceInfo{
+			nvidia.NvidiaGPUDevice: {{ID: "GPU0", Count: 10, Devmem: 16000, Devcore: 100, Mode: nvidia.HamiCoreMode, Health: true}},
+		},
+	})
+	pod := newResizeTestPod("resized", 4000)
+	neighbour := newResizeTestPod("neighbour", 8000)
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(pod, neighbour)
+	defer func() { client.KubeClient = previousKubeClient }()
+	s.onAddPod(pod)
+	s.onAddPod(neighbour)
+	// The quota manager is process-wide; drop this test's usage afterwards.
+	t.Cleanup(func() {
+		s.onDelPod(pod)
+		s.onDelPod(neighbour)
+	})
+
+	// Grow within the 4000 MiB the device has left.
+	updated := pod.DeepCopy()
+	updated.Annotations[nvidia.ResizeAnnotation] = `{"app":{"memory":6000,"cores":30}}`
+	s.onUpdatePod(pod, updated)
+	require.Equal(t, 1, s.resizeQueue.Len(), "the update handler only queues the resize")
+	require.NoError(t, s.handleResize(updated))
+
+	status := resizeStatus(t, pod)
+	assert.Equal(t, nvidia.ResizeApplied, status.Phase, status.Message)
+	got, err := client.KubeClient.CoreV1().Pods("default").Get(context.Background(), pod.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	allocated, err := device.DecodePodDevices(device.SupportDevices, got.Annotations)
+	require.NoError(t, err)
+	assert.Equal(t, int32(6000), allocated[nvidia.NvidiaGPUDevice][0][0].Usedmem)
+	assert.Equal(t, int32(30), allocated[nvidia.NvidiaGPUDevice][0][0].Usedcores)
+	pi, ok := s.podManager.GetPod(pod)
+	require.True(t, ok)
+	assert.Equal(t, int32(6000), pi.Devices[nvidia.NvidiaGPUDevice][0][0].Usedmem)
+	memQuota := (*s.quotaManager.GetResourceQuota()["default"])["hami.io/gpumem"]
+	require.NotNil(t, memQuota)
+	assert.Equal(t, int64(14000), memQuota.Used)
+
+	// Only 2000 MiB are left now; growing by 3000 must be rejected and
+	// leave the accounting alone.
+	next := got.DeepCopy()
+	next.Annotations[nvidia.ResizeAnnotation] = `{"app":{"memory":9000}}`
+	require.NoError(t, s.handleResize(next))
+
+	status = resizeStatus(t, pod)
+	assert.Equal(t, nvidia.ResizeRejected, status.Phase)
+	assert.Equal(t, "device GPU0 has 2000 MiB free, resize needs 3000 MiB more", status.Message)
+	pi, ok = s.podManager.GetPod(pod)
+	require.True(t, ok)
+	assert.Equal(t, int32(6000), pi.Devices[nvidia.NvidiaGPUDevice][0][0].Usedmem)
+
+	// Shrinking always fits, but waits for vGPUmonitor to check the memory
+	// the container uses first.
+	next.Annotations[nvidia.ResizeAnnotation] = `{"app":{"memory":1000}}`
+	require.NoError(t, s.handleResize(next))
+	status = resizeStatus(t, pod)
+	assert.Equal(t, nvidia.ResizePending, status.Phase)
+	pi, _ = s.podManager.GetPod(pod)
+	assert.Equal(t, int32(6000), pi.Devices[nvidia.NvidiaGPUDevice][0][0].Usedmem)
+
+	next.Annotations[nvidia.ResizeStatusAnnotation] = nvidia.EncodeResizeStatus(status)
+	require.NoError(t, s.handleResize(next))
+	assert.Equal(t, nvidia.ResizePending, resizeStatus(t, pod).Phase, "a pending resize is not applied again")
+
+	status.Phase = nvidia.ResizeVerified
+	next.Annotations[nvidia.ResizeStatusAnnotation] = nvidia.EncodeResizeStatus(status)
+	require.NoError(t, s.handleResize(next))
+	assert.Equal(t, nvidia.ResizeApplied, resizeStatus(t, pod).Phase)
+	pi, _ = s.podManager.GetPod(pod)
+	assert.Equal(t, int32(1000), pi.Devices[nvidia.NvidiaGPUDevice][0][0].Usedmem)
+}
+
+func Test_handleResizeRejectsUnknownContainer(t *testing.T) {
+	sConfig := &config.Config{NvidiaConfig: nvidia.NvidiaConfig{ResourceCountName: "hami.io/gpu", DefaultGPUNum: 1}}
+	require.NoError(t, config.InitDevicesWithConfig(sConfig))
+
+	s := NewScheduler()
+	pod := newResizeTestPod("unknown-container", 4000)
+	pod.Annotations[nvidia.ResizeAnnotation] = `{"sidecar":{"cores":10}}`
+	previousKubeClient := client.KubeClient
+	client.KubeClient = fake.NewSimpleClientset(pod)
+	defer func() { client.KubeClient = previousKubeClient }()
+	s.onAddPod(pod)
+	t.Cleanup(func() { s.onDelPod(pod) })
+
+	require.NoError(t, s.handleResize(pod))
+	status := resizeStatus(t, pod)
+	assert.Equal(t, nvidia.ResizeRejected, status.Phase)
+	assert.Equal(t, "container sidecar not found", status.Message)
+}
+
+func Test_processNextResize(t *testing.T) {
+	s := NewScheduler()
+	pod := newResizeTestPod("queued", 4000)
+	pod.Annotations[nvidia.ResizeAnnotation] = `{"app":{"cores":10}}`
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(fake.NewClientset(), 0)
+	s.podLister = informerFactory.Core().V1().Pods().Lister()
+
+	// Answered requests are not queued.
+	answered := pod.DeepCopy()
+	answered.Annotations[nvidia.ResizeStatusAnnotation] = nvidia.EncodeResizeStatus(nvidia.ResizeStatus{Request: `{"app":{"cores":10}}`, Phase: nvidia.ResizeApplied})
+	s.enqueueResize(answered)
+	assert.Equal(t, 0, s.resizeQueue.Len())
+
+	// A pod deleted before the worker gets to it is dropped.
+	s.enqueueResize(pod)
+	require.Equal(t, 1, s.resizeQueue.Len())
+	assert.True(t, s.processNextResize())
+	assert.Equal(t, 0, s.resizeQueue.Len())
+	assert.Equal(t, 0, s.resizeQueue.NumRequeues("default/queued"))
+
+	s.resizeQueue.ShutDown()
+	assert.False(t, s.processNextResize())
+}
@@ -22,12 +22,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"k8s.io/klog/v2"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
 
 	"github.com/Project-HAMi/HAMi/pkg/scheduler"
+	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
 )
 
 const maxRequestSize = 1024 * 1024 // 1MB limit
@@ -165,6 +167,17 @@ func WebHookRoute() httprouter.Handle {
 	}
 }
 
+func NodeWebHookRoute() httprouter.Handle {
+	h, err := scheduler.NewNodeWebHook()
+	if err != nil {
+		klog.ErrorS(err, "Failed to create new node webhook")
+	}
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		klog.V(5).Infof("Handling node webhook request on %s", r.URL.Path)
+		h.ServeHTTP(w, r)
+	}
+}
+
 func HealthzRoute() httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		klog.V(5).Infoln("Health check endpoint hit")
@@ -184,3 +197,64 @@ func ReadyzRoute(s *scheduler.Scheduler) httprouter.Handle {
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// ChargebackRoute serves the GPU-hours allocated over a time range, grouped by
+// namespace or pod label. The from and to query parameters are RFC 3339 times
+// and default to the last 24 hours; format is json (default) or csv. Pods
+// that ended are only known from this replica's memory, so the report misses
+// those that ended before it started, such as after a restart or a failover
+// to a replica started later.
+func ChargebackRoute(s *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		query := r.URL.Query()
+		now := time.Now()
+		opts := scheduler.ChargebackOptions{From: now.Add(-24 * time.Hour), To: now, GroupBy: "namespace"}
+		for name, t := range map[string]*time.Time{"from": &opts.From, "to": &opts.To} {
+			if v := query.Get(name); v != "" {
+				parsed, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid %s: %v", name, err), http.StatusBadRequest)
+					return
+				}
+				*t = parsed
+			}
+		}
+		if v := query.Get("groupBy"); v != "" {
+			opts.GroupBy = v
+		}
+		format := query.Get("format")
+		if format != "" && format != "json" && format != "csv" {
+			http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+			return
+		}
+		// The price table is read on every request so price changes need no restart.
+		if config.ChargebackPriceTable != "" {
+			prices, err := scheduler.LoadPriceTable(config.ChargebackPriceTable)
+			if err != nil {
+				klog.ErrorS(err, "Failed to load chargeback price table")
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			opts.Prices = prices
+		}
+
+		report, err := s.ChargebackReport(opts, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			if err := report.WriteCSV(w); err != nil {
+				klog.ErrorS(err, "Failed to write chargeback report")
+			}
+			return
+		}
+		body, err := json.Marshal(report)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeResponse(w, http.StatusOK, body)
+	}
+}
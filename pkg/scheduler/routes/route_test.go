@@ -343,3 +343,36 @@ func TestBind_WriteError(t *testing.T) {
 		t.Errorf("Expected 'Failed to write response' in log output, but got: %s", buf.String())
 	}
 }
+
+func TestChargebackRouteRejectsInvalidQuery(t *testing.T) {
+	handler := ChargebackRoute(scheduler.NewScheduler())
+	for _, query := range []string{
+		"from=yesterday",
+		"to=2026-01-01",
+		"format=xml",
+		"groupBy=node",
+		"from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z",
+	} {
+		req := httptest.NewRequest("GET", "/chargeback?"+query, nil)
+		w := httptest.NewRecorder()
+		handler(w, req, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestChargebackRouteBadPriceTable(t *testing.T) {
+	origPriceTable := config.ChargebackPriceTable
+	config.ChargebackPriceTable = "/nonexistent/prices.yaml"
+	t.Cleanup(func() {
+		config.ChargebackPriceTable = origPriceTable
+	})
+
+	req := httptest.NewRequest("GET", "/chargeback", nil)
+	w := httptest.NewRecorder()
+	ChargebackRoute(scheduler.NewScheduler())(w, req, nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 for a missing price table, got %d", w.Code)
+	}
+}
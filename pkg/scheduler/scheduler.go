@@ -29,6 +29,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -39,6 +40,7 @@ import (
 	listerscorev1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
 
@@ -50,6 +52,7 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 	"github.com/Project-HAMi/HAMi/pkg/util/leaderelection"
 	nodelockutil "github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 const (
@@ -76,6 +79,10 @@ type Scheduler struct {
 	overviewstatus map[string]*NodeUsage
 	eventRecorder  record.EventRecorder
 	started        uint32 // 0 = false, 1 = true
+	// chargeback keeps the allocations of ended pods for chargeback reports.
+	chargeback chargebackLedger
+	// resizeQueue holds the keys of pods with a vGPU resize to handle.
+	resizeQueue workqueue.TypedRateLimitingInterface[string]
 
 	lock   sync.RWMutex
 	synced bool
@@ -90,6 +97,8 @@ func NewScheduler() *Scheduler {
 		leaderNotify:   make(chan struct{}, 1),
 		started:        0,
 		synced:         false,
+		resizeQueue: workqueue.NewTypedRateLimitingQueueWithConfig(workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "vgpu-resize"}),
 	}
 	s.nodeManager = newNodeManager()
 	s.podManager = device.NewPodManager()
@@ -135,6 +144,13 @@ func (s *Scheduler) doNodeNotify() {
 }
 
 func (s *Scheduler) onAddPod(obj any) {
+	device.RLockConfig()
+	defer device.RUnlockConfig()
+	s.addPod(obj)
+}
+
+// addPod is onAddPod for callers already holding the device config lock.
+func (s *Scheduler) addPod(obj any) {
 	pod, ok := obj.(*corev1.Pod)
 	if !ok {
 		klog.ErrorS(fmt.Errorf("invalid pod object"), "Failed to process pod addition")
@@ -146,6 +162,10 @@ func (s *Scheduler) onAddPod(obj any) {
 		return
 	}
 	if util.IsPodInTerminatedState(pod) {
+		s.recordChargeback(pod, time.Now())
+	}
+	// Pods whose device allocation failed never get their devices.
+	if util.IsPodInTerminatedState(pod) || pod.Annotations[util.DeviceBindPhase] == util.DeviceBindFailed {
 		if pi, ok := s.podManager.TakeAndDeletePod(pod); ok {
 			s.quotaManager.RmUsage(pod, pi.Devices)
 		}
@@ -168,9 +188,13 @@ func (s *Scheduler) onAddPod(obj any) {
 	if s.podManager.AddPod(pod, nodeID, effectiveDevices) {
 		s.quotaManager.AddUsage(pod, effectiveDevices)
 	}
+	// Picks up requests left unanswered across a scheduler restart.
+	s.enqueueResize(pod)
 }
 
 func (s *Scheduler) onUpdatePod(oldObj, newObj any) {
+	device.RLockConfig()
+	defer device.RUnlockConfig()
 	newPod, ok := newObj.(*corev1.Pod)
 	if !ok {
 		return
@@ -183,6 +207,9 @@ func (s *Scheduler) onUpdatePod(oldObj, newObj any) {
 	}
 
 	if util.IsPodInTerminatedState(newPod) {
+		s.recordChargeback(newPod, time.Now())
+	}
+	if util.IsPodInTerminatedState(newPod) || newPod.Annotations[util.DeviceBindPhase] == util.DeviceBindFailed {
 		if pi, ok := s.podManager.TakeAndDeletePod(newPod); ok {
 			s.quotaManager.RmUsage(newPod, pi.Devices)
 		}
@@ -196,7 +223,7 @@ func (s *Scheduler) onUpdatePod(oldObj, newObj any) {
 
 	pi, exists := s.podManager.GetPod(newPod)
 	if !exists {
-		s.onAddPod(newPod)
+		s.addPod(newPod)
 		return
 	}
 
@@ -221,9 +248,13 @@ func (s *Scheduler) onUpdatePod(oldObj, newObj any) {
 			)
 		}
 	}
+
+	s.enqueueResize(newPod)
 }
 
 func (s *Scheduler) onDelPod(obj any) {
+	device.RLockConfig()
+	defer device.RUnlockConfig()
 	var pod *corev1.Pod
 	var ok bool
 
@@ -247,6 +278,7 @@ func (s *Scheduler) onDelPod(obj any) {
 	if !ok {
 		return
 	}
+	s.recordChargeback(pod, time.Now())
 	if pi, ok := s.podManager.TakeAndDeletePod(pod); ok {
 		s.quotaManager.RmUsage(pod, pi.Devices)
 	}
@@ -357,6 +389,7 @@ func (s *Scheduler) Start() error {
 	s.kubeClient = client.GetClient()
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(s.kubeClient, defaultResync)
 	s.podLister = informerFactory.Core().V1().Pods().Lister()
+	nodelockutil.PodLister = s.podLister
 	s.nodeLister = informerFactory.Core().V1().Nodes().Lister()
 	s.quotaLister = informerFactory.Core().V1().ResourceQuotas().Lister()
 
@@ -401,7 +434,10 @@ func (s *Scheduler) Start() error {
 		cache.WaitForCacheSync(s.stopCh, leaseEventHandlerRegistration.HasSynced)
 	}
 
+	watchNamespaceProfiles(s.kubeClient, s.stopCh)
+
 	s.addAllEventHandlers()
+	go s.runResizeWorker()
 	atomic.StoreUint32(&s.started, 1)
 	return nil
 }
@@ -410,6 +446,13 @@ func (s *Scheduler) Stop() {
 	close(s.stopCh)
 }
 
+// WatchDeviceConfig hot-reloads the device config through r until the
+// scheduler stops, reporting every attempt as an event.
+func (s *Scheduler) WatchDeviceConfig(r *config.Reloader) error {
+	r.OnReload = s.recordConfigReloadEvent
+	return r.Watch(s.stopCh)
+}
+
 func (s *Scheduler) RegisterFromNodeAnnotations() {
 	klog.InfoS("Entering RegisterFromNodeAnnotations")
 	defer klog.InfoS("Exiting RegisterFromNodeAnnotations")
@@ -444,6 +487,9 @@ func (s *Scheduler) register(labelSelector labels.Selector, printedLog map[strin
 	// Lock here to avoid setting s.synced to false, when we lost leadership, while doing register.
 	// 1. lost leadership before register: synced will set to false in callbacks, and register will be skipped because IsLeader() returns false
 	// 2. lost leadership during or after register: synced will set to true after finishing register, and callback will set it to false again after lock is acquired by callback
+	// The device config lock is taken first, the same order as the pod handlers.
+	device.RLockConfig()
+	defer device.RUnlockConfig()
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
@@ -677,6 +723,7 @@ func buildNodeUsage(node *device.NodeInfo, task *corev1.Pod) *NodeUsage {
 					Usedcores:   0,
 					MigProfiles: d.MIGProfiles,
 					Mode:        d.Mode,
+					Modes:       d.Modes,
 					Type:        d.Type,
 					Numa:        d.Numa,
 					Health:      d.Health,
@@ -736,6 +783,9 @@ func migAllocationUsage(allocation nvidia.MigAllocation) device.MigAllocation {
 	if allocation.ComputeInstanceID != nil {
 		usage.ComputeInstanceID = *allocation.ComputeInstanceID
 	}
+	if allocation.ComputePlacement != nil {
+		usage.ComputePlacement = *allocation.ComputePlacement
+	}
 	return usage
 }
 
@@ -764,6 +814,10 @@ func (s *Scheduler) getNodesUsage(nodes *[]string, task *corev1.Pod) (*map[strin
 				}
 			}
 		}
+		deviceModes, err := nvidia.DecodeDeviceModes(p.Annotations[nvidia.DeviceModesAnnotation])
+		if err != nil {
+			klog.ErrorS(err, "Failed to decode pod device modes", "pod", klog.KRef(p.Namespace, p.Name))
+		}
 		node, ok := overallnodeMap[p.NodeID]
 		if !ok {
 			klog.V(5).InfoS("pod allocated unknown node resources",
@@ -784,6 +838,11 @@ func (s *Scheduler) getNodesUsage(nodes *[]string, task *corev1.Pod) (*map[strin
 							d.Device.Usedmem += udevice.Usedmem
 							d.Device.Usedcores += udevice.Usedcores
 							d.Device.PodInfos = append(d.Device.PodInfos, p)
+							// The device runs in the mode its pods were placed
+							// in, even before the plugin re-registers it.
+							if mode, ok := deviceModes[udevice.UUID]; ok {
+								d.Device.Mode = mode
+							}
 
 							if allocations := allocationsByGPU[udevice.UUID]; len(allocations) > 0 {
 								if strings.Compare(d.Device.Mode, "hami-core") == 0 {
@@ -925,6 +984,8 @@ func (s *Scheduler) getPodUsage() (map[string]device.PodUseDeviceStat, error) {
 }
 
 func (s *Scheduler) cleanupStalePodAllocation(pod *corev1.Pod) {
+	device.RLockConfig()
+	defer device.RUnlockConfig()
 	if pi, ok := s.podManager.TakeAndDeletePod(pod); ok && len(pi.Devices) > 0 {
 		s.quotaManager.RmUsage(pod, pi.Devices)
 	}
@@ -941,9 +1002,9 @@ func (s *Scheduler) lockAllDevices(node *corev1.Node, pod *corev1.Pod) error {
 	acquired := make([]device.Devices, 0, len(keys))
 	for _, k := range keys {
 		val := devs[k]
-		if err := val.LockNode(node, pod); err != nil {
+		if err := lockNode(val, node, pod); err != nil {
 			for _, locked := range slices.Backward(acquired) {
-				if relErr := locked.ReleaseNodeLock(node, pod); relErr != nil {
+				if relErr := releaseNodeLock(locked, node, pod); relErr != nil {
 					klog.ErrorS(relErr, "Failed to release node lock during rollback", "node", node.Name, "pod", klog.KObj(pod))
 				}
 			}
@@ -954,6 +1015,21 @@ func (s *Scheduler) lockAllDevices(node *corev1.Node, pod *corev1.Pod) error {
 	return nil
 }
 
+// lockNode and releaseNodeLock hold the device config only for a single
+// lock call of dev, which reads the settings its Init function owns, so the
+// lock is free while acquireNodeLocks waits between retries.
+func lockNode(dev device.Devices, node *corev1.Node, pod *corev1.Pod) error {
+	device.RLockConfig()
+	defer device.RUnlockConfig()
+	return dev.LockNode(node, pod)
+}
+
+func releaseNodeLock(dev device.Devices, node *corev1.Node, pod *corev1.Pod) error {
+	device.RLockConfig()
+	defer device.RUnlockConfig()
+	return dev.ReleaseNodeLock(node, pod)
+}
+
 func (s *Scheduler) releaseAllDevices(node *corev1.Node, pod *corev1.Pod) {
 	devs := device.GetDevices()
 	keys := make([]string, 0, len(devs))
@@ -963,7 +1039,7 @@ func (s *Scheduler) releaseAllDevices(node *corev1.Node, pod *corev1.Pod) {
 	sort.Strings(keys)
 	for _, k := range keys {
 		val := devs[k]
-		if err := val.ReleaseNodeLock(node, pod); err != nil {
+		if err := releaseNodeLock(val, node, pod); err != nil {
 			klog.ErrorS(err, "Failed to release node lock", "node", node.Name, "pod", klog.KObj(pod))
 		}
 	}
@@ -998,6 +1074,15 @@ func (s *Scheduler) acquireNodeLocks(node *corev1.Node, pod *corev1.Pod) error {
 func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.ExtenderBindingResult, error) {
 	klog.InfoS("Attempting to bind pod to node", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
 	var res *extenderv1.ExtenderBindingResult
+	start := time.Now()
+	var bindErr error
+	defer func() {
+		result := resultBound
+		if bindErr != nil {
+			result = resultError
+		}
+		BindDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	}()
 
 	binding := &corev1.Binding{
 		ObjectMeta: metav1.ObjectMeta{Name: args.PodName, UID: args.PodUID},
@@ -1007,6 +1092,7 @@ func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.Exten
 	current, err := s.podLister.Pods(args.PodNamespace).Get(args.PodName)
 	if err != nil {
 		klog.ErrorS(err, "Failed to get pod from cache", "pod", args.PodName, "namespace", args.PodNamespace)
+		bindErr = err
 		s.cleanupStalePodAllocation(&corev1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
 				UID:       args.PodUID,
@@ -1017,11 +1103,16 @@ func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.Exten
 		return &extenderv1.ExtenderBindingResult{Error: err.Error()}, err
 	}
 
+	ctx, span := tracing.StartPodSpan(context.Background(), current, "Scheduler.Bind")
+	span.SetAttributes(attribute.String("k8s.node.name", args.Node))
+	defer func() { tracing.End(span, bindErr) }()
+
 	klog.InfoS("Trying to get the target node for pod", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
 
 	node, err := s.nodeLister.Get(args.Node)
 	if err != nil {
 		klog.ErrorS(err, "Failed to get node from cache", "node", args.Node)
+		bindErr = err
 		s.recordScheduleBindingResultEvent(current, EventReasonBindingFailed, []string{}, fmt.Errorf("failed to get node %s", args.Node))
 		s.cleanupStalePodAllocation(current)
 		res = &extenderv1.ExtenderBindingResult{Error: err.Error()}
@@ -1034,6 +1125,7 @@ func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.Exten
 	}
 
 	fail := func(e error) (*extenderv1.ExtenderBindingResult, error) {
+		bindErr = e
 		klog.InfoS("Release node locks", "node", args.Node)
 		s.releaseAllDevices(node, current)
 		s.recordScheduleBindingResultEvent(current, EventReasonBindingFailed, []string{}, e)
@@ -1044,7 +1136,17 @@ func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.Exten
 		return &extenderv1.ExtenderBindingResult{Error: errStr}, nil
 	}
 
-	if err = s.acquireNodeLocks(node, current); err != nil {
+	lockStart := time.Now()
+	err = s.acquireNodeLocks(node, current)
+	lockResult := "acquired"
+	if err != nil {
+		lockResult = "failed"
+	}
+	NodeLockWaitDuration.WithLabelValues(lockResult).Observe(time.Since(lockStart).Seconds())
+	if err != nil {
+		if nodelockutil.IsNodeLockContention(err) {
+			NodeLockContentionsTotal.Inc()
+		}
 		klog.ErrorS(err, "Failed to lock node", "node", args.Node, "pod", klog.KObj(current))
 		return fail(err)
 	}
@@ -1054,7 +1156,7 @@ func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.Exten
 		return fail(err)
 	}
 
-	if err = s.kubeClient.CoreV1().Pods(args.PodNamespace).Bind(context.Background(), binding, metav1.CreateOptions{}); err != nil {
+	if err = s.kubeClient.CoreV1().Pods(args.PodNamespace).Bind(ctx, binding, metav1.CreateOptions{}); err != nil {
 		klog.ErrorS(err, "Failed to bind pod", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
 		return fail(err)
 	}
@@ -1065,7 +1167,20 @@ func (s *Scheduler) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.Exten
 }
 
 func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFilterResult, error) {
+	ctx, span := tracing.StartPodSpan(context.Background(), args.Pod, "Scheduler.Filter")
+	res, err := s.filter(ctx, args)
+	if res != nil && res.NodeNames != nil && len(*res.NodeNames) == 1 {
+		span.SetAttributes(attribute.String("k8s.node.name", (*res.NodeNames)[0]))
+	}
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *Scheduler) filter(ctx context.Context, args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFilterResult, error) {
 	klog.InfoS("Starting schedule filter process", "pod", args.Pod.Name, "uuid", args.Pod.UID, "namespace", args.Pod.Namespace)
+	device.RLockConfig()
+	unlockConfig := sync.OnceFunc(device.RUnlockConfig)
+	defer unlockConfig()
 	resourceReqs := device.Resourcereqs(args.Pod)
 
 	hasHAMiResource := false
@@ -1088,7 +1203,16 @@ func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 	if args.Nodes != nil {
 		return s.filterSimulation(args, resourceReqs)
 	}
+	start := time.Now()
+	res, err := s.filterNodes(ctx, args, resourceReqs, unlockConfig)
+	FilterDuration.WithLabelValues(filterResult(res, err)).Observe(time.Since(start).Seconds())
+	return res, err
+}
 
+// filterNodes picks the node the pod's devices fit best on and records the
+// allocation. It calls unlockConfig to release the device config before
+// patching the pod.
+func (s *Scheduler) filterNodes(ctx context.Context, args extenderv1.ExtenderArgs, resourceReqs device.PodDeviceRequests, unlockConfig func()) (*extenderv1.ExtenderFilterResult, error) {
 	if pi, ok := s.podManager.TakeAndDeletePod(args.Pod); ok {
 		s.quotaManager.RmUsage(args.Pod, pi.Devices)
 	}
@@ -1100,7 +1224,10 @@ func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 	if len(failedNodes) != 0 {
 		klog.V(5).InfoS("Nodes failed during usage retrieval", "nodes", failedNodes)
 	}
+	FilterEvaluatedNodes.Observe(float64(len(*nodeUsage)))
+	scoreStart := time.Now()
 	nodeScores, err := s.calcScore(nodeUsage, resourceReqs, args.Pod, failedNodes)
+	ScoreDuration.Observe(time.Since(scoreStart).Seconds())
 	if err != nil {
 		err := fmt.Errorf("calcScore failed %v for pod %v", err, args.Pod.Name)
 		s.recordScheduleFilterResultEvent(args.Pod, EventReasonFilteringFailed, "", err)
@@ -1124,10 +1251,14 @@ func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 	annotations := make(map[string]string)
 	annotations[util.AssignedNodeAnnotations] = m.NodeID
 	annotations[util.AssignedTimeAnnotations] = strconv.FormatInt(time.Now().Unix(), 10)
+	// Clear the phase a previous attempt left, so a pod failed by a bind
+	// timeout is not taken for terminated and its new devices dropped.
+	annotations[util.DeviceBindPhase] = ""
 
 	for _, val := range device.GetDevices() {
 		val.PatchAnnotations(args.Pod, &annotations, m.Devices)
 	}
+	tracing.InjectPodAnnotation(ctx, args.Pod, &annotations)
 
 	rawDevices := m.Devices
 	effectiveDevices := device.CollapseInitContainerUsage(args.Pod, rawDevices)
@@ -1136,13 +1267,16 @@ func (s *Scheduler) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 		if added {
 			s.quotaManager.AddUsage(args.Pod, effectiveDevices) // use collapsed
 		}
+		unlockConfig()
 		err = util.PatchPodAnnotations(args.Pod, annotations)
 		if err != nil {
 			s.recordScheduleFilterResultEvent(args.Pod, EventReasonFilteringFailed, "", err)
+			device.RLockConfig()
 			if added {
 				s.quotaManager.RmUsage(args.Pod, effectiveDevices)
 			}
 			s.podManager.DelPod(args.Pod)
+			device.RUnlockConfig()
 			return nil, err
 		}
 	}
@@ -407,8 +407,13 @@ func (s *Scheduler) calcScoreWithOptions(nodes *map[string]*NodeUsage, resourceR
 	wg.Wait()
 	close(errCh)
 
-	if recordEvents && len(res.NodeList) == 0 {
-		s.recordFilteringFailures(task, failureReason)
+	if recordEvents {
+		for reason, nodeIDs := range failureReason {
+			FilterNodeFailuresTotal.WithLabelValues(reason).Add(float64(len(nodeIDs)))
+		}
+		if len(res.NodeList) == 0 {
+			s.recordFilteringFailures(task, failureReason)
+		}
 	}
 
 	var errorsSlice []error
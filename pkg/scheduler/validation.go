@@ -0,0 +1,300 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
+)
+
+// hamiAnnotationPrefix is the annotation namespace owned by HAMi. Keys under
+// it that no component reads are reported as admission warnings, since they
+// are almost always typos of a recognized key.
+const hamiAnnotationPrefix = "hami.io/"
+
+// knownGPUPolicies are the tokens accepted in a gpu-scheduler-policy chain.
+var knownGPUPolicies = []util.SchedulerPolicyName{
+	util.GPUSchedulerPolicyBinpack,
+	util.GPUSchedulerPolicySpread,
+	util.GPUSchedulerPolicyTopology,
+	util.GPUSchedulerPolicyMutex,
+	util.GPUSchedulerPolicyNuma,
+}
+
+// knownNodePolicies are the values accepted for node-scheduler-policy.
+var knownNodePolicies = []util.SchedulerPolicyName{
+	util.NodeSchedulerPolicyBinpack,
+	util.NodeSchedulerPolicySpread,
+}
+
+// knownAllocateModes are the tokens accepted in nvidia.com/vgpu-mode.
+var knownAllocateModes = nvidia.KnownModes
+
+// validatePodAnnotations parses every HAMi-recognized pod annotation with the
+// same parser the scheduler uses at Filter time, so a malformed value is
+// rejected at admission instead of silently matching nothing later. All
+// errors are joined so the user can fix them in one round trip. Unknown
+// hami.io/* keys are not an error but are returned as warnings.
+func validatePodAnnotations(annos map[string]string) ([]string, error) {
+	var errs []error
+	var warnings []string
+	for _, key := range sortedKeys(annos) {
+		value := annos[key]
+		switch key {
+		case util.GPUSchedulerPolicyAnnotationKey:
+			errs = append(errs, validateGPUPolicy(key, value))
+		case util.NodeSchedulerPolicyAnnotationKey:
+			errs = append(errs, validateNodePolicy(key, value))
+		case util.DeviceScoringWeightsAnnotationKey:
+			if _, err := util.ParseDeviceScoringWeights(value); err != nil {
+				errs = append(errs, err)
+			}
+		case nvidia.GPUInUse, nvidia.GPUNoUse:
+			errs = append(errs, validateTypeList(key, value))
+		case nvidia.GPUUseUUID, nvidia.GPUNoUseUUID:
+			errs = append(errs, validateUUIDList(key, value))
+		case nvidia.NumaBind:
+			if _, err := strconv.ParseBool(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s annotation %q: must be a boolean", key, value))
+			}
+		case nvidia.AllocateMode:
+			errs = append(errs, validateAllocateMode(key, value))
+		case nvidia.CoreBurstRatioAnnotation:
+			if _, err := nvidia.ParseCoreBurstRatio(value); err != nil {
+				errs = append(errs, err)
+			}
+		case nvidia.ResizeAnnotation:
+			if _, err := nvidia.ParseResizeRequest(value); err != nil {
+				errs = append(errs, err)
+			}
+		case nvidia.DeviceCordonAnnotation:
+			warnings = append(warnings, fmt.Sprintf("annotation %s is only read from nodes and has no effect on pods", key))
+		default:
+			if strings.HasPrefix(key, hamiAnnotationPrefix) && !isKnownPodAnnotation(key) {
+				warnings = append(warnings, fmt.Sprintf("unknown annotation %s is ignored by HAMi", key))
+			}
+		}
+	}
+	warnings = append(warnings, typeListOverlapWarnings(annos)...)
+	return warnings, errors.Join(errs...)
+}
+
+// validateResizeUpdate checks the vGPU resize request on an update of a
+// running pod against the containers and devices the pod was allocated.
+// Requests the scheduler already answered are not checked again, so other
+// updates of the pod are never blocked by them.
+func validateResizeUpdate(pod *corev1.Pod) error {
+	request, ok := pod.Annotations[nvidia.ResizeAnnotation]
+	if !ok {
+		return nil
+	}
+	if status, ok := nvidia.DecodeResizeStatus(pod.Annotations); ok && status.Request == request {
+		return nil
+	}
+	req, err := nvidia.ParseResizeRequest(request)
+	if err != nil {
+		return err
+	}
+	allocated, err := device.DecodePodDevices(device.SupportDevices, pod.Annotations)
+	if err != nil || len(allocated[nvidia.NvidiaGPUDevice]) == 0 {
+		return fmt.Errorf("pod has no NVIDIA devices allocated to resize")
+	}
+	_, err = nvidia.ResizeContainerDevices(pod, allocated[nvidia.NvidiaGPUDevice], req)
+	return err
+}
+
+// validateNodeAnnotations is the node-side counterpart of
+// validatePodAnnotations. Only the operator-managed device-cordon annotation is
+// parsed; the registration and handshake annotations are written by the
+// device plugins and are recognized by prefix.
+func validateNodeAnnotations(annos map[string]string) ([]string, error) {
+	var errs []error
+	var warnings []string
+	for _, key := range sortedKeys(annos) {
+		value := annos[key]
+		switch key {
+		case nvidia.DeviceCordonAnnotation:
+			errs = append(errs, validateUUIDList(key, value))
+		default:
+			if strings.HasPrefix(key, hamiAnnotationPrefix) && !isKnownNodeAnnotation(key) {
+				warnings = append(warnings, fmt.Sprintf("unknown annotation %s is ignored by HAMi", key))
+			}
+		}
+	}
+	return warnings, errors.Join(errs...)
+}
+
+func validateGPUPolicy(key, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("invalid %s annotation %q: must not be empty", key, value)
+	}
+	for i, p := range strings.Split(value, ",") {
+		token := strings.TrimSpace(p)
+		if token == "" {
+			return fmt.Errorf("invalid %s annotation %q: empty policy at position %d", key, value, i+1)
+		}
+		if !slices.ContainsFunc(knownGPUPolicies, func(name util.SchedulerPolicyName) bool {
+			return util.PolicyContains(token, name)
+		}) {
+			return fmt.Errorf("invalid %s annotation %q: unknown policy %q, expected one of %s", key, value, token, joinPolicies(knownGPUPolicies))
+		}
+	}
+	return nil
+}
+
+func validateNodePolicy(key, value string) error {
+	for _, name := range knownNodePolicies {
+		if value == name.String() {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s annotation %q: expected one of %s", key, value, joinPolicies(knownNodePolicies))
+}
+
+// validateTypeList checks a use/nouse card-type list. device.CheckType skips
+// empty entries and matches by substring, so a stray separator or an empty
+// list would quietly turn the constraint into a no-op.
+func validateTypeList(key, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("invalid %s annotation %q: must list at least one card type", key, value)
+	}
+	for i, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return fmt.Errorf("invalid %s annotation %q: empty card type at position %d", key, value, i+1)
+		}
+		if strings.ContainsAny(t, ";:=|") {
+			return fmt.Errorf("invalid %s annotation %q: card type %q contains a separator, use ',' between types", key, value, t)
+		}
+	}
+	return nil
+}
+
+// validateUUIDList checks a comma-separated device UUID list as consumed by
+// device.CheckUUID and the device-cordon lookup.
+func validateUUIDList(key, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	for i, id := range strings.Split(value, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return fmt.Errorf("invalid %s annotation %q: empty uuid at position %d", key, value, i+1)
+		}
+		if strings.ContainsAny(id, " ;:=|") {
+			return fmt.Errorf("invalid %s annotation %q: uuid %q contains a separator, use ',' between uuids", key, value, id)
+		}
+		if _, dup := seen[id]; dup {
+			return fmt.Errorf("invalid %s annotation %q: duplicate uuid %q", key, value, id)
+		}
+		seen[id] = struct{}{}
+	}
+	return nil
+}
+
+func validateAllocateMode(key, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("invalid %s annotation %q: must not be empty", key, value)
+	}
+	for m := range strings.SplitSeq(value, ",") {
+		m = strings.TrimSpace(m)
+		if !slices.Contains(knownAllocateModes, m) {
+			return fmt.Errorf("invalid %s annotation %q: unknown mode %q, expected one of %s", key, value, m, strings.Join(knownAllocateModes, ", "))
+		}
+	}
+	return nil
+}
+
+// typeListOverlapWarnings flags card types listed in both use-gputype and
+// nouse-gputype, which makes them unschedulable for the pod.
+func typeListOverlapWarnings(annos map[string]string) []string {
+	use, ok := annos[nvidia.GPUInUse]
+	if !ok {
+		return nil
+	}
+	noUse, ok := annos[nvidia.GPUNoUse]
+	if !ok {
+		return nil
+	}
+	var warnings []string
+	for t := range strings.SplitSeq(use, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" && !device.CheckType(map[string]string{nvidia.GPUNoUse: noUse}, t, nvidia.GPUInUse, nvidia.GPUNoUse) {
+			warnings = append(warnings, fmt.Sprintf("card type %q is excluded by %s and can never be selected", t, nvidia.GPUNoUse))
+		}
+	}
+	return warnings
+}
+
+func isKnownPodAnnotation(key string) bool {
+	switch key {
+	case util.AssignedTimeAnnotations, util.AssignedNodeAnnotations, util.BindTimeAnnotations,
+		util.DeviceBindPhase, nvidia.MigAllocationsAnnotation, nvidia.DeviceModesAnnotation, nvidia.ResizeStatusAnnotation,
+		tracing.TraceContextAnnotation:
+		return true
+	}
+	for _, anno := range device.InRequestDevices {
+		if anno == key {
+			return true
+		}
+	}
+	for _, anno := range device.SupportDevices {
+		if anno == key {
+			return true
+		}
+	}
+	// Per-vendor UUID selectors, e.g. hami.io/use-Ascend910B-uuid.
+	return (strings.HasPrefix(key, hamiAnnotationPrefix+"use-") || strings.HasPrefix(key, hamiAnnotationPrefix+"no-use-")) &&
+		strings.HasSuffix(key, "-uuid")
+}
+
+func isKnownNodeAnnotation(key string) bool {
+	// Registration, handshake and score annotations are vendor specific and
+	// all live under hami.io/node-. Per-pod locks carry the pod UID.
+	return key == nodelock.NodeLockKey || strings.HasPrefix(key, nodelock.PodLockKeyPrefix) ||
+		strings.HasPrefix(key, hamiAnnotationPrefix+"node-")
+}
+
+func joinPolicies(names []util.SchedulerPolicyName) string {
+	s := make([]string, len(names))
+	for i, n := range names {
+		s[i] = n.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
+)
+
+func TestValidatePodAnnotations(t *testing.T) {
+	tests := []struct {
+		name         string
+		annos        map[string]string
+		wantErr      string
+		wantWarnings int
+	}{
+		{name: "no annotations"},
+		{
+			name: "valid recognized annotations",
+			annos: map[string]string{
+				util.GPUSchedulerPolicyAnnotationKey:   "binpack,numa",
+				util.NodeSchedulerPolicyAnnotationKey:  "spread",
+				util.DeviceScoringWeightsAnnotationKey: "slot=1,core=1,memory=3",
+				nvidia.GPUInUse:                        "A100,H100",
+				nvidia.NumaBind:                        "true",
+				nvidia.AllocateMode:                    "hami-core,mig,exclusive",
+				nvidia.GPUUseUUID:                      "GPU-a,GPU-b",
+			},
+		},
+		{
+			name:    "unknown gpu policy",
+			annos:   map[string]string{util.GPUSchedulerPolicyAnnotationKey: "binpak"},
+			wantErr: `unknown policy "binpak"`,
+		},
+		{
+			name:    "empty gpu policy token",
+			annos:   map[string]string{util.GPUSchedulerPolicyAnnotationKey: "binpack,"},
+			wantErr: "empty policy at position 2",
+		},
+		{
+			name:    "invalid node policy",
+			annos:   map[string]string{util.NodeSchedulerPolicyAnnotationKey: "topology-aware"},
+			wantErr: "expected one of binpack, spread",
+		},
+		{
+			name:    "invalid scoring weights",
+			annos:   map[string]string{util.DeviceScoringWeightsAnnotationKey: "slot=1,core=1"},
+			wantErr: "expected slot, core, and memory weights",
+		},
+		{
+			name:    "gpu type with wrong separator",
+			annos:   map[string]string{nvidia.GPUInUse: "A100;H100"},
+			wantErr: `card type "A100;H100" contains a separator`,
+		},
+		{
+			name:    "empty gpu type list",
+			annos:   map[string]string{nvidia.GPUNoUse: " "},
+			wantErr: "must list at least one card type",
+		},
+		{
+			name:    "invalid numa bind",
+			annos:   map[string]string{nvidia.NumaBind: "yes"},
+			wantErr: "must be a boolean",
+		},
+		{
+			name:    "unknown vgpu mode",
+			annos:   map[string]string{nvidia.AllocateMode: "hami"},
+			wantErr: `unknown mode "hami"`,
+		},
+		{
+			name:    "invalid core burst ratio",
+			annos:   map[string]string{nvidia.CoreBurstRatioAnnotation: "1.5"},
+			wantErr: "must be a number between 0 and 1",
+		},
+		{
+			name:    "invalid vgpu resize",
+			annos:   map[string]string{nvidia.ResizeAnnotation: `{"app":{"cores":150}}`},
+			wantErr: "cores must be between 0 and 100",
+		},
+		{
+			name:    "duplicate uuid",
+			annos:   map[string]string{nvidia.GPUNoUseUUID: "GPU-a,GPU-a"},
+			wantErr: `duplicate uuid "GPU-a"`,
+		},
+		{
+			name:         "unknown hami key warns",
+			annos:        map[string]string{"hami.io/gpu-schedular-policy": "binpack"},
+			wantWarnings: 1,
+		},
+		{
+			name:         "device cordon on pod warns",
+			annos:        map[string]string{nvidia.DeviceCordonAnnotation: "GPU-a"},
+			wantWarnings: 1,
+		},
+		{
+			name:         "use and nouse overlap warns",
+			annos:        map[string]string{nvidia.GPUInUse: "A100,H100", nvidia.GPUNoUse: "A100"},
+			wantWarnings: 1,
+		},
+		{
+			name: "vendor uuid selectors are known",
+			annos: map[string]string{
+				"hami.io/use-Ascend910B-uuid":    "a",
+				"hami.io/no-use-Ascend910B-uuid": "b",
+				util.BindTimeAnnotations:         "1",
+			},
+		},
+		{
+			name: "trace context is known",
+			annos: map[string]string{
+				tracing.TraceContextAnnotation: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := validatePodAnnotations(tt.annos)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, warnings, tt.wantWarnings)
+		})
+	}
+}
+
+func TestValidatePodAnnotationsJoinsErrors(t *testing.T) {
+	_, err := validatePodAnnotations(map[string]string{
+		util.GPUSchedulerPolicyAnnotationKey: "binpak",
+		nvidia.NumaBind:                      "yes",
+	})
+	assert.ErrorContains(t, err, util.GPUSchedulerPolicyAnnotationKey)
+	assert.ErrorContains(t, err, nvidia.NumaBind)
+}
+
+func TestValidateNodeAnnotations(t *testing.T) {
+	tests := []struct {
+		name         string
+		annos        map[string]string
+		wantErr      string
+		wantWarnings int
+	}{
+		{name: "empty cordon", annos: map[string]string{nvidia.DeviceCordonAnnotation: ""}},
+		{name: "valid cordon", annos: map[string]string{nvidia.DeviceCordonAnnotation: "GPU-a, GPU-b"}},
+		{
+			name:    "cordon with empty entry",
+			annos:   map[string]string{nvidia.DeviceCordonAnnotation: "GPU-a,,GPU-b"},
+			wantErr: "empty uuid at position 2",
+		},
+		{
+			name:    "cordon with wrong separator",
+			annos:   map[string]string{nvidia.DeviceCordonAnnotation: "GPU-a;GPU-b"},
+			wantErr: "contains a separator",
+		},
+		{
+			name: "registration annotations are known",
+			annos: map[string]string{
+				nvidia.RegisterAnnos:  "[]",
+				nvidia.HandshakeAnnos: "Reported",
+				nvidia.NodeLockNvidia: "",
+			},
+		},
+		{
+			name: "per-pod lock annotations are known",
+			annos: map[string]string{
+				nodelock.PodLockKeyPrefix + "6f1c2a0e-1b2c-4d5e-8f90-a1b2c3d4e5f6": "2026-10-19T10:00:00Z,default,app",
+			},
+		},
+		{
+			name:         "unknown hami key warns",
+			annos:        map[string]string{"hami.io/device-cordn": "GPU-a"},
+			wantWarnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := validateNodeAnnotations(tt.annos)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, warnings, tt.wantWarnings)
+		})
+	}
+}
+
+func TestHandleRejectsInvalidAnnotations(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-pod",
+			Namespace:   "default",
+			Annotations: map[string]string{util.GPUSchedulerPolicyAnnotationKey: "binpak"},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "c"}}},
+	}
+	raw, err := json.Marshal(pod)
+	assert.NoError(t, err)
+
+	wh, err := NewWebHook()
+	assert.NoError(t, err)
+	resp := wh.Handler.Handle(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Namespace: "default",
+			Name:      "test-pod",
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, `unknown policy "binpak"`)
+}
+
+func TestNodeWebhookHandle(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Annotations: map[string]string{
+				nvidia.DeviceCordonAnnotation: "GPU-a",
+				"hami.io/unknown":             "x",
+			},
+		},
+	}
+	raw, err := json.Marshal(node)
+	assert.NoError(t, err)
+
+	wh, err := NewNodeWebHook()
+	assert.NoError(t, err)
+	resp := wh.Handler.Handle(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Name:   "node1",
+			Object: runtime.RawExtension{Raw: raw},
+		},
+	})
+	assert.True(t, resp.Allowed)
+	assert.Len(t, resp.Warnings, 1)
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/http"
 
+	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -31,6 +32,7 @@ import (
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 const template = "Processing admission hook for pod %v/%v, UID: %v"
@@ -50,13 +52,17 @@ func NewWebHook() (*admission.Webhook, error) {
 	return wh, nil
 }
 
-func (h *webhook) Handle(_ context.Context, req admission.Request) admission.Response {
+func (h *webhook) Handle(ctx context.Context, req admission.Request) admission.Response {
 	pod := &corev1.Pod{}
 	err := h.decoder.Decode(req, pod)
 	if err != nil {
 		klog.Errorf("Failed to decode request: %v", err)
 		return admission.Errored(http.StatusBadRequest, err)
 	}
+	ctx, span := tracing.StartPodSpan(ctx, pod, "webhook.Handle")
+	defer span.End()
+	device.RLockConfig()
+	defer device.RUnlockConfig()
 	if len(pod.Spec.Containers) == 0 {
 		klog.Warningf(template+" - Denying admission as pod has no containers", pod.Namespace, pod.Name, pod.UID)
 		return admission.Denied("pod has no containers")
@@ -68,9 +74,34 @@ func (h *webhook) Handle(_ context.Context, req admission.Request) admission.Res
 		return admission.Allowed("pod already has different scheduler assigned")
 	}
 	klog.V(5).Infof(template, pod.Namespace, pod.Name, pod.UID)
+	// Pods are only mutated on creation. An update may carry a vGPU resize
+	// request for the running pod, which must fit what it was allocated.
+	if req.Operation == admissionv1.Update {
+		if err := validateResizeUpdate(pod); err != nil {
+			klog.Infof(template+" - Denying update: %v", pod.Namespace, pod.Name, pod.UID, err)
+			return admission.Denied(err.Error())
+		}
+		return admission.Allowed("")
+	}
+	warnings, err := validatePodAnnotations(pod.Annotations)
+	if err != nil {
+		klog.Warningf(template+" - Denying admission as annotations are invalid: %v", pod.Namespace, pod.Name, pod.UID, err)
+		return admission.Denied(err.Error())
+	}
 	privilegedName, hasPrivileged := privilegedContainerName(pod)
 	hasResource := false
 
+	// 0. Apply namespace defaults before the vendors default the rest
+	var profile *NamespaceProfile
+	if requestsDevices(pod) {
+		profile, err = getNamespaceProfile(pod.Namespace)
+		if err != nil {
+			klog.Errorf(template+" - Failed to load namespace GPU profile: %v", pod.Namespace, pod.Name, pod.UID, err)
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		applyNamespaceDefaults(pod, profile)
+	}
+
 	// 1. Process InitContainers
 	for idx := range pod.Spec.InitContainers {
 		c := &pod.Spec.InitContainers[idx]
@@ -111,15 +142,56 @@ func (h *webhook) Handle(_ context.Context, req admission.Request) admission.Res
 			return admission.Denied("pod has node assigned")
 		}
 	}
+	if hasResource {
+		if err := enforceNamespaceProfile(pod, profile); err != nil {
+			klog.Infof(template+" - Denying admission: %v", pod.Namespace, pod.Name, pod.UID, err)
+			return admission.Denied(err.Error())
+		}
+	}
 	if !fitResourceQuota(pod) {
 		return admission.Denied("exceeding resource quota")
 	}
+	if hasResource {
+		tracing.InjectPodAnnotation(ctx, pod, &pod.Annotations)
+	}
 	marshaledPod, err := json.Marshal(pod)
 	if err != nil {
 		klog.Errorf(template+" - Failed to marshal pod, error: %v", pod.Namespace, pod.Name, pod.UID, err)
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
-	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod).WithWarnings(warnings...)
+}
+
+type nodeWebhook struct {
+	decoder admission.Decoder
+}
+
+// NewNodeWebHook returns a validating webhook for the HAMi annotations that
+// operators set on nodes, such as hami.io/device-cordon.
+func NewNodeWebHook() (*admission.Webhook, error) {
+	logf.SetLogger(klog.NewKlogr())
+	schema := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(schema); err != nil {
+		return nil, err
+	}
+	decoder := admission.NewDecoder(schema)
+	wh := &admission.Webhook{Handler: &nodeWebhook{decoder: decoder}}
+	return wh, nil
+}
+
+func (h *nodeWebhook) Handle(_ context.Context, req admission.Request) admission.Response {
+	node := &corev1.Node{}
+	err := h.decoder.Decode(req, node)
+	if err != nil {
+		klog.Errorf("Failed to decode request: %v", err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	warnings, err := validateNodeAnnotations(node.Annotations)
+	if err != nil {
+		klog.Warningf("Denying update of node %s as annotations are invalid: %v", node.Name, err)
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
 }
 
 func privilegedContainerName(pod *corev1.Pod) (string, bool) {
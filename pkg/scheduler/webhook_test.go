@@ -21,6 +21,8 @@ import (
 	"flag"
 	"testing"
 
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -36,6 +38,7 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/device/hygon"
 	"github.com/Project-HAMi/HAMi/pkg/device/nvidia"
 	"github.com/Project-HAMi/HAMi/pkg/scheduler/config"
+	"github.com/Project-HAMi/HAMi/pkg/util/tracing"
 )
 
 func TestHandle(t *testing.T) {
@@ -1082,3 +1085,122 @@ func TestFitResourceQuota_InitContainerPeakSequence(t *testing.T) {
 		t.Fatal("Step 3 failed: pod2 should be allowed after pod1 init finished (total 10000+20000=30000)")
 	}
 }
+
+func TestHandleRecordsTraceContext(t *testing.T) {
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	t.Cleanup(func() { otel.SetTracerProvider(previousProvider) })
+
+	sConfig := &config.Config{
+		NvidiaConfig: nvidia.NvidiaConfig{
+			ResourceCountName: "hami.io/gpu",
+			DefaultGPUNum:     1,
+		},
+	}
+	if err := config.InitDevicesWithConfig(sConfig); err != nil {
+		t.Fatalf("Failed to initialize devices with config: %v", err)
+	}
+
+	encode := func(pod *corev1.Pod) admission.Request {
+		scheme := runtime.NewScheme()
+		corev1.AddToScheme(scheme)
+		codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+		podBytes, err := runtime.Encode(codec, pod)
+		if err != nil {
+			t.Fatalf("Error encoding pod: %v", err)
+		}
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID: "test-uid", Namespace: "default", Name: pod.Name,
+			Object: runtime.RawExtension{Raw: podBytes},
+		}}
+	}
+	traceContextPatched := func(resp admission.Response) bool {
+		for _, patch := range resp.Patches {
+			switch patch.Path {
+			case "/metadata/annotations":
+				if value, ok := patch.Value.(map[string]any); ok && value[tracing.TraceContextAnnotation] != nil {
+					return true
+				}
+			case "/metadata/annotations/hami.io~1trace-context":
+				return true
+			}
+		}
+		return false
+	}
+
+	wh, err := NewWebHook()
+	if err != nil {
+		t.Fatalf("Error creating WebHook: %v", err)
+	}
+	gpuPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu-pod", Namespace: "default"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "container1",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"hami.io/gpu": resource.MustParse("1")}},
+		}}},
+	}
+	resp := wh.Handle(context.Background(), encode(gpuPod))
+	if !resp.Allowed {
+		t.Fatalf("Expected allowed response, but got: %v", resp)
+	}
+	if !traceContextPatched(resp) {
+		t.Errorf("Expected the trace context annotation to be added, got patches: %+v", resp.Patches)
+	}
+
+	cpuPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "cpu-pod", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "container1"}}},
+	}
+	resp = wh.Handle(context.Background(), encode(cpuPod))
+	if traceContextPatched(resp) {
+		t.Errorf("Expected pods without HAMi resources to be left out of tracing, got patches: %+v", resp.Patches)
+	}
+}
+
+func TestHandleValidatesResizeUpdate(t *testing.T) {
+	sConfig := &config.Config{NvidiaConfig: nvidia.NvidiaConfig{ResourceCountName: "hami.io/gpu", DefaultGPUNum: 1}}
+	if err := config.InitDevicesWithConfig(sConfig); err != nil {
+		t.Fatalf("Failed to initialize devices with config: %v", err)
+	}
+	wh, err := NewWebHook()
+	if err != nil {
+		t.Fatalf("Error creating WebHook: %v", err)
+	}
+	update := func(pod *corev1.Pod) admission.Response {
+		scheme := runtime.NewScheme()
+		corev1.AddToScheme(scheme)
+		codec := serializer.NewCodecFactory(scheme).LegacyCodec(corev1.SchemeGroupVersion)
+		podBytes, err := runtime.Encode(codec, pod)
+		if err != nil {
+			t.Fatalf("Error encoding pod: %v", err)
+		}
+		return wh.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID: "test-uid", Namespace: "default", Name: pod.Name, Operation: admissionv1.Update,
+			Object: runtime.RawExtension{Raw: podBytes},
+		}})
+	}
+
+	pod := newResizeTestPod("resized", 4000)
+	if resp := update(pod); !resp.Allowed || len(resp.Patches) != 0 {
+		t.Errorf("Expected an update without resize to be allowed unchanged, got %v", resp.Result)
+	}
+	pod.Annotations[nvidia.ResizeAnnotation] = `{"app":{"cores":30}}`
+	if resp := update(pod); !resp.Allowed {
+		t.Errorf("Expected a valid resize to be allowed, got %v", resp.Result)
+	}
+	pod.Annotations[nvidia.ResizeAnnotation] = `{"sidecar":{"cores":30}}`
+	if resp := update(pod); resp.Allowed || resp.Result.Message != "container sidecar not found" {
+		t.Errorf("Expected a resize of an unknown container to be denied, got %v", resp.Result)
+	}
+	pod.Annotations[nvidia.ResizeStatusAnnotation] = nvidia.EncodeResizeStatus(nvidia.ResizeStatus{Request: `{"sidecar":{"cores":30}}`, Phase: nvidia.ResizeRejected})
+	if resp := update(pod); !resp.Allowed {
+		t.Errorf("Expected an answered resize not to block updates, got %v", resp.Result)
+	}
+
+	unbound := newResizeTestPod("unbound", 4000)
+	delete(unbound.Annotations, device.SupportDevices[nvidia.NvidiaGPUDevice])
+	unbound.Annotations[nvidia.ResizeAnnotation] = `{"app":{"cores":30}}`
+	if resp := update(unbound); resp.Allowed {
+		t.Error("Expected a resize of a pod without devices to be denied")
+	}
+}
@@ -18,6 +18,7 @@ package nodelock
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -32,6 +33,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 )
@@ -39,6 +41,9 @@ import (
 const (
 	NodeLockKey = "hami.io/mutex.lock"
 	NodeLockSep = ","
+	// PodLockKeyPrefix prefixes the per-pod lock annotations LockNodeForPod
+	// sets, followed by the UID of the pod holding the lock.
+	PodLockKeyPrefix = NodeLockKey + "."
 )
 
 // ErrNodeLockContention indicates the node lock is currently held by another
@@ -54,6 +59,9 @@ var (
 	nodeLocks = newNodeLockManager()
 	// NodeLockTimeout is the global timeout for node locks.
 	NodeLockTimeout time.Duration = time.Minute * 5
+	// PodLister, when set, is read to check that the pod holding a lock still
+	// exists, so that only pods missing from it are looked up on the API server.
+	PodLister listerscorev1.PodLister
 
 	DefaultStrategy = wait.Backoff{
 		Steps:    5,
@@ -156,6 +164,13 @@ func SetNodeLock(nodeName string, lockname string, pods *corev1.Pod) error {
 		if ok {
 			return fmt.Errorf("node %s is locked: %w", nodeName, ErrNodeLockContention)
 		}
+		held, _, err := podLocksHeld(ctx, node, pods)
+		if err != nil {
+			return err
+		}
+		if held > 0 {
+			return fmt.Errorf("node %s has %d pods allocating: %w", nodeName, held, ErrNodeLockContention)
+		}
 		patchData := fmt.Sprintf(`{"metadata":{"annotations":{"%s":"%s"},"resourceVersion":"%s"}}`, NodeLockKey, GenerateNodeLockKeyByPod(pods), node.ResourceVersion)
 		_, err = client.GetClient().CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, []byte(patchData), metav1.PatchOptions{})
 		if err != nil {
@@ -186,6 +201,13 @@ func ReleaseNodeLock(nodeName string, lockname string, pod *corev1.Pod, skipNode
 	if err != nil {
 		return err
 	}
+	// Releasing an expired node-wide lock on behalf of pod leaves the locks
+	// pod holds itself alone.
+	if _, ok := node.Annotations[PodLockKey(pod)]; ok && !skipNodeLockOwnerCheck {
+		if err := releasePodLock(ctx, nodeName, pod); err != nil {
+			return err
+		}
+	}
 
 	lockStr, ok := node.Annotations[NodeLockKey]
 	if !ok {
@@ -290,6 +312,142 @@ func LockNode(nodeName string, lockname string, pods *corev1.Pod) error {
 	return fmt.Errorf("node %s has been locked within %v: %w", nodeName, NodeLockTimeout, ErrNodeLockContention)
 }
 
+// LockNodeForPod locks nodeName for pod while letting up to limit pods
+// allocate on it at once, each holding its own PodLockKey annotation. It fails
+// with ErrNodeLockContention while another pod holds the node-wide lock or
+// limit other pods hold theirs; locks that expired or whose pod is gone are
+// dropped. A limit of 1 or less takes the node-wide lock through LockNode.
+func LockNodeForPod(nodeName string, lockname string, pod *corev1.Pod, limit int) error {
+	if limit <= 1 {
+		return LockNode(nodeName, lockname, pod)
+	}
+	nodeLock := nodeLocks.getLock(nodeName)
+	nodeLock.Lock()
+	defer nodeLock.Unlock()
+
+	ctx := context.Background()
+	key := PodLockKey(pod)
+	err := retry.OnError(DefaultStrategy, func(err error) bool {
+		return !IsNodeLockContention(err)
+	}, func() error {
+		node, err := client.GetClient().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, ok := node.Annotations[key]; ok {
+			return nil
+		}
+		if value, ok := node.Annotations[NodeLockKey]; ok {
+			_, ns, name, err := ParseNodeLock(value)
+			if err != nil {
+				return err
+			}
+			if ns != pod.Namespace || name != pod.Name {
+				alive, err := lockAlive(ctx, value)
+				if err != nil {
+					return err
+				}
+				if alive {
+					return fmt.Errorf("node %s is locked: %w", nodeName, ErrNodeLockContention)
+				}
+			}
+		}
+		held, stale, err := podLocksHeld(ctx, node, pod)
+		if err != nil {
+			return err
+		}
+		if held >= limit {
+			return fmt.Errorf("node %s has %d pods allocating: %w", nodeName, held, ErrNodeLockContention)
+		}
+		annotations := map[string]any{key: GenerateNodeLockKeyByPod(pod)}
+		for _, staleKey := range stale {
+			klog.InfoS("Dropping stale pod lock", "node", nodeName, "lock", staleKey, "value", node.Annotations[staleKey])
+			annotations[staleKey] = nil
+		}
+		patch, err := json.Marshal(map[string]any{
+			"metadata": map[string]any{"annotations": annotations, "resourceVersion": node.ResourceVersion},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = client.GetClient().CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set pod lock (node=%s, retry strategy=%+v): %w", nodeName, DefaultStrategy, err)
+	}
+	klog.InfoS("Pod lock set", "node", nodeName, "podName", pod.Name)
+	return nil
+}
+
+// PodLockKey returns the node annotation holding the per-pod lock of pod.
+func PodLockKey(pod *corev1.Pod) string {
+	return PodLockKeyPrefix + string(pod.UID)
+}
+
+// podLocksHeld counts the live per-pod locks other pods hold on node and
+// returns the keys of the stale ones.
+func podLocksHeld(ctx context.Context, node *corev1.Node, pod *corev1.Pod) (int, []string, error) {
+	held := 0
+	var stale []string
+	for key, value := range node.Annotations {
+		if !strings.HasPrefix(key, PodLockKeyPrefix) || key == PodLockKey(pod) {
+			continue
+		}
+		alive, err := lockAlive(ctx, value)
+		if err != nil {
+			return 0, nil, err
+		}
+		if alive {
+			held++
+		} else {
+			stale = append(stale, key)
+		}
+	}
+	return held, stale, nil
+}
+
+// lockAlive reports whether the lock value has neither expired nor outlived
+// its pod. Malformed values are treated as stale.
+func lockAlive(ctx context.Context, value string) (bool, error) {
+	lockTime, ns, name, err := ParseNodeLock(value)
+	if err != nil || time.Since(lockTime) > NodeLockTimeout {
+		return false, nil
+	}
+	if ns == "" || name == "" {
+		return true, nil
+	}
+	if PodLister != nil {
+		if _, err := PodLister.Pods(ns).Get(name); err == nil {
+			return true, nil
+		}
+		// The informer may not have seen a pod that was just created.
+	}
+	if _, err := client.GetClient().CoreV1().Pods(ns).Get(ctx, name, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// releasePodLock removes the per-pod lock of pod from nodeName.
+func releasePodLock(ctx context.Context, nodeName string, pod *corev1.Pod) error {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{"%s":null}}}`, PodLockKey(pod))
+	err := retry.OnError(DefaultStrategy, func(err error) bool {
+		return !apierrors.IsNotFound(err)
+	}, func() error {
+		_, err := client.GetClient().CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release pod lock (node=%s, retry strategy=%+v): %w", nodeName, DefaultStrategy, err)
+	}
+	klog.InfoS("Pod lock released", "node", nodeName, "podName", pod.Name)
+	return nil
+}
+
 func ParseNodeLock(value string) (lockTime time.Time, ns, name string, err error) {
 	if !strings.Contains(value, NodeLockSep) {
 		lockTime, err = time.Parse(time.RFC3339, value)
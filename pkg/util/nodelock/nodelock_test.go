@@ -30,8 +30,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
 	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 )
@@ -957,3 +960,107 @@ func TestSetupNodeLockTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestLockNodeForPod(t *testing.T) {
+	client.KubeClient = fake.NewClientset()
+	nodeLocks = newNodeLockManager()
+	nodeName := "shared-node"
+	ctx := context.TODO()
+	if _, err := client.KubeClient.CoreV1().Nodes().Create(ctx, &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	newPod := func(name string) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns", UID: types.UID("uid-" + name)}}
+		if _, err := client.KubeClient.CoreV1().Pods("test-ns").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+		return pod
+	}
+	podA, podB, podC := newPod("a"), newPod("b"), newPod("c")
+
+	for _, pod := range []*corev1.Pod{podA, podB} {
+		if err := LockNodeForPod(nodeName, NodeLockKey, pod, 2); err != nil {
+			t.Fatalf("LockNodeForPod(%s) failed: %v", pod.Name, err)
+		}
+	}
+	if err := LockNodeForPod(nodeName, NodeLockKey, podA, 2); err != nil {
+		t.Fatalf("relocking a pod lock should succeed, got: %v", err)
+	}
+	if err := LockNodeForPod(nodeName, NodeLockKey, podC, 2); !IsNodeLockContention(err) {
+		t.Fatalf("a third pod should contend, got: %v", err)
+	}
+	if err := LockNode(nodeName, NodeLockKey, podC); !IsNodeLockContention(err) {
+		t.Fatalf("the node-wide lock should contend with pod locks, got: %v", err)
+	}
+
+	// A pod lock outliving its pod does not count.
+	if err := client.KubeClient.CoreV1().Pods("test-ns").Delete(ctx, podB.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete pod: %v", err)
+	}
+	if err := LockNodeForPod(nodeName, NodeLockKey, podC, 2); err != nil {
+		t.Fatalf("LockNodeForPod should drop the lock of the deleted pod, got: %v", err)
+	}
+	node, _ := client.KubeClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if _, ok := node.Annotations[PodLockKey(podB)]; ok {
+		t.Errorf("stale lock %s was not dropped", PodLockKey(podB))
+	}
+
+	for _, pod := range []*corev1.Pod{podA, podC} {
+		if err := ReleaseNodeLock(nodeName, NodeLockKey, pod, false); err != nil {
+			t.Fatalf("ReleaseNodeLock(%s) failed: %v", pod.Name, err)
+		}
+	}
+	node, _ = client.KubeClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	for key := range node.Annotations {
+		if strings.HasPrefix(key, PodLockKeyPrefix) {
+			t.Errorf("lock %s was not released", key)
+		}
+	}
+
+	// The node-wide lock of another pod excludes pod locks.
+	if err := LockNode(nodeName, NodeLockKey, podA); err != nil {
+		t.Fatalf("LockNode failed: %v", err)
+	}
+	if err := LockNodeForPod(nodeName, NodeLockKey, podC, 2); !IsNodeLockContention(err) {
+		t.Fatalf("pod locks should contend with the node-wide lock, got: %v", err)
+	}
+}
+
+func TestLockAliveReadsPodLister(t *testing.T) {
+	cached := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cached", Namespace: "test-ns"}}
+	uncached := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "uncached", Namespace: "test-ns"}}
+	fakeClient := fake.NewClientset(cached, uncached)
+	gets := 0
+	fakeClient.PrependReactor("get", "pods", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gets++
+		return false, nil, nil
+	})
+	client.KubeClient = fakeClient
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := indexer.Add(cached); err != nil {
+		t.Fatal(err)
+	}
+	PodLister = listerscorev1.NewPodLister(indexer)
+	defer func() { PodLister = nil }()
+
+	ctx := context.TODO()
+	for _, tc := range []struct {
+		pod       *corev1.Pod
+		wantAlive bool
+		wantGets  int
+	}{
+		{pod: cached, wantAlive: true, wantGets: 0},
+		{pod: uncached, wantAlive: true, wantGets: 1},
+		{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "gone", Namespace: "test-ns"}}, wantAlive: false, wantGets: 2},
+	} {
+		alive, err := lockAlive(ctx, GenerateNodeLockKeyByPod(tc.pod))
+		if err != nil {
+			t.Fatalf("lockAlive(%s) failed: %v", tc.pod.Name, err)
+		}
+		if alive != tc.wantAlive || gets != tc.wantGets {
+			t.Errorf("lockAlive(%s) = %v after %d pod GETs, want %v after %d", tc.pod.Name, alive, gets, tc.wantAlive, tc.wantGets)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing exports OpenTelemetry spans for the phases a pod goes
+// through on its way to a device: admission, filter, bind and the device
+// plugin's Allocate. The phases run in different processes, so the trace
+// context travels between them in the TraceContextAnnotation of the pod.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"github.com/urfave/cli/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/version"
+)
+
+const (
+	// TraceContextAnnotation holds the W3C traceparent of the trace a pod's
+	// scheduling is recorded in.
+	TraceContextAnnotation = "hami.io/trace-context"
+
+	instrumentationName = "github.com/Project-HAMi/HAMi"
+	traceparentHeader   = "traceparent"
+)
+
+// Options configures the OTLP exporter. Tracing is off while Endpoint is
+// empty.
+type Options struct {
+	Endpoint      string
+	Insecure      bool
+	SamplingRatio float64
+}
+
+const (
+	endpointFlag      = "tracing-endpoint"
+	insecureFlag      = "tracing-insecure"
+	samplingRatioFlag = "tracing-sampling-ratio"
+
+	endpointUsage      = "OTLP gRPC endpoint (host:port) to export traces of pod scheduling and allocation to. Empty disables tracing"
+	insecureUsage      = "Export traces without TLS"
+	samplingRatioUsage = "Fraction of new traces sampled; pods whose trace is already sampled are always recorded"
+)
+
+// AddFlags registers the tracing flags of the scheduler and vGPUmonitor.
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Endpoint, endpointFlag, "", endpointUsage)
+	fs.BoolVar(&o.Insecure, insecureFlag, false, insecureUsage)
+	fs.Float64Var(&o.SamplingRatio, samplingRatioFlag, 1, samplingRatioUsage)
+}
+
+// CliFlags returns the tracing flags of the device plugin.
+func CliFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: endpointFlag, Usage: endpointUsage, EnvVars: []string{"TRACING_ENDPOINT"}},
+		&cli.BoolFlag{Name: insecureFlag, Usage: insecureUsage, EnvVars: []string{"TRACING_INSECURE"}},
+		&cli.Float64Flag{Name: samplingRatioFlag, Value: 1, Usage: samplingRatioUsage, EnvVars: []string{"TRACING_SAMPLING_RATIO"}},
+	}
+}
+
+// OptionsFromCli reads the flags registered by CliFlags.
+func OptionsFromCli(c *cli.Context) Options {
+	return Options{
+		Endpoint:      c.String(endpointFlag),
+		Insecure:      c.Bool(insecureFlag),
+		SamplingRatio: c.Float64(samplingRatioFlag),
+	}
+}
+
+// Init installs the global tracer provider for service. The returned function
+// flushes and stops the exporter; it is a no-op when tracing is off.
+func Init(ctx context.Context, service string, opts Options) (func(context.Context) error, error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if opts.SamplingRatio < 0 || opts.SamplingRatio > 1 {
+		return nil, fmt.Errorf("tracing sampling ratio %v is not between 0 and 1", opts.SamplingRatio)
+	}
+	clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(opts.Endpoint)}
+	if opts.Insecure {
+		clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %v", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", service),
+		attribute.String("service.version", version.Version().Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SamplingRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	klog.InfoS("Exporting traces", "endpoint", opts.Endpoint, "service", service, "samplingRatio", opts.SamplingRatio)
+	return provider.Shutdown, nil
+}
+
+// StartPodSpan starts the span of a phase of pod's scheduling, as a child of
+// the trace recorded in the pod's TraceContextAnnotation if it has one.
+func StartPodSpan(ctx context.Context, pod *corev1.Pod, name string) (context.Context, trace.Span) {
+	if v, ok := pod.Annotations[TraceContextAnnotation]; ok {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceparentHeader: v})
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(
+		attribute.String("k8s.namespace.name", pod.Namespace),
+		attribute.String("k8s.pod.name", pod.Name),
+		attribute.String("k8s.pod.uid", string(pod.UID)),
+	))
+}
+
+// InjectPodAnnotation records the span in ctx in annotations unless pod is
+// already part of a trace, so that the later phases join the trace. Nothing
+// is recorded while tracing is off.
+func InjectPodAnnotation(ctx context.Context, pod *corev1.Pod, annotations *map[string]string) {
+	if _, ok := pod.Annotations[TraceContextAnnotation]; ok {
+		return
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	v, ok := carrier[traceparentHeader]
+	if !ok {
+		return
+	}
+	if *annotations == nil {
+		*annotations = map[string]string{}
+	}
+	(*annotations)[TraceContextAnnotation] = v
+}
+
+// End ends span, marking it failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordSpans installs a tracer provider that samples every span and returns
+// the recorder the ended spans end up in.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func testPod() *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "default", UID: "uid1"}}
+}
+
+func TestInitDisabled(t *testing.T) {
+	shutdown, err := Init(context.Background(), "test", Options{SamplingRatio: 5})
+	if err != nil {
+		t.Fatalf("Init without an endpoint failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown failed: %v", err)
+	}
+
+	_, err = Init(context.Background(), "test", Options{Endpoint: "localhost:4317", SamplingRatio: 1.5})
+	if err == nil {
+		t.Error("expected an out of range sampling ratio to be rejected")
+	}
+}
+
+func TestInjectPodAnnotationTracingOff(t *testing.T) {
+	pod := testPod()
+	ctx, span := StartPodSpan(context.Background(), pod, "webhook.Handle")
+	defer span.End()
+	InjectPodAnnotation(ctx, pod, &pod.Annotations)
+	if pod.Annotations != nil {
+		t.Errorf("expected no annotations while tracing is off, got %v", pod.Annotations)
+	}
+}
+
+func TestPodTracePropagation(t *testing.T) {
+	recorder := recordSpans(t)
+
+	pod := testPod()
+	ctx, admission := StartPodSpan(context.Background(), pod, "webhook.Handle")
+	InjectPodAnnotation(ctx, pod, &pod.Annotations)
+	admission.End()
+	traceContext, ok := pod.Annotations[TraceContextAnnotation]
+	if !ok {
+		t.Fatal("expected the admission span to be recorded on the pod")
+	}
+
+	ctx, filter := StartPodSpan(context.Background(), pod, "Scheduler.Filter")
+	patch := map[string]string{}
+	InjectPodAnnotation(ctx, pod, &patch)
+	if len(patch) != 0 {
+		t.Errorf("a pod already in a trace must keep it, got patch %v", patch)
+	}
+	End(filter, errors.New("no available node"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	root, child := spans[0], spans[1]
+	if child.SpanContext().TraceID() != root.SpanContext().TraceID() {
+		t.Error("expected the filter span to join the admission trace")
+	}
+	if child.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Error("expected the admission span to be the parent of the filter span")
+	}
+	if want := "00-" + root.SpanContext().TraceID().String() + "-" + root.SpanContext().SpanID().String() + "-01"; traceContext != want {
+		t.Errorf("expected annotation %q, got %q", want, traceContext)
+	}
+	if child.Status().Code != codes.Error || child.Status().Description != "no available node" {
+		t.Errorf("expected the failed filter span to carry the error, got %+v", child.Status())
+	}
+	found := false
+	for _, attr := range child.Attributes() {
+		if attr.Key == "k8s.pod.uid" && attr.Value.AsString() == "uid1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the pod UID among the span attributes, got %v", child.Attributes())
+	}
+}
+
+func TestStartPodSpanIgnoresMalformedAnnotation(t *testing.T) {
+	recorder := recordSpans(t)
+
+	pod := testPod()
+	pod.Annotations = map[string]string{TraceContextAnnotation: "not-a-traceparent"}
+	_, span := StartPodSpan(context.Background(), pod, "Scheduler.Bind")
+	span.End()
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Parent().IsValid() {
+		t.Errorf("expected a malformed annotation to start a new trace, got %v", spans)
+	}
+}
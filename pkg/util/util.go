@@ -91,32 +91,31 @@ func GetPendingPod(ctx context.Context, node string) (*corev1.Pod, error) {
 		return nil, err
 	}
 	for _, p := range podlist.Items {
-		if p.Status.Phase != corev1.PodPending {
-			continue
-		}
-		if _, ok := p.Annotations[BindTimeAnnotations]; !ok {
-			continue
-		}
-		if phase, ok := p.Annotations[DeviceBindPhase]; !ok {
-			continue
-		} else {
-			// Allow both "allocating" and "success" phases for multi-container pods
-			// where some containers have already been allocated but others are still pending
-			if phase != DeviceBindAllocating && phase != DeviceBindSuccess {
-				continue
-			}
-		}
-		if n, ok := p.Annotations[AssignedNodeAnnotations]; !ok {
-			continue
-		} else {
-			if strings.Compare(n, node) == 0 {
-				return &p, nil
-			}
+		if IsBindingPod(&p, node) {
+			return &p, nil
 		}
 	}
 	return nil, fmt.Errorf("no binding pod found on node %s", node)
 }
 
+// IsBindingPod reports whether p is a pending pod the scheduler bound to node
+// whose devices are still being handed out by the device plugins.
+func IsBindingPod(p *corev1.Pod, node string) bool {
+	if p.Status.Phase != corev1.PodPending {
+		return false
+	}
+	if _, ok := p.Annotations[BindTimeAnnotations]; !ok {
+		return false
+	}
+	// Allow both "allocating" and "success" phases for multi-container pods
+	// where some containers have already been allocated but others are still pending
+	if phase := p.Annotations[DeviceBindPhase]; phase != DeviceBindAllocating && phase != DeviceBindSuccess {
+		return false
+	}
+	assigned, ok := p.Annotations[AssignedNodeAnnotations]
+	return ok && assigned == node
+}
+
 func GetAllocatePodByNode(ctx context.Context, nodeName string) (*corev1.Pod, error) {
 	node, err := client.GetClient().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
@@ -320,6 +319,15 @@ func AllContainersCreated(pod *corev1.Pod) bool {
 
 // EmitNodeWarningEvent emits a Warning event on the given Node with deduplication.
 func EmitNodeWarningEvent(node *corev1.Node, reason, message string, dedupWindow time.Duration) {
+	emitNodeEvent(node, corev1.EventTypeWarning, reason, message, dedupWindow)
+}
+
+// EmitNodeNormalEvent emits a Normal event on the given Node with deduplication.
+func EmitNodeNormalEvent(node *corev1.Node, reason, message string, dedupWindow time.Duration) {
+	emitNodeEvent(node, corev1.EventTypeNormal, reason, message, dedupWindow)
+}
+
+func emitNodeEvent(node *corev1.Node, eventType, reason, message string, dedupWindow time.Duration) {
 	c := client.GetClient()
 	if c == nil {
 		klog.Warningf("cannot emit node event for %s: Kubernetes client not initialized", node.Name)
@@ -378,7 +386,7 @@ func EmitNodeWarningEvent(node *corev1.Node, reason, message string, dedupWindow
 		},
 		Reason:         reason,
 		Message:        message,
-		Type:           corev1.EventTypeWarning,
+		Type:           eventType,
 		Count:          1,
 		FirstTimestamp: now,
 		LastTimestamp:  now,
@@ -827,6 +827,20 @@ func TestSchedulerPolicyName_String(t *testing.T) {
 	}
 }
 
+func TestEmitNodeNormalEvent(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "test-node", UID: types.UID("test-uid-1234")}}
+	client.KubeClient = fake.NewClientset()
+
+	EmitNodeNormalEvent(node, "GPURecovered", "device recovered", time.Hour)
+
+	events, err := client.KubeClient.CoreV1().Events(corev1.NamespaceDefault).List(
+		context.TODO(), metav1.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, "GPURecovered", events.Items[0].Reason)
+	assert.Equal(t, corev1.EventTypeNormal, events.Items[0].Type)
+}
+
 func TestEmitNodeWarningEvent(t *testing.T) {
 	const (
 		nodeName = "test-node"